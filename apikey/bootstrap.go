@@ -0,0 +1,59 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"go-app/domain"
+	"time"
+)
+
+// bootstrapKeyName is the name of the seeded key, it is the only key in the listings not issued through the API.
+const bootstrapKeyName = "bootstrap"
+
+// bootstrapScopes are every scope of the service, keys are only granted scopes their issuer holds.
+var bootstrapScopes = []string{
+	domain.ScopeAPIKeysAdmin,
+	domain.ScopeUsersRead,
+	domain.ScopeUsersWrite,
+	domain.ScopeUsersAdmin,
+	domain.ScopeWebhooksAdmin,
+}
+
+/*
+Bootstrap stores the key of AUTH_BOOTSTRAP_API_KEY with every scope, unless it is stored already, so the first keys
+can be issued without a bearer token. A revoked bootstrap key stays revoked across restarts, another prefix has to be
+configured to seed a new one.
+*/
+func Bootstrap(ctx context.Context, repo domain.APIKeyRepository, key string) error {
+	prefix, ok := parseKey(key)
+	if !ok {
+		return errors.New("AUTH_BOOTSTRAP_API_KEY must have the form gak_<12 hex>_<64 hex>")
+	}
+
+	existing, err := repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err == nil {
+		if subtle.ConstantTimeCompare([]byte(existing.Hash), []byte(hashKey(key))) != 1 {
+			return fmt.Errorf("the prefix %s of AUTH_BOOTSTRAP_API_KEY belongs to another key", prefix)
+		}
+		return nil
+	}
+	if err.Code != domain.ErrCodeNotFound {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := repo.CreateAPIKey(ctx, domain.APIKey{
+		Name:      bootstrapKeyName,
+		Prefix:    prefix,
+		Hash:      hashKey(key),
+		Scopes:    bootstrapScopes,
+		CreatedBy: "AUTH_BOOTSTRAP_API_KEY",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package apikey
+
+import (
+	sentrygin "github.com/getsentry/sentry-go/gin"
+	"github.com/gin-gonic/gin"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type Handler struct {
+	apiKeyUseCase domain.APIKeyUseCase
+	logger        *zap.Logger
+}
+
+func NewAPIKeyHandler(apiKeyUseCase domain.APIKeyUseCase, logger *zap.Logger) *Handler {
+	return &Handler{apiKeyUseCase: apiKeyUseCase, logger: logger}
+}
+
+// CreateAPIKey godoc
+// @Summary Issue API Key
+// @Description Issue an API key with the given scopes, the caller has to hold them. The response holds the key, it is not returned again.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param apiKey body domain.APIKeyRequest true "API key to be issued"
+// @Success 201 {object} domain.APIKey "Returns issued API key"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 403 {object} domain.ProblemDetails "Returns error when the caller does not hold a scope"
+// @Router /api/v1/api-keys [post]
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		var request domain.APIKeyRequest
+		if c.ShouldBindJSON(&request) != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		apiKey, err := h.apiKeyUseCase.CreateAPIKey(c.Request.Context(), request)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusCreated, apiKey)
+	}
+}
+
+// ListAPIKeys godoc
+// @Summary List API Keys
+// @Description List all API keys, revoked ones included, without the keys themselves.
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} domain.APIKey "Returns API keys"
+// @Router /api/v1/api-keys [get]
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		apiKeys, err := h.apiKeyUseCase.ListAPIKeys(c.Request.Context())
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		if apiKeys == nil {
+			apiKeys = []domain.APIKey{}
+		}
+		c.JSON(http.StatusOK, apiKeys)
+	}
+}
+
+// GetAPIKey godoc
+// @Summary Get an API key by ID
+// @Description Retrieve an API key, without the key itself.
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} domain.APIKey "Returns API key"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/api-keys/{id} [get]
+func (h *Handler) GetAPIKey(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		apiKey, err := h.apiKeyUseCase.GetAPIKey(c.Request.Context(), id)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiKey)
+	}
+}
+
+// UpdateAPIKey godoc
+// @Summary Update API Key
+// @Description Replace the name, scopes and expiry of an API key, the caller has to hold the scopes. The key itself does not change.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Param apiKey body domain.APIKeyRequest true "API key to be updated"
+// @Success 200 {object} domain.APIKey "Returns updated API key"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 403 {object} domain.ProblemDetails "Returns error when the caller does not hold a scope"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 409 {object} domain.ProblemDetails "Returns error when the key is revoked"
+// @Router /api/v1/api-keys/{id} [put]
+func (h *Handler) UpdateAPIKey(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		var request domain.APIKeyRequest
+		if c.ShouldBindJSON(&request) != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		apiKey, err := h.apiKeyUseCase.UpdateAPIKey(c.Request.Context(), id, request)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiKey)
+	}
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke API Key
+// @Description Stop an API key from authenticating. The key stays listed with the time it was revoked.
+// @Tags api-keys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} domain.APIKey "Returns revoked API key"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/api-keys/{id}/revoke [post]
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		apiKey, err := h.apiKeyUseCase.RevokeAPIKey(c.Request.Context(), id)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, apiKey)
+	}
+}
+
+func errorResponse(c *gin.Context, err *domain.AppError) {
+	c.Header("Content-Type", domain.ProblemContentType)
+	if err.Retryable {
+		c.Header("Retry-After", "1")
+	}
+	c.JSON(err.Status, err.Problem(c.Request.URL.Path))
+}
+
+func parseId(c *gin.Context) (uint, *domain.AppError) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, domain.NewBadRequestError("Invalid API key ID: " + c.Param("id"))
+	}
+	return uint(id), nil
+}
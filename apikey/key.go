@@ -0,0 +1,49 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+/*
+A key is "gak_<12 hex ID>_<64 hex secret>". The part up to the secret is the prefix the key is looked up by,
+the secret holds 256 random bits, so a single SHA-256 is enough to store it, there is nothing to brute force.
+*/
+const (
+	keyScheme    = "gak_"
+	keyIDLength  = 6
+	secretLength = 32
+)
+
+func newKey() (key string, prefix string, err error) {
+	id := make([]byte, keyIDLength)
+	secret := make([]byte, secretLength)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	prefix = keyScheme + hex.EncodeToString(id)
+	return prefix + "_" + hex.EncodeToString(secret), prefix, nil
+}
+
+// parseKey returns the prefix of a well-formed key.
+func parseKey(key string) (string, bool) {
+	rest, found := strings.CutPrefix(key, keyScheme)
+	if !found {
+		return "", false
+	}
+	id, secret, found := strings.Cut(rest, "_")
+	if !found || len(id) != 2*keyIDLength || len(secret) != 2*secretLength {
+		return "", false
+	}
+	return keyScheme + id, true
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-app/database"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks/mockAPIKeyRepository.go -package=mocks go-app/domain APIKeyRepository
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) domain.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, apiKey domain.APIKey) (domain.APIKey, *domain.AppError) {
+	if err := database.Conn(ctx, r.db).Create(&apiKey).Error; err != nil {
+		return apiKey, database.TranslateError(err)
+	}
+	return apiKey, nil
+}
+
+func (r *apiKeyRepository) GetAPIKey(ctx context.Context, id uint) (domain.APIKey, *domain.AppError) {
+	var apiKey domain.APIKey
+	err := database.Conn(ctx, r.db).First(&apiKey, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apiKey, apiKeyNotFound(id)
+	}
+	if err != nil {
+		return apiKey, database.TranslateError(err)
+	}
+	return apiKey, nil
+}
+
+func (r *apiKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (domain.APIKey, *domain.AppError) {
+	var apiKey domain.APIKey
+	err := database.Conn(ctx, r.db).Where("prefix = ?", prefix).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apiKey, domain.NewNotFoundError("API key not found, prefix: " + prefix)
+	}
+	if err != nil {
+		return apiKey, database.TranslateError(err)
+	}
+	return apiKey, nil
+}
+
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context) ([]domain.APIKey, *domain.AppError) {
+	var apiKeys []domain.APIKey
+	if err := database.Conn(ctx, r.db).Order("id").Find(&apiKeys).Error; err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepository) UpdateAPIKey(ctx context.Context, apiKey domain.APIKey) (domain.APIKey, *domain.AppError) {
+	result := database.Conn(ctx, r.db).Model(&apiKey).Select("name", "scopes", "expires_at", "revoked_at", "updated_at").Updates(&apiKey)
+	if result.Error != nil {
+		return apiKey, database.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apiKey, apiKeyNotFound(apiKey.ID)
+	}
+	return apiKey, nil
+}
+
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) *domain.AppError {
+	err := database.Conn(ctx, r.db).Model(&domain.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", usedAt).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func apiKeyNotFound(id uint) *domain.AppError {
+	return domain.NewNotFoundError(fmt.Sprintf("API key not found, ID: %d", id))
+}
@@ -0,0 +1,194 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// The last use of a key is written at most once per interval, not on every request.
+const lastUsedInterval = time.Minute
+
+//go:generate mockgen -destination=../mocks/mockAPIKeyUsecase.go -package=mocks go-app/domain APIKeyUseCase
+type apiKeyUseCase struct {
+	repo   domain.APIKeyRepository
+	logger *zap.Logger
+}
+
+func NewAPIKeyUseCase(repo domain.APIKeyRepository, logger *zap.Logger) domain.APIKeyUseCase {
+	return &apiKeyUseCase{repo: repo, logger: logger}
+}
+
+func (u *apiKeyUseCase) CreateAPIKey(ctx context.Context, request domain.APIKeyRequest) (domain.APIKey, *domain.AppError) {
+	now := time.Now()
+	if err := request.Validate(now); err != nil {
+		return domain.APIKey{}, err
+	}
+	if err := checkGrantable(ctx, request.Scopes); err != nil {
+		return domain.APIKey{}, err
+	}
+
+	key, prefix, keyErr := newKey()
+	if keyErr != nil {
+		err := domain.NewUnexpectedError("API key could not be generated.").WithCause(keyErr)
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.APIKey{}, err
+	}
+
+	created, err := u.repo.CreateAPIKey(ctx, domain.APIKey{
+		Name:      request.Name,
+		Prefix:    prefix,
+		Hash:      hashKey(key),
+		Scopes:    request.Scopes,
+		CreatedBy: domain.AuditMetadataFrom(ctx).Actor,
+		ExpiresAt: request.ExpiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.APIKey{}, err
+	}
+
+	u.logger.Info(fmt.Sprintf("API key issued. ID: %d, Prefix: %s", created.ID, created.Prefix))
+	created.Key = key
+	return created, nil
+}
+
+// checkGrantable refuses scopes the caller does not hold, api-keys:admin alone must not lead to any other scope.
+func checkGrantable(ctx context.Context, scopes []string) *domain.AppError {
+	var missing []string
+	for _, scope := range scopes {
+		if !domain.CallerHasScope(ctx, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return domain.NewForbiddenError("API keys can only be granted the scopes of the caller.").WithDetails([]domain.FieldViolation{
+			{Field: "scopes", Rule: "held_by_caller", Message: "not held by the caller: " + strings.Join(missing, " ")},
+		})
+	}
+	return nil
+}
+
+func (u *apiKeyUseCase) GetAPIKey(ctx context.Context, id uint) (domain.APIKey, *domain.AppError) {
+	apiKey, err := u.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return apiKey, err
+	}
+	return apiKey, nil
+}
+
+func (u *apiKeyUseCase) ListAPIKeys(ctx context.Context) ([]domain.APIKey, *domain.AppError) {
+	apiKeys, err := u.repo.ListAPIKeys(ctx)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (u *apiKeyUseCase) UpdateAPIKey(ctx context.Context, id uint, request domain.APIKeyRequest) (domain.APIKey, *domain.AppError) {
+	now := time.Now()
+	if err := request.Validate(now); err != nil {
+		return domain.APIKey{}, err
+	}
+	if err := checkGrantable(ctx, request.Scopes); err != nil {
+		return domain.APIKey{}, err
+	}
+
+	apiKey, err := u.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return apiKey, err
+	}
+	if apiKey.RevokedAt != nil {
+		return domain.APIKey{}, domain.NewConflictError(fmt.Sprintf("API key is revoked, ID: %d", id))
+	}
+
+	apiKey.Name = request.Name
+	apiKey.Scopes = request.Scopes
+	apiKey.ExpiresAt = request.ExpiresAt
+	apiKey.UpdatedAt = now
+
+	updated, err := u.repo.UpdateAPIKey(ctx, apiKey)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.APIKey{}, err
+	}
+	return updated, nil
+}
+
+func (u *apiKeyUseCase) RevokeAPIKey(ctx context.Context, id uint) (domain.APIKey, *domain.AppError) {
+	apiKey, err := u.repo.GetAPIKey(ctx, id)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return apiKey, err
+	}
+	if apiKey.RevokedAt != nil {
+		return apiKey, nil
+	}
+
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	apiKey.UpdatedAt = now
+	revoked, err := u.repo.UpdateAPIKey(ctx, apiKey)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.APIKey{}, err
+	}
+
+	u.logger.Info(fmt.Sprintf("API key revoked. ID: %d, Prefix: %s", revoked.ID, revoked.Prefix))
+	return revoked, nil
+}
+
+/*
+Authenticate looks the key up by its prefix and compares the hashes in constant time.
+Unknown, revoked and expired keys get the same error, the reason is only logged and counted.
+*/
+func (u *apiKeyUseCase) Authenticate(ctx context.Context, key string) (domain.Principal, *domain.AppError) {
+	invalid := domain.NewUnauthorizedError("Invalid API key.")
+
+	prefix, ok := parseKey(key)
+	if !ok {
+		metrics.AuthAPIKeyAuthentications.WithLabelValues("invalid").Inc()
+		return domain.Principal{}, invalid
+	}
+
+	apiKey, err := u.repo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil && err.Code != domain.ErrCodeNotFound {
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.Principal{}, err
+	}
+	if err != nil || subtle.ConstantTimeCompare([]byte(hashKey(key)), []byte(apiKey.Hash)) != 1 {
+		metrics.AuthAPIKeyAuthentications.WithLabelValues("invalid").Inc()
+		return domain.Principal{}, invalid
+	}
+
+	now := time.Now()
+	if !apiKey.Usable(now) {
+		result := "expired"
+		if apiKey.RevokedAt != nil {
+			result = "revoked"
+		}
+		metrics.AuthAPIKeyAuthentications.WithLabelValues(result).Inc()
+		u.logger.Warn(fmt.Sprintf("API key is %s. Prefix: %s", result, apiKey.Prefix))
+		return domain.Principal{}, invalid
+	}
+
+	// A failed write of the last use must not fail the request.
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedInterval {
+		if err := u.repo.TouchAPIKey(ctx, apiKey.ID, now); err != nil {
+			u.logger.Warn(err.Message, zap.Error(err))
+		}
+	}
+
+	metrics.AuthAPIKeyAuthentications.WithLabelValues("succeeded").Inc()
+	return domain.Principal{Subject: apiKey.Prefix, Issuer: domain.APIKeyIssuer, Scopes: apiKey.Scopes}, nil
+}
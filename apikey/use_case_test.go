@@ -0,0 +1,225 @@
+package apikey
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/domain"
+	"go-app/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_apiKeyMockRepo *mocks.MockAPIKeyRepository
+	_apiKeyUseCase  domain.APIKeyUseCase
+)
+
+func mockUseCaseSetup(t *testing.T) {
+	c := gomock.NewController(t)
+	_apiKeyMockRepo = mocks.NewMockAPIKeyRepository(c)
+	_apiKeyUseCase = NewAPIKeyUseCase(_apiKeyMockRepo, config.ZapTestConfig())
+}
+
+func issuedKey(t *testing.T) (string, domain.APIKey) {
+	key, prefix, err := newKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, domain.APIKey{ID: 1, Name: "billing-service", Prefix: prefix, Hash: hashKey(key), Scopes: []string{"users:read"}}
+}
+
+func Test_Should_Issue_API_Key_And_Store_Only_Its_Hash(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	ctx := domain.WithAuditMetadata(context.Background(), domain.AuditMetadata{Actor: "admin"})
+	request := domain.APIKeyRequest{Name: "billing-service", Scopes: []string{"users:read", "users:write"}}
+
+	// WHEN
+	var stored domain.APIKey
+	_apiKeyMockRepo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, apiKey domain.APIKey) (domain.APIKey, *domain.AppError) {
+			apiKey.ID = 1
+			stored = apiKey
+			return apiKey, nil
+		})
+	apiKey, err := _apiKeyUseCase.CreateAPIKey(ctx, request)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(apiKey.Key, apiKey.Prefix+"_"))
+	assert.Empty(t, stored.Key)
+	assert.Equal(t, hashKey(apiKey.Key), stored.Hash)
+	assert.Equal(t, "admin", stored.CreatedBy)
+	assert.Equal(t, []string{"users:read", "users:write"}, stored.Scopes)
+}
+
+func Test_Should_Return_Validation_Err_When_API_Key_Request_Is_Invalid(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	past := time.Now().Add(-time.Hour)
+	request := domain.APIKeyRequest{Name: "billing-service", Scopes: []string{"users read"}, ExpiresAt: &past}
+
+	// WHEN
+	_, err := _apiKeyUseCase.CreateAPIKey(context.Background(), request)
+
+	// THEN
+	assert.Equal(t, domain.ErrCodeValidationFailed, err.Code)
+	assert.Equal(t, []domain.FieldViolation{
+		{Field: "scopes[0]", Rule: "scope", Message: "may only contain letters, digits and : . _ / -"},
+		{Field: "expires_at", Rule: "future", Message: "must be in the future"},
+	}, err.Details)
+}
+
+func Test_Should_Authenticate_API_Key_And_Record_Its_Use(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	key, apiKey := issuedKey(t)
+
+	// WHEN
+	_apiKeyMockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), apiKey.Prefix).Return(apiKey, nil)
+	_apiKeyMockRepo.EXPECT().TouchAPIKey(gomock.Any(), uint(1), gomock.Any()).Return(nil)
+	principal, err := _apiKeyUseCase.Authenticate(context.Background(), key)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.Principal{Subject: apiKey.Prefix, Issuer: domain.APIKeyIssuer, Scopes: []string{"users:read"}}, principal)
+}
+
+func Test_Should_Not_Record_Use_Of_Recently_Used_API_Key(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	key, apiKey := issuedKey(t)
+	lastUsed := time.Now().Add(-10 * time.Second)
+	apiKey.LastUsedAt = &lastUsed
+
+	// WHEN
+	_apiKeyMockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), apiKey.Prefix).Return(apiKey, nil)
+	_, err := _apiKeyUseCase.Authenticate(context.Background(), key)
+
+	// THEN
+	assert.Nil(t, err)
+}
+
+func Test_Should_Reject_Unknown_Revoked_And_Expired_API_Keys(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	key, apiKey := issuedKey(t)
+	past := time.Now().Add(-time.Minute)
+	revoked, expired := apiKey, apiKey
+	revoked.RevokedAt = &past
+	expired.ExpiresAt = &past
+	wrongSecret := apiKey.Prefix + "_" + strings.Repeat("0", 2*secretLength)
+
+	// WHEN
+	_apiKeyMockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), apiKey.Prefix).Return(revoked, nil)
+	_apiKeyMockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), apiKey.Prefix).Return(expired, nil)
+	_apiKeyMockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), apiKey.Prefix).Return(apiKey, nil)
+	_, revokedErr := _apiKeyUseCase.Authenticate(context.Background(), key)
+	_, expiredErr := _apiKeyUseCase.Authenticate(context.Background(), key)
+	_, wrongSecretErr := _apiKeyUseCase.Authenticate(context.Background(), wrongSecret)
+	_, malformedErr := _apiKeyUseCase.Authenticate(context.Background(), "not-a-key")
+
+	// THEN
+	for _, err := range []*domain.AppError{revokedErr, expiredErr, wrongSecretErr, malformedErr} {
+		assert.NotNil(t, err)
+		assert.Equal(t, domain.ErrCodeUnauthorized, err.Code)
+		assert.Equal(t, "Invalid API key.", err.Message)
+	}
+}
+
+func Test_Should_Revoke_API_Key_Once(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	_, apiKey := issuedKey(t)
+
+	// WHEN
+	_apiKeyMockRepo.EXPECT().GetAPIKey(gomock.Any(), uint(1)).Return(apiKey, nil)
+	_apiKeyMockRepo.EXPECT().UpdateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, apiKey domain.APIKey) (domain.APIKey, *domain.AppError) {
+			return apiKey, nil
+		})
+	revoked, err := _apiKeyUseCase.RevokeAPIKey(context.Background(), 1)
+
+	_apiKeyMockRepo.EXPECT().GetAPIKey(gomock.Any(), uint(1)).Return(revoked, nil)
+	again, againErr := _apiKeyUseCase.RevokeAPIKey(context.Background(), 1)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Nil(t, againErr)
+	assert.NotNil(t, revoked.RevokedAt)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt)
+}
+
+func Test_Should_Return_Conflict_When_Updating_Revoked_API_Key(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	_, apiKey := issuedKey(t)
+	now := time.Now()
+	apiKey.RevokedAt = &now
+
+	// WHEN
+	_apiKeyMockRepo.EXPECT().GetAPIKey(gomock.Any(), uint(1)).Return(apiKey, nil)
+	_, err := _apiKeyUseCase.UpdateAPIKey(context.Background(), 1, domain.APIKeyRequest{Name: "renamed", Scopes: []string{"users:read"}})
+
+	// THEN
+	assert.Equal(t, domain.ErrCodeConflict, err.Code)
+}
+
+func Test_Should_Seed_Bootstrap_Key_Once(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	key, stored := issuedKey(t)
+	other, _ := issuedKey(t)
+	other = stored.Prefix + other[len(stored.Prefix):]
+
+	// WHEN
+	_apiKeyMockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), stored.Prefix).Return(domain.APIKey{}, domain.NewNotFoundError("API key not found"))
+	_apiKeyMockRepo.EXPECT().CreateAPIKey(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, apiKey domain.APIKey) (domain.APIKey, *domain.AppError) {
+			assert.Equal(t, stored.Hash, apiKey.Hash)
+			assert.Contains(t, apiKey.Scopes, domain.ScopeAPIKeysAdmin)
+			assert.Contains(t, apiKey.Scopes, domain.ScopeUsersAdmin)
+			return apiKey, nil
+		})
+	seeded := Bootstrap(context.Background(), _apiKeyMockRepo, key)
+
+	_apiKeyMockRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), stored.Prefix).Return(stored, nil).Times(2)
+	reseeded := Bootstrap(context.Background(), _apiKeyMockRepo, key)
+	collision := Bootstrap(context.Background(), _apiKeyMockRepo, other)
+	malformed := Bootstrap(context.Background(), _apiKeyMockRepo, "not-a-key")
+
+	// THEN
+	assert.Nil(t, seeded)
+	assert.Nil(t, reseeded)
+	assert.NotNil(t, collision)
+	assert.NotNil(t, malformed)
+}
+
+func Test_Should_Refuse_To_Grant_Scopes_The_Caller_Does_Not_Hold(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	ctx := domain.WithPrincipal(context.Background(), domain.Principal{Subject: "admin", Scopes: []string{domain.ScopeAPIKeysAdmin, "users:read"}})
+	request := domain.APIKeyRequest{Name: "billing-service", Scopes: []string{"users:read", domain.ScopeUsersAdmin}}
+
+	// WHEN
+	_, createErr := _apiKeyUseCase.CreateAPIKey(ctx, request)
+	_, updateErr := _apiKeyUseCase.UpdateAPIKey(ctx, 1, request)
+
+	// THEN
+	assert.Equal(t, domain.ErrCodeForbidden, createErr.Code)
+	assert.Equal(t, domain.ErrCodeForbidden, updateErr.Code)
+	assert.Contains(t, createErr.Details.([]domain.FieldViolation)[0].Message, domain.ScopeUsersAdmin)
+	assert.NotContains(t, createErr.Details.([]domain.FieldViolation)[0].Message, "users:read")
+}
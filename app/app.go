@@ -0,0 +1,243 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-app/apikey"
+	"go-app/audit"
+	"go-app/config"
+	"go-app/database"
+	"go-app/domain"
+	"go-app/idempotency"
+	"go-app/outbox"
+	"go-app/stream"
+	"go-app/user"
+	"go-app/webhook"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"time"
+)
+
+/*
+App holds one wired instance of the application, several instances can live in the same process.
+New builds it, Start runs its background jobs and Close releases its resources.
+*/
+type App struct {
+	Config *config.AppConfig
+	Logger *zap.Logger
+	DB     *gorm.DB
+	Router *gin.Engine
+
+	jobs []func(ctx context.Context)
+	// Released in reverse order by Close.
+	closers []func() error
+}
+
+func New(ctx context.Context, cfg *config.AppConfig) (_ *App, err error) {
+	a := &App{Config: cfg}
+	defer func() {
+		if err != nil {
+			_ = a.Close()
+		}
+	}()
+
+	// Sentry Config, New Relic Config & Zap Config
+	if err := config.SentryConfig(cfg.Sentry); err != nil {
+		return nil, fmt.Errorf("sentry: %w", err)
+	}
+	newRelicApp, err := config.NewRelicConfig(cfg.NewRelic)
+	if err != nil {
+		return nil, fmt.Errorf("new relic: %w", err)
+	}
+	a.closers = append(a.closers, func() error {
+		newRelicApp.Shutdown(5 * time.Second)
+		return nil
+	})
+	a.Logger = config.ZapConfig(newRelicApp)
+
+	// Database Config & Migration, Postgres unless DB_DRIVER selects SQLite
+	db, err := config.ConnectDatabase(ctx, cfg.Database, a.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	a.DB = db
+	a.closers = append(a.closers, func() error {
+		a.Logger.Info("DB connection closing...")
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+	if err := database.Migrate(db); err != nil {
+		return nil, fmt.Errorf("migration: %w", err)
+	}
+
+	// Database Connection Monitor, reports outages and closes the circuit breaker once the database answers again
+	connectionMonitor, err := database.NewConnectionMonitor(db, a.Logger, cfg.Database.MonitorInterval, cfg.Database.MonitorTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("database connection monitor: %w", err)
+	}
+	a.jobs = append(a.jobs, connectionMonitor.Start)
+
+	// Read Replicas, user reads outside transactions go to a healthy replica when POSTGRES_REPLICAS is set
+	replicaRouter, err := config.ConnectReplicas(db, cfg.Database, a.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("read replicas: %w", err)
+	}
+	if replicaRouter != nil {
+		a.closers = append(a.closers, func() error {
+			replicaRouter.Close()
+			return nil
+		})
+		a.jobs = append(a.jobs, replicaRouter.Start)
+	}
+
+	// Transaction Manager
+	isolation, err := database.ParseIsolationLevel(cfg.Transaction.Isolation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction isolation level: %w", err)
+	}
+	txManager := database.NewTxManager(db, a.Logger, isolation, cfg.Transaction.MaxRetries, cfg.Transaction.RetryBackoff)
+
+	// User Repository, read through the cache selected by USER_CACHE, User UseCase & User Handler
+	userRepo := newUserRepository(db, cfg.Database)
+	userCache, err := config.ConnectUserCache(cfg.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("user cache: %w", err)
+	}
+	if userCache != nil {
+		if closer, ok := userCache.(io.Closer); ok {
+			a.closers = append(a.closers, closer.Close)
+		}
+		userRepo = user.NewCachingUserRepository(userRepo, userCache, a.Logger, user.CacheOptions{
+			TTL:         cfg.Cache.TTL,
+			NegativeTTL: cfg.Cache.NegativeTTL,
+		})
+	}
+	outboxRepo := outbox.NewOutboxRepository(db)
+	auditRepo := audit.NewAuditRepository(db)
+	userUseCase := user.NewUserUseCase(userRepo, outboxRepo, auditRepo, txManager, a.Logger)
+	userHandler := user.NewUserHandler(userUseCase, a.Logger, user.HandlerOptions{
+		MaxBatchSize:         cfg.Batch.MaxSize,
+		ImportAsyncThreshold: cfg.Import.AsyncThreshold,
+	})
+
+	// Soft Deleted User Purge Job
+	purgeJob := user.NewPurgeJob(userRepo, a.Logger, cfg.SoftDelete.Retention, cfg.SoftDelete.PurgeInterval)
+	a.jobs = append(a.jobs, purgeJob.Start)
+
+	// Idempotency Key Repository & Cleanup Job
+	idempotencyRepo := idempotency.NewIdempotencyRepository(db)
+	cleanupJob := idempotency.NewCleanupJob(idempotencyRepo, a.Logger, cfg.Idempotency.CleanupInterval)
+	a.jobs = append(a.jobs, cleanupJob.Start)
+
+	// Webhook Repository, UseCase, Handler & Dispatcher
+	webhookRepo := webhook.NewWebhookRepository(db)
+	webhookHandler := webhook.NewWebhookHandler(webhook.NewWebhookUseCase(webhookRepo, a.Logger, cfg.Webhook.AllowPrivateNetworks), a.Logger)
+	dispatcher := webhook.NewDispatcher(webhookRepo, txManager, a.Logger, webhook.DispatcherOptions{
+		PollInterval:         cfg.Webhook.PollInterval,
+		BatchSize:            cfg.Webhook.BatchSize,
+		Timeout:              cfg.Webhook.Timeout,
+		MaxAttempts:          cfg.Webhook.MaxAttempts,
+		RetryBase:            cfg.Webhook.RetryBase,
+		RetryMax:             cfg.Webhook.RetryMax,
+		AllowPrivateNetworks: cfg.Webhook.AllowPrivateNetworks,
+	})
+	a.jobs = append(a.jobs, dispatcher.Start)
+
+	// Outbox Sinks & Relay, webhook deliveries are created by the relay
+	sinks := []domain.EventSink{webhook.NewSink(webhookRepo)}
+	for _, name := range cfg.Outbox.Sinks {
+		sink, err := outbox.NewSink(name, outbox.SinkOptions{
+			HTTPURL:     cfg.Outbox.HTTPURL,
+			HTTPTimeout: cfg.Outbox.HTTPTimeout,
+			FilePath:    cfg.Outbox.FilePath,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid outbox sink: %w", err)
+		}
+		sinks = append(sinks, sink)
+	}
+	relay := outbox.NewRelay(outboxRepo, txManager, sinks, a.Logger, outbox.RelayOptions{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		RetryBase:    cfg.Outbox.RetryBase,
+		RetryMax:     cfg.Outbox.RetryMax,
+		MaxAttempts:  cfg.Outbox.MaxAttempts,
+		ClaimTimeout: cfg.Outbox.ClaimTimeout,
+		Retention:    cfg.Outbox.Retention,
+	})
+	a.jobs = append(a.jobs, relay.Start)
+
+	// User Event Stream Broker & Handler
+	broker := stream.NewBroker(outboxRepo, a.Logger, stream.BrokerOptions{
+		PollInterval:   cfg.Stream.PollInterval,
+		LogSize:        cfg.Stream.LogSize,
+		MaxSubscribers: cfg.Stream.MaxSubscribers,
+		GapTimeout:     cfg.Stream.GapTimeout,
+	})
+	a.jobs = append(a.jobs, broker.Start)
+	streamHandler := stream.NewStreamHandler(broker, a.Logger, cfg.Stream.Heartbeat)
+
+	// Bearer Token Verifier, the user endpoints stay open unless AUTH_ENABLED or AUTH_API_KEYS_ENABLED is set
+	verifier, err := config.NewTokenVerifier(cfg.Auth, a.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+
+	// API Key Repository, UseCase & Handler, keys authenticate requests when AUTH_API_KEYS_ENABLED is set
+	apiKeyRepo := apikey.NewAPIKeyRepository(db)
+	apiKeyUseCase := apikey.NewAPIKeyUseCase(apiKeyRepo, a.Logger)
+	apiKeyHandler := apikey.NewAPIKeyHandler(apiKeyUseCase, a.Logger)
+	var apiKeys domain.APIKeyAuthenticator
+	if cfg.Auth.APIKeysEnabled {
+		apiKeys = apiKeyUseCase
+	}
+	if cfg.Auth.BootstrapAPIKey != "" {
+		if !cfg.Auth.APIKeysEnabled {
+			return nil, errors.New("auth: AUTH_BOOTSTRAP_API_KEY requires AUTH_API_KEYS_ENABLED")
+		}
+		if err := apikey.Bootstrap(ctx, apiKeyRepo, cfg.Auth.BootstrapAPIKey); err != nil {
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	// Setup Router
+	a.Router = NewRouter(newRelicApp, a.Logger, userHandler, webhookHandler, streamHandler, idempotencyRepo, cfg.Idempotency.TTL, apiKeyHandler, verifier, apiKeys)
+	return a, nil
+}
+
+// Start runs the background jobs until ctx is cancelled.
+func (a *App) Start(ctx context.Context) {
+	for _, job := range a.jobs {
+		go job(ctx)
+	}
+}
+
+// Close releases the resources of the application, the jobs should be stopped first.
+func (a *App) Close() error {
+	var errs []error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i](); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	a.closers = nil
+	return errors.Join(errs...)
+}
+
+// newUserRepository picks the user repository configured by USER_REPOSITORY and DB_DRIVER.
+func newUserRepository(db *gorm.DB, cfg *config.Database) domain.UserRepository {
+	switch {
+	case cfg.UserRepository == "memory":
+		return user.NewMemoryUserRepository()
+	case db.Dialector.Name() == "sqlite":
+		return user.NewSQLiteUserRepository(db)
+	default:
+		return user.NewUserRepository(db)
+	}
+}
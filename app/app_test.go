@@ -0,0 +1,285 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sethvargo/go-envconfig"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/domain"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestApp(t *testing.T) *App {
+	return newTestAppWithEnv(t, nil)
+}
+
+func newTestAppWithEnv(t *testing.T, env map[string]string) *App {
+	lookup := map[string]string{
+		"DB_DRIVER":         "sqlite",
+		"SQLITE_PATH":       filepath.Join(t.TempDir(), "app.db"),
+		"NEW_RELIC_ENABLED": "false",
+	}
+	for key, value := range env {
+		lookup[key] = value
+	}
+	cfg, err := config.LoadFrom(context.Background(), envconfig.MapLookuper(lookup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	application, err := New(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	application.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		assert.Nil(t, application.Close())
+	})
+	return application
+}
+
+func Test_Should_Run_Isolated_Apps_In_One_Process(t *testing.T) {
+	// GIVEN
+	first := newTestApp(t)
+	second := newTestApp(t)
+	body, _ := json.Marshal(domain.User{Name: "isolated-user", Age: 30, Email: "isolated-user@example.com"})
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	first.Router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created domain.User
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	url := fmt.Sprintf("/api/v1/users/%d", created.ID)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	first.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	second.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_Should_Record_Audit_Trail_Of_User_Changes(t *testing.T) {
+	// GIVEN
+	application := newTestApp(t)
+	body, _ := json.Marshal(domain.User{Name: "audited-user", Age: 30, Email: "audited-user@example.com"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", "admin")
+	application.Router.ServeHTTP(w, req)
+	var created domain.User
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &created))
+	afterCreate := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPatch, fmt.Sprintf("/api/v1/users/%d", created.ID), strings.NewReader(`{"age": 31}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", "*")
+	req.Header.Set("X-Request-ID", "patch-request")
+	application.Router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// WHEN
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d/history?limit=1", created.ID), nil)
+	application.Router.ServeHTTP(w, req)
+	var page domain.UserHistory
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &page))
+
+	w = httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d/history?before=%d&as_of=%s", created.ID, page.NextBefore, neturl.QueryEscape(afterCreate.Format(time.RFC3339Nano)))
+	req, _ = http.NewRequest(http.MethodGet, url, nil)
+	application.Router.ServeHTTP(w, req)
+	var asOf domain.UserHistory
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &asOf))
+
+	// THEN
+	assert.Len(t, page.Entries, 1)
+	assert.Equal(t, domain.AuditUpdate, page.Entries[0].Action)
+	assert.Equal(t, "patch-request", page.Entries[0].RequestID)
+	assert.Equal(t, `30`, string(page.Entries[0].Changes["age"].Before))
+	assert.Equal(t, `31`, string(page.Entries[0].Changes["age"].After))
+	assert.NotZero(t, page.NextBefore)
+
+	assert.Len(t, asOf.Entries, 1)
+	assert.Equal(t, domain.AuditCreate, asOf.Entries[0].Action)
+	assert.Equal(t, "admin", asOf.Entries[0].Actor)
+	assert.Zero(t, asOf.NextBefore)
+	assert.Equal(t, 30, asOf.User.Age)
+	assert.Equal(t, created.UUID, asOf.User.UUID)
+}
+
+func Test_Should_Require_Bearer_Token_When_Auth_Is_Enabled(t *testing.T) {
+	// GIVEN
+	secret := "a-secret-of-at-least-32-bytes-long!"
+	application := newTestAppWithEnv(t, map[string]string{
+		"AUTH_ENABLED":      "true",
+		"AUTH_ISSUER":       "https://issuer.example.com",
+		"AUTH_AUDIENCE":     "go-app",
+		"AUTH_HS256_SECRET": secret,
+	})
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":   "https://issuer.example.com",
+		"aud":   "go-app",
+		"sub":   "service-account",
+		"scope": "users:read users:write",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	body, _ := json.Marshal(domain.User{Name: "authenticated-user", Age: 30, Email: "authenticated-user@example.com"})
+
+	// WHEN
+	anonymous := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	application.Router.ServeHTTP(anonymous, req)
+
+	authenticated := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Actor", "spoofed-actor")
+	application.Router.ServeHTTP(authenticated, req)
+
+	// THEN
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+	assert.NotEmpty(t, anonymous.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusCreated, authenticated.Code)
+
+	var created domain.User
+	assert.Nil(t, json.Unmarshal(authenticated.Body.Bytes(), &created))
+	w := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d/history", created.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	application.Router.ServeHTTP(w, req)
+	var history domain.UserHistory
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &history))
+	assert.Len(t, history.Entries, 1)
+	assert.Equal(t, "service-account", history.Entries[0].Actor)
+}
+
+func Test_Should_Authenticate_With_Issued_API_Key_Until_It_Is_Revoked(t *testing.T) {
+	// GIVEN
+	secret := "a-secret-of-at-least-32-bytes-long!"
+	application := newTestAppWithEnv(t, map[string]string{
+		"AUTH_ENABLED":          "true",
+		"AUTH_ISSUER":           "https://issuer.example.com",
+		"AUTH_AUDIENCE":         "go-app",
+		"AUTH_HS256_SECRET":     secret,
+		"AUTH_API_KEYS_ENABLED": "true",
+	})
+	bearer := func(scope string) string {
+		token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"iss":   "https://issuer.example.com",
+			"aud":   "go-app",
+			"sub":   "admin",
+			"scope": scope,
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte(secret))
+		return "Bearer " + token
+	}
+	serve := func(method string, url string, body string, header string, value string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(header, value)
+		application.Router.ServeHTTP(w, req)
+		return w
+	}
+	request := `{"name": "billing-service", "scopes": ["users:read"]}`
+
+	// WHEN
+	forbidden := serve(http.MethodPost, "/api/v1/api-keys", request, "Authorization", bearer("users:read"))
+	issued := serve(http.MethodPost, "/api/v1/api-keys", request, "Authorization", bearer("api-keys:admin users:read"))
+	var apiKey domain.APIKey
+	assert.Nil(t, json.Unmarshal(issued.Body.Bytes(), &apiKey))
+
+	user := `{"name": "key-user", "age": 30, "email": "key-user@example.com"}`
+	readOnly := serve(http.MethodPost, "/api/v1/users", user, "X-API-Key", apiKey.Key)
+	createdUser := serve(http.MethodPost, "/api/v1/users", user, "Authorization", bearer("users:write"))
+	var created domain.User
+	assert.Nil(t, json.Unmarshal(createdUser.Body.Bytes(), &created))
+	read := serve(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", created.ID), "", "X-API-Key", apiKey.Key)
+	withScheme := serve(http.MethodGet, fmt.Sprintf("/api/v1/users/%d/history", created.ID), "", "Authorization", "ApiKey "+apiKey.Key)
+	var history domain.UserHistory
+	assert.Nil(t, json.Unmarshal(withScheme.Body.Bytes(), &history))
+
+	listed := serve(http.MethodGet, "/api/v1/api-keys", "", "Authorization", bearer("api-keys:admin"))
+	revoked := serve(http.MethodPost, fmt.Sprintf("/api/v1/api-keys/%d/revoke", apiKey.ID), "", "Authorization", bearer("api-keys:admin"))
+	afterRevoke := serve(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", created.ID), "", "X-API-Key", apiKey.Key)
+
+	// THEN
+	assert.Equal(t, http.StatusForbidden, forbidden.Code)
+	assert.Equal(t, http.StatusCreated, issued.Code)
+	assert.NotEmpty(t, apiKey.Key)
+	assert.Equal(t, "admin", apiKey.CreatedBy)
+
+	assert.Equal(t, http.StatusForbidden, readOnly.Code)
+	assert.Equal(t, http.StatusCreated, createdUser.Code)
+	assert.Equal(t, http.StatusOK, read.Code)
+	assert.Equal(t, http.StatusOK, withScheme.Code)
+	assert.Len(t, history.Entries, 1)
+	assert.Equal(t, "admin", history.Entries[0].Actor)
+
+	assert.Equal(t, http.StatusOK, listed.Code)
+	assert.NotContains(t, listed.Body.String(), apiKey.Key)
+	assert.Contains(t, listed.Body.String(), `"last_used_at"`)
+	assert.Equal(t, http.StatusOK, revoked.Code)
+	assert.Equal(t, http.StatusUnauthorized, afterRevoke.Code)
+	assert.Equal(t, []string{"Bearer", `ApiKey error="invalid_token"`}, afterRevoke.Header().Values("WWW-Authenticate"))
+}
+
+func Test_Should_Issue_API_Keys_With_Bootstrap_Key_When_Bearer_Tokens_Are_Off(t *testing.T) {
+	// GIVEN
+	bootstrapKey := "gak_0123456789ab_" + strings.Repeat("cd", 32)
+	application := newTestAppWithEnv(t, map[string]string{
+		"AUTH_API_KEYS_ENABLED":  "true",
+		"AUTH_BOOTSTRAP_API_KEY": bootstrapKey,
+	})
+	serve := func(method string, url string, body string, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		application.Router.ServeHTTP(w, req)
+		return w
+	}
+	request := `{"name": "billing-service", "scopes": ["users:read", "users:write"]}`
+
+	// WHEN
+	anonymous := serve(http.MethodPost, "/api/v1/api-keys", request, "")
+	issued := serve(http.MethodPost, "/api/v1/api-keys", request, bootstrapKey)
+	var apiKey domain.APIKey
+	assert.Nil(t, json.Unmarshal(issued.Body.Bytes(), &apiKey))
+	createdUser := serve(http.MethodPost, "/api/v1/users", `{"name": "key-user", "age": 30}`, apiKey.Key)
+	escalated := serve(http.MethodPost, "/api/v1/api-keys", `{"name": "escalated", "scopes": ["users:admin"]}`, apiKey.Key)
+
+	// THEN
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+	assert.Equal(t, http.StatusCreated, issued.Code)
+	assert.Equal(t, "gak_0123456789ab", apiKey.CreatedBy)
+	assert.Equal(t, http.StatusCreated, createdUser.Code)
+	assert.Equal(t, http.StatusForbidden, escalated.Code)
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"go-app/apikey"
+	"go-app/docs"
+	"go-app/domain"
+	"go-app/middleware"
+	"go-app/stream"
+	"go-app/user"
+	"go-app/webhook"
+	"go.uber.org/zap"
+	"time"
+)
+
+func NewRouter(newRelicApp *newrelic.Application, logger *zap.Logger, handler *user.Handler, webhookHandler *webhook.Handler, streamHandler *stream.Handler, idempotencyRepo domain.IdempotencyRepository, idempotencyTTL time.Duration, apiKeyHandler *apikey.Handler, verifier domain.TokenVerifier, apiKeys domain.APIKeyAuthenticator) *gin.Engine {
+	router := gin.Default()
+
+	// Swagger => http://localhost:8080/swagger/index.html
+	docs.SwaggerInfo.BasePath = "/"
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Middlewares
+	_middleware := middleware.NewMiddleware(newRelicApp, logger).WithEventStreams("/api/v1/users/events")
+	router.Use(_middleware.NewRelicMiddleWare())
+	router.Use(_middleware.SentryMiddleware())
+	router.Use(_middleware.AuditMiddleware)
+	router.Use(_middleware.LogMiddleware)
+	router.Use(_middleware.ReadYourWritesMiddleware)
+
+	// Prometheus Metrics
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Authentication of the user, API key and webhook endpoints, turned off when there is neither a verifier nor API keys
+	var authenticated, userReaders, userWriters, userAdmins, apiKeyAdmins, webhookAdmins []gin.HandlerFunc
+	if verifier != nil || apiKeys != nil {
+		authenticated = append(authenticated, _middleware.AuthMiddleware(verifier, apiKeys))
+		userReaders = append(append([]gin.HandlerFunc{}, authenticated...), _middleware.RequireScope(domain.ScopeUsersRead))
+		userWriters = append(append([]gin.HandlerFunc{}, authenticated...), _middleware.RequireScope(domain.ScopeUsersWrite))
+		userAdmins = append(append([]gin.HandlerFunc{}, authenticated...), _middleware.RequireScope(domain.ScopeUsersAdmin))
+		apiKeyAdmins = append(append([]gin.HandlerFunc{}, authenticated...), _middleware.RequireScope(domain.ScopeAPIKeysAdmin))
+		webhookAdmins = append(append([]gin.HandlerFunc{}, authenticated...), _middleware.RequireScope(domain.ScopeWebhooksAdmin))
+	}
+
+	// Endpoints
+	readers := router.Group("/api/v1/users", userReaders...)
+	writers := router.Group("/api/v1/users", userWriters...)
+	writers.POST("", _middleware.IdempotencyMiddleware(idempotencyRepo, idempotencyTTL), handler.CreateUser)
+	readers.GET("/export", handler.ExportUsers)
+	readers.GET("/events", streamHandler.StreamUserEvents)
+	writers.POST("/import", handler.ImportUsers)
+	readers.GET("/import/:jobId", handler.GetImportJob)
+	readers.GET("/:id", handler.GetUserById)
+	writers.PUT("/:id", handler.UpdateUser)
+	writers.PATCH("/:id", handler.PatchUser)
+	writers.DELETE("/:id", handler.DeleteUserById)
+	writers.POST("/:id/restore", handler.RestoreUserById)
+	readers.GET("/:id/history", handler.GetUserHistory)
+	writers.POST("/:id/activate", handler.ActivateUser)
+	writers.POST("/:id/suspend", handler.SuspendUser)
+	writers.POST("/:id/deactivate", handler.DeactivateUser)
+	router.POST("/api/v1/users:action", append(append([]gin.HandlerFunc{}, userWriters...),
+		_middleware.CustomMethodMiddleware("action", "batch"), _middleware.IdempotencyMiddleware(idempotencyRepo, idempotencyTTL), handler.BatchUsers)...)
+
+	// Roles are granted by admins only, without authentication there is no admin to tell apart
+	if authenticated != nil {
+		router.PUT("/api/v1/users/:id/roles", append(userAdmins, handler.SetUserRoles)...)
+	}
+
+	// Issuing keys while authentication is off would hand them to anyone, the first admin key is seeded from AUTH_BOOTSTRAP_API_KEY
+	if authenticated != nil {
+		apiKeysGroup := router.Group("/api/v1/api-keys", apiKeyAdmins...)
+		apiKeysGroup.POST("", apiKeyHandler.CreateAPIKey)
+		apiKeysGroup.GET("", apiKeyHandler.ListAPIKeys)
+		apiKeysGroup.GET("/:id", apiKeyHandler.GetAPIKey)
+		apiKeysGroup.PUT("/:id", apiKeyHandler.UpdateAPIKey)
+		apiKeysGroup.POST("/:id/revoke", apiKeyHandler.RevokeAPIKey)
+	}
+
+	// Subscriptions choose where the user events are sent, they are managed by webhook admins only
+	if authenticated != nil {
+		webhooks := router.Group("/api/v1/webhooks", webhookAdmins...)
+		webhooks.POST("", webhookHandler.CreateSubscription)
+		webhooks.GET("", webhookHandler.ListSubscriptions)
+		webhooks.GET("/:id", webhookHandler.GetSubscription)
+		webhooks.PUT("/:id", webhookHandler.UpdateSubscription)
+		webhooks.DELETE("/:id", webhookHandler.DeleteSubscription)
+		webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+		webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
+	}
+
+	return router
+}
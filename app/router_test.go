@@ -0,0 +1,1420 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"go-app/apikey"
+	"go-app/config"
+	"go-app/domain"
+	"go-app/metrics"
+	"go-app/mocks"
+	"go-app/stream"
+	"go-app/user"
+	"go-app/webhook"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_userMockUseCase     *mocks.MockUserUseCase
+	_userHandler         *user.Handler
+	_idempotencyMockRepo *mocks.MockIdempotencyRepository
+	_webhookMockUseCase  *mocks.MockWebhookUseCase
+	_outboxMockRepo      *mocks.MockOutboxRepository
+	_broker              *stream.Broker
+	_apiKeyMockUseCase   *mocks.MockAPIKeyUseCase
+)
+
+// stubVerifier accepts any token, its space separated words are the scopes of the principal.
+type stubVerifier struct{}
+
+func (stubVerifier) Verify(ctx context.Context, token string) (domain.Principal, *domain.AppError) {
+	return domain.Principal{Subject: "caller", Scopes: strings.Fields(strings.ReplaceAll(token, "+", " "))}, nil
+}
+
+func handlerSetupRouter(t *testing.T) *gin.Engine {
+	return setupRouter(t, nil)
+}
+
+// handlerSetupAuthRouter requires a bearer token, "Bearer users:read+users:write" authenticates with these scopes.
+func handlerSetupAuthRouter(t *testing.T) *gin.Engine {
+	return setupRouter(t, stubVerifier{})
+}
+
+func setupRouter(t *testing.T, verifier domain.TokenVerifier) *gin.Engine {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	// Mock UserUseCase, WebhookUseCase, APIKeyUseCase, OutboxRepository & IdempotencyRepository
+	_userMockUseCase = mocks.NewMockUserUseCase(c)
+	_apiKeyMockUseCase = mocks.NewMockAPIKeyUseCase(c)
+	_webhookMockUseCase = mocks.NewMockWebhookUseCase(c)
+	_outboxMockRepo = mocks.NewMockOutboxRepository(c)
+	_idempotencyMockRepo = mocks.NewMockIdempotencyRepository(c)
+
+	logger := config.ZapTestConfig()
+	_userHandler = user.NewUserHandler(_userMockUseCase, logger, user.HandlerOptions{MaxBatchSize: 3, ImportAsyncThreshold: 64})
+
+	webhookHandler := webhook.NewWebhookHandler(_webhookMockUseCase, logger)
+	_broker = stream.NewBroker(_outboxMockRepo, logger, stream.BrokerOptions{PollInterval: time.Second, LogSize: 10, MaxSubscribers: 1, GapTimeout: time.Second})
+	streamHandler := stream.NewStreamHandler(_broker, logger, time.Minute)
+
+	newRelicApp, _ := newrelic.NewApplication(newrelic.ConfigEnabled(false))
+	r := NewRouter(newRelicApp, logger, _userHandler, webhookHandler, streamHandler, _idempotencyMockRepo, 24*time.Hour, apikey.NewAPIKeyHandler(_apiKeyMockUseCase, logger), verifier, nil)
+	return r
+
+}
+
+func Test_Should_Create_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	u := domain.User{Name: "created-user", Age: 22, Email: "created-user@example.com"}
+	byteUser, _ := json.Marshal(u)
+	expectedUser := domain.User{ID: 10, Name: u.Name, Age: u.Age}
+
+	// WHEN
+	_userMockUseCase.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	url := "/api/v1/users"
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	savedUser := domain.User{}
+
+	assert.NotEmpty(t, w.Body.String())
+	err := json.Unmarshal([]byte(w.Body.String()), &savedUser)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, w.Code)
+	assert.Equal(t, expectedUser.Name, savedUser.Name)
+	assert.NotEmpty(t, savedUser.ID)
+}
+
+func Test_Should_Return_Validation_Err_When_Invoke_Create_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	u := domain.User{Name: "x", Age: 200, Email: "x@example.com"}
+	byteUser, _ := json.Marshal(u)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	url := "/api/v1/users"
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	resErr := struct {
+		Code    domain.ErrorCode        `json:"code"`
+		Details []domain.FieldViolation `json:"details"`
+	}{}
+	err := json.Unmarshal([]byte(w.Body.String()), &resErr)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, w.Code)
+	assert.Equal(t, domain.ErrCodeValidationFailed, resErr.Code)
+	assert.Len(t, resErr.Details, 2)
+}
+
+func Test_Should_Return_Validation_Err_When_User_Is_Created_Suspended(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	u := domain.User{Name: "created-user", Age: 22, Status: domain.UserSuspended}
+	byteUser, _ := json.Marshal(u)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	resErr := struct {
+		Details []domain.FieldViolation `json:"details"`
+	}{}
+	err := json.Unmarshal(w.Body.Bytes(), &resErr)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 400, w.Code)
+	assert.Equal(t, []domain.FieldViolation{{Field: "status", Rule: "initial_status", Message: "must be one of: active pending"}}, resErr.Details)
+}
+
+func Test_Should_Return_Unexpected_Err_When_Invoke_Create_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	u := domain.User{Name: "created-user", Age: 22, Email: "created-user@example.com"}
+	byteUser, _ := json.Marshal(u)
+
+	gormErr := errors.New("Unexpected Error")
+	expectedErr := domain.NewUnexpectedError(gormErr.Error())
+
+	// WHEN
+	_userMockUseCase.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(domain.User{}, expectedErr)
+
+	w := httptest.NewRecorder()
+	url := "/api/v1/users"
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	resErr := domain.ProblemDetails{}
+
+	assert.NotEmpty(t, w.Body.String())
+	err := json.Unmarshal([]byte(w.Body.String()), &resErr)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, expectedErr.Message, resErr.Detail)
+	assert.Equal(t, expectedErr.Code, resErr.Code)
+}
+
+func Test_Should_Find_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	expectedUser := domain.User{ID: id, Name: "test", Age: 18, Email: "test@example.com"}
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserById(gomock.Any(), gomock.Any()).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	u := domain.User{}
+
+	assert.NotEmpty(t, w.Body.String())
+	err := json.Unmarshal([]byte(w.Body.String()), &u)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, id, u.ID)
+}
+
+func Test_Should_Find_User_By_UUID_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	userUUID := uuid.New()
+	expectedUser := domain.User{ID: 7, UUID: userUUID, Name: "test", Age: 18, Email: "test@example.com"}
+
+	// WHEN
+	_userMockUseCase.EXPECT().ResolveUserId(gomock.Any(), userUUID).Return(uint(7), nil)
+	_userMockUseCase.EXPECT().GetUserById(gomock.Any(), uint(7)).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/"+userUUID.String(), nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	u := domain.User{}
+	err := json.Unmarshal(w.Body.Bytes(), &u)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, userUUID, u.UUID)
+}
+
+func Test_Should_Return_Bad_Request_For_Invalid_User_Id(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/not-a-uuid", nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 400, w.Code)
+}
+
+func Test_Should_Return_Not_Found_Err_When_Invoke_Find_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	errStr := fmt.Sprintf("User not found, ID: %d", id)
+	expectedErr := domain.NewNotFoundError(errStr)
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserById(gomock.Any(), gomock.Any()).Return(domain.User{}, expectedErr)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	resErr := domain.ProblemDetails{}
+
+	assert.NotEmpty(t, w.Body.String())
+	_ = json.Unmarshal([]byte(w.Body.String()), &resErr)
+
+	assert.NotNil(t, resErr)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, domain.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, expectedErr.Message, resErr.Detail)
+	assert.Equal(t, expectedErr.Code, resErr.Code)
+}
+
+func Test_Should_Update_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	expectedUser := domain.User{ID: 5, Name: "updated-user", Age: 22, Email: "updated-user@example.com"}
+	byteUser, _ := json.Marshal(expectedUser)
+
+	// WHEN
+	_userMockUseCase.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", expectedUser.ID)
+	req, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(byteUser))
+	req.Header.Set("If-Match", `W/"1"`)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	updatedUser := domain.User{}
+
+	assert.NotEmpty(t, w.Body.String())
+	err := json.Unmarshal([]byte(w.Body.String()), &updatedUser)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, expectedUser.Name, updatedUser.Name)
+	assert.NotEmpty(t, updatedUser.ID)
+}
+
+func Test_Should_Return_Unexpected_Err_When_Invoke_Update_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	expectedUser := domain.User{ID: 5, Name: "updated-user", Age: 22, Email: "updated-user@example.com"}
+	byteUser, _ := json.Marshal(expectedUser)
+
+	gormErr := errors.New("Unexpected Error")
+	expectedErr := domain.NewUnexpectedError(gormErr.Error())
+
+	// WHEN
+	_userMockUseCase.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(domain.User{}, expectedErr)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", expectedUser.ID)
+	req, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(byteUser))
+	req.Header.Set("If-Match", `W/"1"`)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	resErr := domain.ProblemDetails{}
+
+	assert.NotEmpty(t, w.Body.String())
+	err := json.Unmarshal([]byte(w.Body.String()), &resErr)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, expectedErr.Message, resErr.Detail)
+	assert.Equal(t, expectedErr.Code, resErr.Code)
+}
+
+func Test_Should_Return_Not_Found_Err_When_Invoke_Update_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 99
+	byteUser, _ := json.Marshal(domain.User{Name: "updated-user", Age: 22, Email: "updated-user@example.com"})
+	expectedErr := domain.NewUserNotFoundError(id)
+
+	// WHEN
+	_userMockUseCase.EXPECT().UpdateUser(gomock.Any(), domain.User{ID: id, Name: "updated-user", Age: 22, Email: "updated-user@example.com", Version: 1}).Return(domain.User{}, expectedErr)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(byteUser))
+	req.Header.Set("If-Match", `W/"1"`)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	resErr := domain.ProblemDetails{}
+	err := json.Unmarshal([]byte(w.Body.String()), &resErr)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, domain.ErrCodeUserNotFound, resErr.Code)
+}
+
+func Test_Should_Patch_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 5
+	patch := []byte(`{"name":"patched-user"}`)
+	expectedUser := domain.User{ID: id, Name: "patched-user", Age: 22, Email: "patched-user@example.com"}
+
+	// WHEN
+	_userMockUseCase.EXPECT().PatchUser(gomock.Any(), id, uint(1), domain.MergePatch, patch).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(patch))
+	req.Header.Set("If-Match", `W/"1"`)
+	req.Header.Set("Content-Type", string(domain.MergePatch))
+	router.ServeHTTP(w, req)
+
+	// THEN
+	patchedUser := domain.User{}
+	err := json.Unmarshal([]byte(w.Body.String()), &patchedUser)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, expectedUser.Name, patchedUser.Name)
+}
+
+func Test_Should_Return_Unsupported_Media_Type_Err_When_Invoke_Patch_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPatch, "/api/v1/users/5", bytes.NewBufferString("name=patched-user"))
+	req.Header.Set("If-Match", `W/"1"`)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 415, w.Code)
+}
+
+func Test_Should_Delete_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+
+	// WHEN
+	_userMockUseCase.EXPECT().DeleteUserById(gomock.Any(), gomock.Any(), uint(1)).Return(nil)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	req.Header.Set("If-Match", `W/"1"`)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 204, w.Code)
+}
+
+func Test_Should_Return_Not_Found_Err_When_Invoke_Delete_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 99
+
+	// WHEN
+	_userMockUseCase.EXPECT().DeleteUserById(gomock.Any(), id, uint(1)).Return(domain.NewUserNotFoundError(id))
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	req.Header.Set("If-Match", `W/"1"`)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 404, w.Code)
+}
+
+func Test_Should_Return_Bad_Request_Err_When_Invoke_Delete_User_With_Invalid_Id(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/api/v1/users/abc", nil)
+	req.Header.Set("If-Match", `W/"1"`)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 400, w.Code)
+}
+
+func Test_Should_Return_Unexpected_Err_When_Invoke_Delete_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	gormErr := errors.New("Unexpected Error")
+	expectedErr := domain.NewUnexpectedError(gormErr.Error())
+
+	// WHEN
+	_userMockUseCase.EXPECT().DeleteUserById(gomock.Any(), gomock.Any(), uint(1)).Return(expectedErr)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	req.Header.Set("If-Match", `W/"1"`)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	resErr := domain.ProblemDetails{}
+
+	assert.NotEmpty(t, w.Body.String())
+	err := json.Unmarshal([]byte(w.Body.String()), &resErr)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, expectedErr.Message, resErr.Detail)
+	assert.Equal(t, expectedErr.Code, resErr.Code)
+}
+
+func Test_Should_Restore_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	expectedUser := domain.User{ID: id, Name: "test", Age: 18, Email: "test@example.com"}
+
+	// WHEN
+	_userMockUseCase.EXPECT().RestoreUserById(gomock.Any(), id).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d/restore", id)
+	req, _ := http.NewRequest(http.MethodPost, url, nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	u := domain.User{}
+	err := json.Unmarshal([]byte(w.Body.String()), &u)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, id, u.ID)
+}
+
+func Test_Should_Suspend_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	change := domain.StatusChange{Reason: "chargeback", Actor: "support"}
+	expectedUser := domain.User{ID: id, Name: "test", Age: 18, Email: "test@example.com", Status: domain.UserSuspended, StatusReason: change.Reason}
+
+	// WHEN
+	_userMockUseCase.EXPECT().TransitionUser(gomock.Any(), id, domain.TransitionSuspend, change).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	body, _ := json.Marshal(change)
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/users/%d/suspend", id), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	u := domain.User{}
+	err := json.Unmarshal([]byte(w.Body.String()), &u)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, domain.UserSuspended, u.Status)
+	assert.Equal(t, "chargeback", u.StatusReason)
+}
+
+func Test_Should_Return_Conflict_For_Invalid_Status_Transition(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+
+	// WHEN
+	_userMockUseCase.EXPECT().TransitionUser(gomock.Any(), id, domain.TransitionActivate, domain.StatusChange{}).
+		Return(domain.User{}, domain.NewInvalidTransitionError("User can not activate from status deactivated, ID: 1"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/users/%d/activate", id), nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	problem := domain.ProblemDetails{}
+	err := json.Unmarshal([]byte(w.Body.String()), &problem)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 409, w.Code)
+	assert.Equal(t, domain.ErrCodeInvalidTransition, problem.Code)
+}
+
+func Test_Should_Return_User_History_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	asOf := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	expectedHistory := domain.UserHistory{Entries: []domain.AuditEntry{{ID: 3, UserID: id, Action: domain.AuditCreate}}, User: &domain.User{ID: id}}
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserHistory(gomock.Any(), id, domain.HistoryQuery{Before: 4, Limit: 10, AsOf: asOf}).Return(expectedHistory, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/1/history?before=4&limit=10&as_of=2026-01-02T03:04:05Z", nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	history := domain.UserHistory{}
+	err := json.Unmarshal([]byte(w.Body.String()), &history)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Len(t, history.Entries, 1)
+	assert.Equal(t, id, history.User.ID)
+}
+
+func Test_Should_Return_Bad_Request_For_Invalid_History_As_Of(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/1/history?as_of=yesterday", nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 400, w.Code)
+}
+
+func Test_Should_Find_Deleted_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	expectedUser := domain.User{ID: id, Name: "test", Age: 18, Email: "test@example.com"}
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserByIdIncludingDeleted(gomock.Any(), id).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d?include_deleted=true", id)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 200, w.Code)
+}
+
+func Test_Should_Return_Forbidden_When_Non_Admin_Asks_For_Deleted_Users(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	expectedUser := domain.User{ID: id, Name: "test", Age: 18, Email: "test@example.com"}
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserByIdIncludingDeleted(gomock.Any(), id).Return(expectedUser, nil)
+
+	serve := func(url string, scopes string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer "+scopes)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	userCode := serve(fmt.Sprintf("/api/v1/users/%d?include_deleted=true", id), "users:read")
+	exportCode := serve("/api/v1/users/export?include_deleted=true", "users:read")
+	adminCode := serve(fmt.Sprintf("/api/v1/users/%d?include_deleted=true", id), "users:read+users:admin")
+
+	// THEN
+	assert.Equal(t, http.StatusForbidden, userCode)
+	assert.Equal(t, http.StatusForbidden, exportCode)
+	assert.Equal(t, http.StatusOK, adminCode)
+}
+
+func Test_Should_Not_Serve_User_Roles_When_Auth_Is_Off(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/api/v1/users/1/roles", strings.NewReader(`{"roles":["admin"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `W/"1"`)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_Should_Require_Users_Admin_Scope_To_Set_User_Roles(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	expectedUser := domain.User{ID: id, Name: "test", Age: 18, Roles: domain.Roles{"admin"}, Version: 3}
+
+	// WHEN
+	_userMockUseCase.EXPECT().SetUserRoles(gomock.Any(), id, uint(2), domain.Roles{"admin"}).Return(expectedUser, nil)
+
+	serve := func(scopes string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/users/%d/roles", id), strings.NewReader(`{"roles":["admin"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+scopes)
+		req.Header.Set("If-Match", `W/"2"`)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	writer := serve("users:read+users:write")
+	admin := serve("users:admin")
+
+	// THEN
+	assert.Equal(t, http.StatusForbidden, writer.Code)
+	assert.Equal(t, http.StatusOK, admin.Code)
+	assert.Equal(t, `W/"3"`, admin.Header().Get("ETag"))
+	assert.Contains(t, admin.Body.String(), `"roles":["admin"]`)
+}
+
+func Test_Should_Require_Users_Write_Scope_To_Create_User(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	byteUser, _ := json.Marshal(domain.User{Name: "created-user", Age: 22})
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserById(gomock.Any(), id).Return(domain.User{ID: id, Name: "test", Age: 18}, nil)
+	_userMockUseCase.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(domain.User{ID: 10, Name: "created-user", Age: 22}, nil)
+
+	serve := func(method string, url string, body []byte, scopes string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+scopes)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	readerPost := serve(http.MethodPost, "/api/v1/users", byteUser, "users:read")
+	readerGet := serve(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", id), nil, "users:read")
+	writerGet := serve(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", id), nil, "users:write")
+	writerPost := serve(http.MethodPost, "/api/v1/users", byteUser, "users:write")
+
+	// THEN
+	assert.Equal(t, http.StatusForbidden, readerPost)
+	assert.Equal(t, http.StatusOK, readerGet)
+	assert.Equal(t, http.StatusForbidden, writerGet)
+	assert.Equal(t, http.StatusCreated, writerPost)
+}
+
+func Test_Should_Return_Not_Modified_When_ETag_Matches_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	expectedUser := domain.User{ID: id, Name: "test", Age: 18, Version: 3}
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserById(gomock.Any(), id).Return(expectedUser, nil)
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("If-None-Match", `W/"3"`)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 304, w.Code)
+	assert.Equal(t, `W/"3"`, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+}
+
+func Test_Should_Return_Precondition_Required_When_Invoke_Update_User_Without_If_Match(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	byteUser, _ := json.Marshal(domain.User{Name: "updated-user", Age: 22, Email: "updated-user@example.com"})
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/api/v1/users/1", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 428, w.Code)
+}
+
+func Test_Should_Return_Precondition_Failed_Err_When_Invoke_Delete_User_With_MockUserUseCase(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+
+	// WHEN
+	_userMockUseCase.EXPECT().DeleteUserById(gomock.Any(), id, uint(2)).Return(domain.NewPreconditionFailedError("User has been modified, ID: 1"))
+
+	w := httptest.NewRecorder()
+	url := fmt.Sprintf("/api/v1/users/%d", id)
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	req.Header.Set("If-Match", `W/"2"`)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 412, w.Code)
+}
+
+func Test_Should_Store_Response_For_Idempotency_Key(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	u := domain.User{Name: "created-user", Age: 22, Email: "created-user@example.com"}
+	byteUser, _ := json.Marshal(u)
+	expectedUser := domain.User{ID: 10, Name: u.Name, Age: u.Age}
+
+	// WHEN
+	_idempotencyMockRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(true, nil)
+	_userMockUseCase.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(expectedUser, nil)
+	_idempotencyMockRepo.EXPECT().Complete(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record domain.IdempotencyRecord) *domain.AppError {
+		assert.Equal(t, "key-1", record.Key)
+		assert.Equal(t, 201, record.ResponseStatus)
+		assert.Contains(t, string(record.ResponseBody), "created-user")
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 201, w.Code)
+}
+
+func Test_Should_Release_Idempotency_Key_When_Response_Cannot_Be_Stored(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	byteUser, _ := json.Marshal(domain.User{Name: "created-user", Age: 22})
+
+	// WHEN
+	_idempotencyMockRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(true, nil)
+	_userMockUseCase.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(domain.User{ID: 10, Name: "created-user", Age: 22}, nil)
+	_idempotencyMockRepo.EXPECT().Complete(gomock.Any(), gomock.Any()).Return(domain.NewUnexpectedError("Database is unavailable."))
+	_idempotencyMockRepo.EXPECT().DeleteByKey(gomock.Any(), "key-1").Return(nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 201, w.Code)
+}
+
+func Test_Should_Release_Idempotency_Key_When_Handler_Panics(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	u := domain.User{Name: "created-user", Age: 22, Email: "created-user@example.com"}
+	byteUser, _ := json.Marshal(u)
+
+	// WHEN
+	_idempotencyMockRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(true, nil)
+	_userMockUseCase.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+			panic("unexpected failure")
+		})
+	_idempotencyMockRepo.EXPECT().DeleteByKey(gomock.Any(), "key-1").Return(nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func Test_Should_Replay_Response_For_Idempotency_Key(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	byteUser, _ := json.Marshal(domain.User{Name: "created-user", Age: 22, Email: "created-user@example.com"})
+	var fingerprint string
+	storedBody := []byte(`{"id":10,"name":"created-user","age":22}`)
+
+	// WHEN
+	_idempotencyMockRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record domain.IdempotencyRecord) (bool, *domain.AppError) {
+		fingerprint = record.Fingerprint
+		return false, nil
+	})
+	_idempotencyMockRepo.EXPECT().GetByKey(gomock.Any(), "key-1").DoAndReturn(func(ctx context.Context, key string) (domain.IdempotencyRecord, *domain.AppError) {
+		return domain.IdempotencyRecord{Key: key, Fingerprint: fingerprint, Completed: true, ResponseStatus: 201, ResponseContentType: "application/json", ResponseBody: storedBody}, nil
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 201, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, string(storedBody), w.Body.String())
+}
+
+func Test_Should_Scope_Idempotency_Key_By_Principal(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	byteUser, _ := json.Marshal(domain.User{Name: "created-user", Age: 22})
+	var reservedKey string
+
+	// WHEN
+	_idempotencyMockRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record domain.IdempotencyRecord) (bool, *domain.AppError) {
+		reservedKey = record.Key
+		return false, nil
+	})
+	_idempotencyMockRepo.EXPECT().GetByKey(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, key string) (domain.IdempotencyRecord, *domain.AppError) {
+		assert.Equal(t, reservedKey, key)
+		return domain.IdempotencyRecord{Key: key, Fingerprint: "other", Completed: true}, nil
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer users:write")
+	req.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.NotEqual(t, "key-1", reservedKey)
+	assert.True(t, strings.HasSuffix(reservedKey, ":key-1"))
+}
+
+func Test_Should_Return_Unprocessable_When_Idempotency_Key_Reused_With_Different_Payload(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	byteUser, _ := json.Marshal(domain.User{Name: "other-user", Age: 30, Email: "other-user@example.com"})
+
+	// WHEN
+	_idempotencyMockRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).Return(false, nil)
+	_idempotencyMockRepo.EXPECT().GetByKey(gomock.Any(), "key-1").Return(domain.IdempotencyRecord{Key: "key-1", Fingerprint: "other", Completed: true}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 422, w.Code)
+}
+
+func Test_Should_Return_Conflict_When_Idempotent_Request_In_Progress(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	byteUser, _ := json.Marshal(domain.User{Name: "created-user", Age: 22, Email: "created-user@example.com"})
+	var fingerprint string
+
+	// WHEN
+	_idempotencyMockRepo.EXPECT().Reserve(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, record domain.IdempotencyRecord) (bool, *domain.AppError) {
+		fingerprint = record.Fingerprint
+		return false, nil
+	})
+	_idempotencyMockRepo.EXPECT().GetByKey(gomock.Any(), "key-1").DoAndReturn(func(ctx context.Context, key string) (domain.IdempotencyRecord, *domain.AppError) {
+		return domain.IdempotencyRecord{Key: key, Fingerprint: fingerprint}, nil
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewBuffer(byteUser))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "key-1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 409, w.Code)
+}
+
+func Test_Should_Return_Results_For_Batch(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	request := domain.BatchRequest{Operation: domain.BatchDelete, Mode: domain.BatchBestEffort, Items: []domain.User{{ID: 1}, {ID: 2}}}
+	byteRequest, _ := json.Marshal(request)
+	expectedResponse := domain.BatchResponse{
+		Operation: request.Operation,
+		Mode:      request.Mode,
+		Succeeded: 2,
+		Results:   []domain.BatchItemResult{{Index: 0, Status: 204}, {Index: 1, Status: 204}},
+	}
+
+	// WHEN
+	_userMockUseCase.EXPECT().BatchUsers(gomock.Any(), request).Return(expectedResponse)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users:batch", bytes.NewBuffer(byteRequest))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	var response domain.BatchResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, expectedResponse, response)
+}
+
+func Test_Should_Return_Payload_Too_Large_When_Batch_Exceeds_Limit(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	request := domain.BatchRequest{Operation: domain.BatchDelete, Mode: domain.BatchAtomic, Items: []domain.User{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}}
+	byteRequest, _ := json.Marshal(request)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users:batch", bytes.NewBuffer(byteRequest))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	var problem domain.ProblemDetails
+	_ = json.Unmarshal(w.Body.Bytes(), &problem)
+	assert.Equal(t, 413, w.Code)
+	assert.Equal(t, domain.ErrCodeBatchTooLarge, problem.Code)
+}
+
+func Test_Should_Return_Not_Found_For_Unknown_User_Action(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users:merge", bytes.NewBuffer([]byte("{}")))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 404, w.Code)
+}
+
+func Test_Should_Export_Users_With_Gzip(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	expectedFilter := domain.UserFilter{Name: "john", MinAge: 18}
+
+	// WHEN
+	_userMockUseCase.EXPECT().ExportUsers(gomock.Any(), expectedFilter, domain.ExportNDJSON, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, filter domain.UserFilter, format domain.ExportFormat, w io.Writer) *domain.AppError {
+			_, _ = w.Write([]byte(`{"id":1,"name":"john"}` + "\n"))
+			return nil
+		})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/export?format=ndjson&name=john&min_age=18", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	reader, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	body, _ := io.ReadAll(reader)
+	assert.Equal(t, `{"id":1,"name":"john"}`+"\n", string(body))
+}
+
+func Test_Should_Return_Problem_When_Export_Fails_Before_Streaming(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	_userMockUseCase.EXPECT().ExportUsers(gomock.Any(), gomock.Any(), domain.ExportCSV, gomock.Any()).
+		Return(domain.NewServiceUnavailableError("Database is unavailable."))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/export", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 503, w.Code)
+	assert.Equal(t, "", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, domain.ProblemContentType, w.Header().Get("Content-Type"))
+}
+
+func Test_Should_Return_Bad_Request_For_Invalid_Export_Format(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/export?format=xml", nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 400, w.Code)
+}
+
+func multipartImportRequest(filename string, content string, fields map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", filename)
+	_, _ = part.Write([]byte(content))
+	for key, value := range fields {
+		_ = writer.WriteField(key, value)
+	}
+	_ = writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/users/import", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func Test_Should_Import_Small_File_Synchronously(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	expectedReport := domain.ImportReport{Status: domain.ImportCompleted, DryRun: true, TotalRows: 1, ImportedRows: 1, Errors: []domain.ImportRowError{}}
+
+	// WHEN
+	_userMockUseCase.EXPECT().ImportUsers(gomock.Any(), domain.ImportCSV, gomock.Any(), true).Return(expectedReport)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, multipartImportRequest("users.csv", "name,age\nJohn,30\n", map[string]string{"dry_run": "true"}))
+
+	// THEN
+	var report domain.ImportReport
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, expectedReport, report)
+}
+
+func Test_Should_Start_Import_Job_For_Large_File(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	content := strings.Repeat(`{"name":"John Doe","age":30}`+"\n", 10)
+
+	// WHEN
+	_userMockUseCase.EXPECT().StartImportJob(gomock.Any(), domain.ImportNDJSON, gomock.Any(), false).
+		DoAndReturn(func(ctx context.Context, format domain.ImportFormat, r io.ReadCloser, dryRun bool) domain.ImportReport {
+			data, _ := io.ReadAll(r)
+			assert.Equal(t, content, string(data))
+			assert.Nil(t, r.Close())
+			return domain.ImportReport{ID: "job-1", Status: domain.ImportPending}
+		})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, multipartImportRequest("users.jsonl", content, nil))
+
+	// THEN
+	assert.Equal(t, 202, w.Code)
+	assert.Equal(t, "/api/v1/users/import/job-1", w.Header().Get("Location"))
+}
+
+func Test_Should_Return_Unsupported_Media_Type_For_Unknown_Import_File(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, multipartImportRequest("users.xlsx", "name,age\n", nil))
+
+	// THEN
+	assert.Equal(t, 415, w.Code)
+}
+
+func Test_Should_Return_Import_Job_Status(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetImportJob(gomock.Any(), "job-1").Return(domain.ImportReport{ID: "job-1", Status: domain.ImportRunning}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/import/job-1", nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"running"`)
+}
+
+func Test_Should_Create_Webhook_Subscription_With_MockWebhookUseCase(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	body := `{"url":"https://partner.example.com/hooks","event_types":["user.created"]}`
+	expected := domain.WebhookSubscription{ID: 1, URL: "https://partner.example.com/hooks", Secret: "whsec_1",
+		EventTypes: []domain.EventType{domain.UserCreated}, Active: true}
+
+	// WHEN
+	_webhookMockUseCase.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).Return(expected, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+domain.ScopeWebhooksAdmin)
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	subscription := domain.WebhookSubscription{}
+	err := json.Unmarshal(w.Body.Bytes(), &subscription)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, w.Code)
+	assert.Equal(t, expected.Secret, subscription.Secret)
+}
+
+func Test_Should_Return_Not_Found_When_Webhook_Subscription_Does_Not_Exist(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// WHEN
+	_webhookMockUseCase.EXPECT().GetSubscription(gomock.Any(), uint(9)).Return(domain.WebhookSubscription{}, domain.NewNotFoundError("Webhook subscription not found, ID: 9"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/webhooks/9", nil)
+	req.Header.Set("Authorization", "Bearer "+domain.ScopeWebhooksAdmin)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, domain.ProblemContentType, w.Header().Get("Content-Type"))
+}
+
+func Test_Should_List_Webhook_Deliveries_By_Status_With_MockWebhookUseCase(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	deliveries := []domain.WebhookDelivery{{ID: 7, SubscriptionID: 1, EventID: "e1", Status: domain.DeliveryDeadLetter, Payload: []byte(`{}`)}}
+
+	// WHEN
+	_webhookMockUseCase.EXPECT().ListDeliveries(gomock.Any(), uint(1), domain.DeliveryDeadLetter, 10).Return(deliveries, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/webhooks/1/deliveries?status=dead_letter&limit=10", nil)
+	req.Header.Set("Authorization", "Bearer "+domain.ScopeWebhooksAdmin)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	var res []domain.WebhookDelivery
+	err := json.Unmarshal(w.Body.Bytes(), &res)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, w.Code)
+	assert.Len(t, res, 1)
+}
+
+func Test_Should_Return_Bad_Request_When_Delivery_Status_Is_Invalid(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/webhooks/1/deliveries?status=lost", nil)
+	req.Header.Set("Authorization", "Bearer "+domain.ScopeWebhooksAdmin)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 400, w.Code)
+}
+
+func Test_Should_Not_Serve_Webhooks_When_Auth_Is_Off(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(`{"url":"https://partner.example.com/hooks","event_types":["user.created"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func Test_Should_Require_Webhooks_Admin_Scope_When_Auth_Is_Enabled(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// WHEN
+	anonymous := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/webhooks", nil)
+	router.ServeHTTP(anonymous, req)
+
+	reader := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer users:read")
+	router.ServeHTTP(reader, req)
+
+	_webhookMockUseCase.EXPECT().ListSubscriptions(gomock.Any()).Return([]domain.WebhookSubscription{}, nil)
+	admin := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/api/v1/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer "+domain.ScopeWebhooksAdmin)
+	router.ServeHTTP(admin, req)
+
+	// THEN
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+	assert.Equal(t, http.StatusForbidden, reader.Code)
+	assert.Equal(t, http.StatusOK, admin.Code)
+}
+
+func Test_Should_Not_Serve_API_Keys_Without_Credentials(t *testing.T) {
+	// WHEN
+	open := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(`{"name":"billing-service","scopes":["users:read"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	handlerSetupRouter(t).ServeHTTP(open, req)
+
+	anonymous := httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(`{"name":"billing-service","scopes":["users:read"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	handlerSetupAuthRouter(t).ServeHTTP(anonymous, req)
+
+	// THEN
+	assert.Equal(t, http.StatusNotFound, open.Code)
+	assert.Equal(t, http.StatusUnauthorized, anonymous.Code)
+}
+
+func Test_Should_Issue_API_Key_With_MockAPIKeyUseCase(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	body := `{"name":"billing-service","scopes":["users:read"]}`
+	expected := domain.APIKey{ID: 1, Name: "billing-service", Prefix: "gak_0123456789ab", Scopes: []string{"users:read"}, Key: "gak_0123456789ab_secret"}
+
+	// WHEN
+	_apiKeyMockUseCase.EXPECT().CreateAPIKey(gomock.Any(), domain.APIKeyRequest{Name: "billing-service", Scopes: []string{"users:read"}}).Return(expected, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+domain.ScopeAPIKeysAdmin)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	apiKey := domain.APIKey{}
+	err := json.Unmarshal(w.Body.Bytes(), &apiKey)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Equal(t, expected.Key, apiKey.Key)
+}
+
+func Test_Should_Redeliver_Webhook_Delivery_With_MockWebhookUseCase(t *testing.T) {
+	router := handlerSetupAuthRouter(t)
+
+	// GIVEN
+	delivery := domain.WebhookDelivery{ID: 7, SubscriptionID: 1, Status: domain.DeliveryPending, Payload: []byte(`{}`)}
+
+	// WHEN
+	_webhookMockUseCase.EXPECT().Redeliver(gomock.Any(), uint(1), uint64(7)).Return(delivery, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/webhooks/1/deliveries/7/redeliver", nil)
+	req.Header.Set("Authorization", "Bearer "+domain.ScopeWebhooksAdmin)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 202, w.Code)
+}
+
+func Test_Should_Stream_Missed_User_Events_After_Last_Event_ID(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	logged := []domain.OutboxEvent{
+		{ID: 1, EventID: "e1", EventType: domain.UserCreated, AggregateType: domain.UserAggregate, AggregateID: "1", Payload: []byte(`{"id":1}`)},
+		{ID: 2, EventID: "e2", EventType: domain.UserUpdated, AggregateType: domain.UserAggregate, AggregateID: "1", Payload: []byte(`{"id":1}`)},
+	}
+	_outboxMockRepo.EXPECT().FetchLatest(gomock.Any(), 10).Return(logged, nil)
+	_ = _broker.Prime(context.Background())
+
+	// WHEN
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/users/events", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "1")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "id:2\nevent:user.updated\n")
+	assert.NotContains(t, w.Body.String(), "id:1\n")
+}
+
+func Test_Should_Measure_Requests_Asking_For_Event_Stream_Outside_The_Stream_Route(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	var id uint = 1
+	measured := func() uint64 {
+		var metric dto.Metric
+		_ = metrics.HttpRequestDuration.WithLabelValues("[GET] /api/v1/users/:id").(prometheus.Histogram).Write(&metric)
+		return metric.GetHistogram().GetSampleCount()
+	}
+	before := measured()
+
+	// WHEN
+	_userMockUseCase.EXPECT().GetUserById(gomock.Any(), id).Return(domain.User{ID: id, Name: "test", Age: 18}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", id), nil)
+	req.Header.Set("Accept", "text/event-stream")
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, before+1, measured())
+}
+
+func Test_Should_Return_Service_Unavailable_When_Event_Stream_Is_Full(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// GIVEN
+	_, _ = _broker.Subscribe(nil, nil)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/events?types=user.created", nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 503, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func Test_Should_Return_Bad_Request_When_Event_Type_Is_Unknown(t *testing.T) {
+	router := handlerSetupRouter(t)
+
+	// WHEN
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/api/v1/users/events?types=user.renamed", nil)
+	router.ServeHTTP(w, req)
+
+	// THEN
+	assert.Equal(t, 400, w.Code)
+}
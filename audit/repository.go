@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"context"
+	"go-app/database"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks/mockAuditRepository.go -package=mocks go-app/domain AuditRepository
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository only inserts and reads, the table rejects updates and deletes.
+func NewAuditRepository(db *gorm.DB) domain.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Add(ctx context.Context, entries ...domain.AuditEntry) *domain.AppError {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := database.Conn(ctx, r.db).Create(&entries).Error; err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *auditRepository) ListByUser(ctx context.Context, userID uint, before uint64, until time.Time, limit int) ([]domain.AuditEntry, *domain.AppError) {
+	query := database.Conn(ctx, r.db).Where("user_id = ?", userID)
+	if before != 0 {
+		query = query.Where("id < ?", before)
+	}
+	if !until.IsZero() {
+		query = query.Where("created_at <= ?", until)
+	}
+
+	var entries []domain.AuditEntry
+	if err := query.Order("id DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return entries, nil
+}
+
+func (r *auditRepository) ListByUserUntil(ctx context.Context, userID uint, until time.Time) ([]domain.AuditEntry, *domain.AppError) {
+	var entries []domain.AuditEntry
+	err := database.Conn(ctx, r.db).Where("user_id = ? AND created_at <= ?", userID, until).Order("id").Find(&entries).Error
+	if err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return entries, nil
+}
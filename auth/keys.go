@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Largest JWKS document read from a provider.
+const maxJWKSSize = 1 << 20
+
+// KeySource finds the key that verifies a token by the key ID in its header.
+type KeySource interface {
+	key(ctx context.Context, kid string) (verificationKey, error)
+}
+
+// StaticKeys is a fixed key set, rotating a key means restarting with the new set.
+type StaticKeys struct {
+	keys map[string]verificationKey
+}
+
+/*
+NewStaticKeys builds a key set from a JWKS document and an HS256 secret, either may be empty.
+The secret verifies tokens without a key ID.
+*/
+func NewStaticKeys(jwks []byte, hmacSecret []byte) (*StaticKeys, error) {
+	keys := map[string]verificationKey{}
+	if len(jwks) > 0 {
+		var err error
+		if keys, err = parseJWKS(jwks); err != nil {
+			return nil, err
+		}
+	}
+	if len(hmacSecret) > 0 {
+		keys[""] = verificationKey{key: hmacSecret, alg: "HS256"}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("static key set is empty")
+	}
+	return &StaticKeys{keys: keys}, nil
+}
+
+func (s *StaticKeys) key(_ context.Context, kid string) (verificationKey, error) {
+	if key, ok := lookupKey(s.keys, kid); ok {
+		return key, nil
+	}
+	return verificationKey{}, fmt.Errorf("unknown key ID %q", kid)
+}
+
+type RemoteKeysOptions struct {
+	// Keys older than this are fetched again.
+	RefreshInterval time.Duration
+	// An unknown key ID fetches the set again, at most once per interval.
+	MinRefreshInterval time.Duration
+	Timeout            time.Duration
+}
+
+// RemoteKeys caches the key set published at a JWKS URL and follows the rotations of the provider.
+type RemoteKeys struct {
+	url     string
+	client  *http.Client
+	logger  *zap.Logger
+	options RemoteKeysOptions
+
+	// Concurrent callers share one fetch, the lock is only held to read or swap the keys.
+	refreshes   singleflight.Group
+	mu          sync.RWMutex
+	keys        map[string]verificationKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func NewRemoteKeys(url string, logger *zap.Logger, options RemoteKeysOptions) *RemoteKeys {
+	return &RemoteKeys{url: url, client: &http.Client{Timeout: options.Timeout}, logger: logger, options: options}
+}
+
+func (r *RemoteKeys) key(ctx context.Context, kid string) (verificationKey, error) {
+	r.mu.RLock()
+	key, found := lookupKey(r.keys, kid)
+	fresh := time.Since(r.fetchedAt) < r.options.RefreshInterval
+	r.mu.RUnlock()
+	if found && fresh {
+		return key, nil
+	}
+
+	// A stale set is still used while the provider can not be reached. The fetch does not depend on the request
+	// which started it, a cancelled request only stops waiting for it.
+	refreshed := r.refreshes.DoChan("jwks", func() (interface{}, error) {
+		r.refresh(ctx)
+		return nil, nil
+	})
+	select {
+	case <-refreshed:
+	case <-ctx.Done():
+		return verificationKey{}, ctx.Err()
+	}
+
+	r.mu.RLock()
+	key, found = lookupKey(r.keys, kid)
+	r.mu.RUnlock()
+	if !found {
+		return verificationKey{}, fmt.Errorf("unknown key ID %q", kid)
+	}
+	return key, nil
+}
+
+// refresh replaces the cached keys with the set published by the provider, at most once per MinRefreshInterval.
+func (r *RemoteKeys) refresh(ctx context.Context) {
+	r.mu.Lock()
+	if time.Since(r.attemptedAt) < r.options.MinRefreshInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.attemptedAt = time.Now()
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.options.Timeout)
+	defer cancel()
+	keys, err := r.fetch(ctx)
+	if err != nil {
+		metrics.AuthJWKSRefreshes.WithLabelValues("failed").Inc()
+		r.logger.Warn("JWKS could not be refreshed.", zap.String("url", r.url), zap.Error(err))
+		return
+	}
+	metrics.AuthJWKSRefreshes.WithLabelValues("succeeded").Inc()
+
+	r.mu.Lock()
+	r.keys = keys
+	r.fetchedAt = time.Now()
+	r.mu.Unlock()
+}
+
+// fetch reads the key set published by the provider.
+func (r *RemoteKeys) fetch(ctx context.Context) (map[string]verificationKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint answered %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+// lookupKey returns the key with the ID, a token without a key ID uses the only key of a set.
+func lookupKey(keys map[string]verificationKey, kid string) (verificationKey, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return verificationKey{}, false
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"go-app/domain"
+	"strings"
+	"time"
+)
+
+// Signing algorithms accepted in tokens, the key type of each is checked when the signature is verified.
+var validMethods = []string{"RS256", "ES256", "HS256"}
+
+type VerifierOptions struct {
+	Issuer   string
+	Audience string
+	// Clock skew tolerated on exp, nbf and iat.
+	Leeway time.Duration
+}
+
+// Verifier checks JWT bearer tokens, it is safe for concurrent use.
+type Verifier struct {
+	keys   KeySource
+	parser *jwt.Parser
+}
+
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	// Space separated scopes, as in RFC 8693.
+	Scope string `json:"scope"`
+}
+
+func NewVerifier(keys KeySource, options VerifierOptions) *Verifier {
+	return &Verifier{
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(validMethods),
+			jwt.WithIssuer(options.Issuer),
+			jwt.WithAudience(options.Audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+			jwt.WithLeeway(options.Leeway),
+		),
+	}
+}
+
+func (v *Verifier) Verify(ctx context.Context, token string) (domain.Principal, *domain.AppError) {
+	var claims tokenClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := v.keys.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.alg != "" && key.alg != token.Method.Alg() {
+			return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+		}
+		return key.key, nil
+	})
+	if err != nil {
+		return domain.Principal{}, domain.NewUnauthorizedError("Invalid bearer token.").WithCause(err)
+	}
+	if claims.Subject == "" {
+		return domain.Principal{}, domain.NewUnauthorizedError("Bearer token has no subject.")
+	}
+
+	return domain.Principal{
+		Subject: claims.Subject,
+		Issuer:  claims.Issuer,
+		Scopes:  strings.Fields(claims.Scope),
+	}, nil
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "go-app"
+)
+
+// jwksServer publishes the public keys it is given, they can be swapped to simulate a rotation.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches atomic.Int32
+	// gate holds the fetches until it is closed, when set.
+	gate chan struct{}
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		s.mu.Lock()
+		gate := s.gate
+		s.mu.Unlock()
+		if gate != nil {
+			<-gate
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// hold makes the next fetches wait until the returned function is called.
+func (s *jwksServer) hold() func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	gate := make(chan struct{})
+	s.gate = gate
+	return func() { close(gate) }
+}
+
+func (s *jwksServer) publish(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-42",
+		"scope": "users:read users:write",
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func newRemoteVerifier(server *jwksServer) *Verifier {
+	keys := NewRemoteKeys(server.URL, zap.NewNop(), RemoteKeysOptions{
+		RefreshInterval: time.Hour,
+		Timeout:         time.Second,
+	})
+	return NewVerifier(keys, VerifierOptions{Issuer: testIssuer, Audience: testAudience, Leeway: time.Second})
+}
+
+func Test_Should_Verify_RS256_And_ES256_Tokens_From_JWKS(t *testing.T) {
+	// GIVEN
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+	verifier := newRemoteVerifier(server)
+
+	// WHEN
+	rsaPrincipal, rsaErr := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, validClaims()))
+	ecPrincipal, ecErr := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodES256, "ec-1", ecKey, validClaims()))
+
+	// THEN
+	assert.Nil(t, rsaErr)
+	assert.Nil(t, ecErr)
+	assert.Equal(t, domain.Principal{Subject: "user-42", Issuer: testIssuer, Scopes: []string{"users:read", "users:write"}}, rsaPrincipal)
+	assert.Equal(t, "user-42", ecPrincipal.Subject)
+	assert.Equal(t, int32(1), server.fetches.Load())
+}
+
+func Test_Should_Verify_HS256_Token_With_Static_Secret(t *testing.T) {
+	// GIVEN
+	secret := []byte("a-secret-of-at-least-32-bytes-long!")
+	keys, err := NewStaticKeys(nil, secret)
+	assert.Nil(t, err)
+	verifier := NewVerifier(keys, VerifierOptions{Issuer: testIssuer, Audience: testAudience})
+
+	// WHEN
+	principal, verifyErr := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", secret, validClaims()))
+	_, wrongSecretErr := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "", []byte("another-secret-of-32-bytes-long!!"), validClaims()))
+
+	// THEN
+	assert.Nil(t, verifyErr)
+	assert.Equal(t, "user-42", principal.Subject)
+	assert.NotNil(t, wrongSecretErr)
+	assert.Equal(t, domain.ErrCodeUnauthorized, wrongSecretErr.Code)
+}
+
+func Test_Should_Reject_Tokens_With_Invalid_Claims(t *testing.T) {
+	// GIVEN
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("rsa-1", &rsaKey.PublicKey))
+	verifier := newRemoteVerifier(server)
+
+	cases := map[string]func(claims jwt.MapClaims){
+		"wrong issuer":   func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+		"wrong audience": func(claims jwt.MapClaims) { claims["aud"] = "another-app" },
+		"expired":        func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no expiry":      func(claims jwt.MapClaims) { delete(claims, "exp") },
+		"no subject":     func(claims jwt.MapClaims) { delete(claims, "sub") },
+	}
+
+	for name, modify := range cases {
+		claims := validClaims()
+		modify(claims)
+
+		// WHEN
+		_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims))
+
+		// THEN
+		assert.NotNil(t, err, name)
+		assert.Equal(t, 401, err.Status, name)
+	}
+}
+
+func Test_Should_Reject_HS256_Token_Signed_With_RSA_Public_Key(t *testing.T) {
+	// GIVEN
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("rsa-1", &rsaKey.PublicKey))
+	verifier := newRemoteVerifier(server)
+	publicKey, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+
+	// WHEN
+	_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "rsa-1", publicKey, validClaims()))
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeUnauthorized, err.Code)
+}
+
+func Test_Should_Fetch_JWKS_Again_When_Key_Is_Rotated(t *testing.T) {
+	// GIVEN
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("old", &oldKey.PublicKey))
+	verifier := newRemoteVerifier(server)
+	_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims()))
+	assert.Nil(t, err)
+
+	// WHEN
+	server.publish(rsaJWK("new", &newKey.PublicKey))
+	principal, rotatedErr := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims()))
+	_, unknownErr := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "unknown", newKey, validClaims()))
+
+	// THEN
+	assert.Nil(t, rotatedErr)
+	assert.Equal(t, "user-42", principal.Subject)
+	assert.NotNil(t, unknownErr)
+	assert.Equal(t, int32(3), server.fetches.Load())
+}
+
+func Test_Should_Verify_With_Cached_Keys_While_JWKS_Is_Fetched(t *testing.T) {
+	// GIVEN
+	oldKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	server := newJWKSServer(t)
+	server.publish(rsaJWK("old", &oldKey.PublicKey))
+	verifier := newRemoteVerifier(server)
+	_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims()))
+	assert.Nil(t, err)
+
+	// WHEN
+	release := server.hold()
+	server.publish(rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey))
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancelledErr := make(chan *domain.AppError)
+	go func() {
+		_, err := verifier.Verify(cancelled, sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims()))
+		cancelledErr <- err
+	}()
+	waitingErr := make(chan *domain.AppError)
+	go func() {
+		_, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "new", newKey, validClaims()))
+		waitingErr <- err
+	}()
+	for server.fetches.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	_, cachedErr := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "old", oldKey, validClaims()))
+	cancel()
+	firstErr := <-cancelledErr
+	release()
+
+	// THEN
+	assert.Nil(t, cachedErr)
+	assert.NotNil(t, firstErr)
+	assert.Nil(t, <-waitingErr)
+	assert.Equal(t, int32(2), server.fetches.Load())
+}
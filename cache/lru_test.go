@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Evict_Least_Recently_Used_Entry_When_Full(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+
+	// GIVEN
+	_ = lru.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = lru.Set(ctx, "b", []byte("2"), time.Minute)
+	_, _, _ = lru.Get(ctx, "a")
+
+	// WHEN
+	_ = lru.Set(ctx, "c", []byte("3"), time.Minute)
+
+	// THEN
+	_, foundA, _ := lru.Get(ctx, "a")
+	_, foundB, _ := lru.Get(ctx, "b")
+	value, foundC, _ := lru.Get(ctx, "c")
+	assert.True(t, foundA)
+	assert.False(t, foundB)
+	assert.True(t, foundC)
+	assert.Equal(t, []byte("3"), value)
+	assert.Equal(t, 2, lru.Len())
+}
+
+func Test_Should_Expire_Entry_After_TTL(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+	now := time.Now()
+	lru.now = func() time.Time { return now }
+
+	// GIVEN
+	_ = lru.Set(ctx, "a", []byte("1"), time.Minute)
+
+	// WHEN
+	_, foundBefore, _ := lru.Get(ctx, "a")
+	now = now.Add(time.Minute)
+	_, foundAfter, _ := lru.Get(ctx, "a")
+
+	// THEN
+	assert.True(t, foundBefore)
+	assert.False(t, foundAfter)
+	assert.Equal(t, 0, lru.Len())
+}
+
+func Test_Should_Delete_Entries(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRU(2)
+
+	// GIVEN
+	_ = lru.Set(ctx, "a", []byte("1"), time.Minute)
+	_ = lru.Set(ctx, "b", []byte{}, time.Minute)
+
+	// WHEN
+	err := lru.Delete(ctx, "a", "b", "missing")
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 0, lru.Len())
+}
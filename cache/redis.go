@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go-app/domain"
+	"time"
+)
+
+// Redis shares the cache between the instances of the application, so an invalidation reaches all of them.
+type Redis struct {
+	client *redis.Client
+	// Prepended to every key, so several applications can share a Redis database.
+	prefix string
+}
+
+func NewRedis(client *redis.Client, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+var _ domain.Cache = (*Redis)(nil)
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	prefixedKeys := make([]string, len(keys))
+	for i, key := range keys {
+		prefixedKeys[i] = c.prefix + key
+	}
+	return c.client.Del(ctx, prefixedKeys...).Err()
+}
+
+// Close releases the connections of the Redis client.
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
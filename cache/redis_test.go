@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func mockRedisSetup(t *testing.T) (*miniredis.Miniredis, *Redis) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return server, NewRedis(client, "test:")
+}
+
+func Test_Should_Set_And_Get_Prefixed_Entry_In_Redis(t *testing.T) {
+	server, cache := mockRedisSetup(t)
+	ctx := context.Background()
+
+	// WHEN
+	setErr := cache.Set(ctx, "user:1", []byte(`{"id":1}`), time.Minute)
+	value, found, getErr := cache.Get(ctx, "user:1")
+
+	// THEN
+	assert.Nil(t, setErr)
+	assert.Nil(t, getErr)
+	assert.True(t, found)
+	assert.Equal(t, []byte(`{"id":1}`), value)
+	assert.True(t, server.Exists("test:user:1"))
+	assert.Equal(t, time.Minute, server.TTL("test:user:1"))
+}
+
+func Test_Should_Miss_Expired_And_Deleted_Entries_In_Redis(t *testing.T) {
+	server, cache := mockRedisSetup(t)
+	ctx := context.Background()
+
+	// GIVEN
+	_ = cache.Set(ctx, "user:1", []byte{}, time.Second)
+	_ = cache.Set(ctx, "user:2", []byte(`{"id":2}`), time.Minute)
+
+	// WHEN
+	server.FastForward(2 * time.Second)
+	_, expiredFound, expiredErr := cache.Get(ctx, "user:1")
+	deleteErr := cache.Delete(ctx, "user:1", "user:2")
+	_, deletedFound, _ := cache.Get(ctx, "user:2")
+
+	// THEN
+	assert.Nil(t, expiredErr)
+	assert.False(t, expiredFound)
+	assert.Nil(t, deleteErr)
+	assert.False(t, deletedFound)
+}
+
+func Test_Should_Return_Error_When_Redis_Is_Down(t *testing.T) {
+	server, cache := mockRedisSetup(t)
+	server.Close()
+
+	// WHEN
+	_, found, err := cache.Get(context.Background(), "user:1")
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.False(t, found)
+}
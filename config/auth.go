@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"go-app/auth"
+	"go-app/domain"
+	"go.uber.org/zap"
+)
+
+// NewTokenVerifier returns the verifier of the bearer tokens, or nil when AUTH_ENABLED is off.
+func NewTokenVerifier(cfg *Auth, logger *zap.Logger) (domain.TokenVerifier, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Issuer == "" || cfg.Audience == "" {
+		return nil, errors.New("AUTH_ISSUER and AUTH_AUDIENCE are required")
+	}
+
+	var keys auth.KeySource
+	if cfg.JWKSURL != "" {
+		if cfg.JWKS != "" || cfg.HMACSecret != "" {
+			return nil, errors.New("AUTH_JWKS_URL can not be combined with AUTH_JWKS or AUTH_HS256_SECRET")
+		}
+		keys = auth.NewRemoteKeys(cfg.JWKSURL, logger, auth.RemoteKeysOptions{
+			RefreshInterval:    cfg.JWKSRefreshInterval,
+			MinRefreshInterval: cfg.JWKSMinRefreshInterval,
+			Timeout:            cfg.JWKSTimeout,
+		})
+	} else {
+		staticKeys, err := auth.NewStaticKeys([]byte(cfg.JWKS), []byte(cfg.HMACSecret))
+		if err != nil {
+			return nil, err
+		}
+		keys = staticKeys
+	}
+
+	return auth.NewVerifier(keys, auth.VerifierOptions{
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   cfg.Leeway,
+	}), nil
+}
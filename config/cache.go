@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go-app/cache"
+	"go-app/domain"
+)
+
+// ConnectUserCache returns the cache selected by USER_CACHE, or nil when caching is turned off.
+func ConnectUserCache(cfg *Cache) (domain.Cache, error) {
+	switch cfg.Backend {
+	case "none":
+		return nil, nil
+	case "memory":
+		return cache.NewLRU(cfg.Capacity), nil
+	case "redis":
+		client := redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddress,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		return cache.NewRedis(client, cfg.RedisKeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown user cache: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,15 @@
+package config
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ConnectDatabase connects to the database selected by DB_DRIVER.
+func ConnectDatabase(ctx context.Context, cfg *Database, logger *zap.Logger) (*gorm.DB, error) {
+	if cfg.Driver == "sqlite" {
+		return ConnectSQLite(cfg, logger)
+	}
+	return ConnectPostgres(ctx, cfg, logger)
+}
@@ -3,30 +3,37 @@ package config
 import (
 	"context"
 	"github.com/sethvargo/go-envconfig"
-	"log"
-	"sync"
+	"time"
 )
 
-var (
-	cfg        AppConfig
-	configOnce sync.Once
-)
+// Load reads the configuration from the environment variables.
+func Load(ctx context.Context) (*AppConfig, error) {
+	return LoadFrom(ctx, envconfig.OsLookuper())
+}
 
-func config() AppConfig {
-	configOnce.Do(func() {
-		ctx := context.Background()
-		if err := envconfig.Process(ctx, &cfg); err != nil {
-			log.Fatal(err)
-		}
-		log.Println("Environments initialized.")
-	})
-	return cfg
+// LoadFrom reads the configuration through lookuper, so tests can run instances with their own settings side by side.
+func LoadFrom(ctx context.Context, lookuper envconfig.Lookuper) (*AppConfig, error) {
+	var cfg AppConfig
+	if err := envconfig.ProcessWith(ctx, &envconfig.Config{Target: &cfg, Lookuper: lookuper}); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
 }
 
 type AppConfig struct {
-	Database *Database
-	NewRelic *NewRelic
-	Sentry   *Sentry
+	Database    *Database
+	NewRelic    *NewRelic
+	Sentry      *Sentry
+	SoftDelete  *SoftDelete
+	Idempotency *Idempotency
+	Batch       *Batch
+	Import      *Import
+	Transaction *Transaction
+	Outbox      *Outbox
+	Webhook     *Webhook
+	Stream      *Stream
+	Cache       *Cache
+	Auth        *Auth
 }
 
 type Database struct {
@@ -35,9 +42,43 @@ type Database struct {
 	Password     string `env:"POSTGRES_PASSWORD, default=postgres"`
 	Port         string `env:"POSTGRES_PORT, default=5432"`
 	DatabaseName string `env:"DATABASE_NAME, default=postgres"`
+
+	// postgres or sqlite, SQLite is meant for local development and tests.
+	Driver     string `env:"DB_DRIVER, default=postgres"`
+	SQLitePath string `env:"SQLITE_PATH, default=go-app.db"`
+	// database keeps the users in the database of DB_DRIVER, memory keeps them in the process.
+	UserRepository string `env:"USER_REPOSITORY, default=database"`
+
+	// Comma separated host:port list of read replicas, they share the credentials and database name of the primary.
+	Replicas              []string      `env:"POSTGRES_REPLICAS"`
+	ReplicaHealthInterval time.Duration `env:"DB_REPLICA_HEALTH_INTERVAL, default=5s"`
+	ReplicaHealthTimeout  time.Duration `env:"DB_REPLICA_HEALTH_TIMEOUT, default=1s"`
+
+	SlowQueryThreshold time.Duration `env:"DB_SLOW_QUERY_THRESHOLD, default=200ms"`
+
+	// Start up waits for Postgres until the connect timeout passes.
+	ConnectTimeout   time.Duration `env:"DB_CONNECT_TIMEOUT, default=1m"`
+	ConnectRetryBase time.Duration `env:"DB_CONNECT_RETRY_BASE, default=500ms"`
+	ConnectRetryMax  time.Duration `env:"DB_CONNECT_RETRY_MAX, default=10s"`
+
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS, default=25"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS, default=10"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME, default=30m"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME, default=5m"`
+
+	MonitorInterval time.Duration `env:"DB_MONITOR_INTERVAL, default=10s"`
+	MonitorTimeout  time.Duration `env:"DB_MONITOR_TIMEOUT, default=2s"`
+
+	// Retries of statements outside transactions that failed with a transient error.
+	QueryMaxRetries   int           `env:"DB_QUERY_MAX_RETRIES, default=2"`
+	QueryRetryBackoff time.Duration `env:"DB_QUERY_RETRY_BACKOFF, default=100ms"`
+	// Consecutive transient failures that open the circuit breaker, 0 turns it off.
+	BreakerThreshold int           `env:"DB_BREAKER_THRESHOLD, default=5"`
+	BreakerCooldown  time.Duration `env:"DB_BREAKER_COOLDOWN, default=30s"`
 }
 
 type NewRelic struct {
+	Enabled bool   `env:"NEW_RELIC_ENABLED, default=true"`
 	AppName string `env:"APP_NAME, default=go-app"`
 	License string `env:"NEW_RELIC_LICENSE, default=eu01xx489166739db843beeeb65452b2CCCCNRAL"`
 }
@@ -45,3 +86,97 @@ type NewRelic struct {
 type Sentry struct {
 	Dsn string `env:"SENTRY_DSN"`
 }
+
+type SoftDelete struct {
+	Retention     time.Duration `env:"SOFT_DELETE_RETENTION, default=720h"`
+	PurgeInterval time.Duration `env:"SOFT_DELETE_PURGE_INTERVAL, default=1h"`
+}
+
+type Idempotency struct {
+	TTL             time.Duration `env:"IDEMPOTENCY_KEY_TTL, default=24h"`
+	CleanupInterval time.Duration `env:"IDEMPOTENCY_CLEANUP_INTERVAL, default=1h"`
+}
+
+type Batch struct {
+	MaxSize int `env:"BATCH_MAX_SIZE, default=1000"`
+}
+
+type Import struct {
+	AsyncThreshold int64 `env:"IMPORT_ASYNC_THRESHOLD, default=1048576"`
+}
+
+type Transaction struct {
+	Isolation    string        `env:"DB_TX_ISOLATION, default=read_committed"`
+	MaxRetries   int           `env:"DB_TX_MAX_RETRIES, default=3"`
+	RetryBackoff time.Duration `env:"DB_TX_RETRY_BACKOFF, default=50ms"`
+}
+
+type Outbox struct {
+	Sinks        []string      `env:"OUTBOX_SINKS, default=stdout"`
+	HTTPURL      string        `env:"OUTBOX_HTTP_URL"`
+	HTTPTimeout  time.Duration `env:"OUTBOX_HTTP_TIMEOUT, default=5s"`
+	FilePath     string        `env:"OUTBOX_FILE_PATH, default=outbox-events.ndjson"`
+	PollInterval time.Duration `env:"OUTBOX_POLL_INTERVAL, default=1s"`
+	BatchSize    int           `env:"OUTBOX_BATCH_SIZE, default=100"`
+	RetryBase    time.Duration `env:"OUTBOX_RETRY_BASE, default=1s"`
+	RetryMax     time.Duration `env:"OUTBOX_RETRY_MAX, default=5m"`
+	MaxAttempts  int           `env:"OUTBOX_MAX_ATTEMPTS, default=10"`
+	// Must exceed the time a batch takes to deliver, the events of an expired claim are delivered again.
+	ClaimTimeout time.Duration `env:"OUTBOX_CLAIM_TIMEOUT, default=5m"`
+	Retention    time.Duration `env:"OUTBOX_RETENTION, default=168h"`
+}
+
+type Webhook struct {
+	PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL, default=1s"`
+	BatchSize    int           `env:"WEBHOOK_BATCH_SIZE, default=20"`
+	Timeout      time.Duration `env:"WEBHOOK_TIMEOUT, default=10s"`
+	MaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS, default=8"`
+	RetryBase    time.Duration `env:"WEBHOOK_RETRY_BASE, default=10s"`
+	RetryMax     time.Duration `env:"WEBHOOK_RETRY_MAX, default=1h"`
+	// Subscribers on loopback and private addresses are refused unless this is set.
+	AllowPrivateNetworks bool `env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS, default=false"`
+}
+
+type Stream struct {
+	PollInterval   time.Duration `env:"STREAM_POLL_INTERVAL, default=500ms"`
+	LogSize        int           `env:"STREAM_LOG_SIZE, default=1000"`
+	MaxSubscribers int           `env:"STREAM_MAX_SUBSCRIBERS, default=100"`
+	Heartbeat      time.Duration `env:"STREAM_HEARTBEAT, default=15s"`
+	GapTimeout     time.Duration `env:"STREAM_GAP_TIMEOUT, default=5s"`
+}
+
+type Cache struct {
+	// none, memory or redis, a memory cache only sees the writes of its own instance.
+	Backend        string        `env:"USER_CACHE, default=none"`
+	Capacity       int           `env:"USER_CACHE_CAPACITY, default=10000"`
+	TTL            time.Duration `env:"USER_CACHE_TTL, default=1m"`
+	NegativeTTL    time.Duration `env:"USER_CACHE_NEGATIVE_TTL, default=10s"`
+	RedisAddress   string        `env:"REDIS_ADDRESS, default=localhost:6379"`
+	RedisPassword  string        `env:"REDIS_PASSWORD"`
+	RedisDB        int           `env:"REDIS_DB, default=0"`
+	RedisKeyPrefix string        `env:"REDIS_KEY_PREFIX, default=go-app:"`
+}
+
+type Auth struct {
+	// Requires a bearer token on the user endpoints, the issuer and the audience must be set.
+	Enabled  bool          `env:"AUTH_ENABLED, default=false"`
+	Issuer   string        `env:"AUTH_ISSUER"`
+	Audience string        `env:"AUTH_AUDIENCE"`
+	Leeway   time.Duration `env:"AUTH_LEEWAY, default=30s"`
+
+	// Keys come from the JWKS URL, or from the static JWKS document and HS256 secret when it is not set.
+	JWKSURL                string        `env:"AUTH_JWKS_URL"`
+	JWKSRefreshInterval    time.Duration `env:"AUTH_JWKS_REFRESH_INTERVAL, default=15m"`
+	JWKSMinRefreshInterval time.Duration `env:"AUTH_JWKS_MIN_REFRESH_INTERVAL, default=30s"`
+	JWKSTimeout            time.Duration `env:"AUTH_JWKS_TIMEOUT, default=5s"`
+	JWKS                   string        `env:"AUTH_JWKS"`
+	HMACSecret             string        `env:"AUTH_HS256_SECRET"`
+
+	// Accepts the API keys issued at /api/v1/api-keys, with or without bearer tokens. Issuing keys takes the
+	// api-keys:admin scope and the scopes granted: a bearer token holding them, or the bootstrap key. The endpoints are
+	// absent while authentication is off.
+	APIKeysEnabled bool `env:"AUTH_API_KEYS_ENABLED, default=false"`
+	// Seeds a key with every scope at startup, e.g. "gak_$(openssl rand -hex 6)_$(openssl rand -hex 32)". Keys are only
+	// granted scopes their issuer holds. Revoke it once the first keys are issued, a revoked bootstrap key is not seeded again.
+	BootstrapAPIKey string `env:"AUTH_BOOTSTRAP_API_KEY"`
+}
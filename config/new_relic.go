@@ -1,22 +1,15 @@
 package config
 
 import (
-	"fmt"
 	"github.com/newrelic/go-agent/v3/newrelic"
-	"os"
 )
 
-func NewRelicConfig() *newrelic.Application {
-	app, err := newrelic.NewApplication(
-		newrelic.ConfigAppName(config().NewRelic.AppName),
-		newrelic.ConfigLicense(config().NewRelic.License),
+func NewRelicConfig(cfg *NewRelic) (*newrelic.Application, error) {
+	return newrelic.NewApplication(
+		newrelic.ConfigEnabled(cfg.Enabled),
+		newrelic.ConfigAppName(cfg.AppName),
+		newrelic.ConfigLicense(cfg.License),
 		newrelic.ConfigCodeLevelMetricsEnabled(true),
 		newrelic.ConfigAppLogForwardingEnabled(true),
 	)
-	if nil != err {
-		fmt.Printf("New Relic initialization failed: %v", err)
-		os.Exit(1)
-	}
-
-	return app
 }
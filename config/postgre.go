@@ -1,50 +1,63 @@
 package config
 
 import (
+	"context"
 	"fmt"
+	"go-app/database"
+	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log"
-	"sync"
 )
 
-var once = sync.Once{}
+// Seconds a single connection attempt may take, so an unreachable host does not eat the whole connect timeout.
+const connectAttemptTimeout = 5
 
-func ConnectPostgres() *gorm.DB {
-	var postgresDb *gorm.DB
-	once.Do(func() {
-		dsn := getConnectionString()
-		var err error
-		postgresDb, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-		if err != nil {
-			log.Fatalln(err)
-		}
-		log.Println("Creating single postgres db instance now.")
+// ConnectPostgres waits for Postgres until the connect timeout passes, every call opens its own connection pool.
+func ConnectPostgres(ctx context.Context, cfg *Database, logger *zap.Logger) (*gorm.DB, error) {
+	gormLogger := database.NewGormLogger(logger, cfg.SlowQueryThreshold)
+	dsn := cfg.connectionString(cfg.Host, cfg.Port)
+	postgresDb, err := database.Connect(ctx, func() (*gorm.DB, error) {
+		return gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormLogger})
+	}, logger, database.ConnectOptions{
+		Timeout:   cfg.ConnectTimeout,
+		RetryBase: cfg.ConnectRetryBase,
+		RetryMax:  cfg.ConnectRetryMax,
 	})
-	return postgresDb
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := postgresDb.DB()
+	if err != nil {
+		return nil, err
+	}
+	database.ConfigurePool(sqlDB, cfg.poolOptions())
+	breaker := database.NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown, logger)
+	if err := database.UseResilience(postgresDb, breaker, logger, database.ResilienceOptions{
+		MaxRetries:   cfg.QueryMaxRetries,
+		RetryBackoff: cfg.QueryRetryBackoff,
+	}); err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return postgresDb, nil
 }
 
-func ConnectTestPostgres(connStr string) *gorm.DB {
-	var postgresDb *gorm.DB
-	once.Do(func() {
-		var err error
-		postgresDb, err = gorm.Open(postgres.Open(connStr), &gorm.Config{})
-		if err != nil {
-			log.Fatalln(err)
-		}
-		log.Println("Creating single postgres db instance now.")
-	})
-	return postgresDb
+func ConnectTestPostgres(connStr string) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(connStr), &gorm.Config{})
 }
 
-func getConnectionString() string {
-	host := config().Database.Host
-	user := config().Database.Username
-	password := config().Database.Password
-	dbname := config().Database.DatabaseName
-	port := config().Database.Port
+func (cfg *Database) connectionString(host string, port string) string {
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Europe/Istanbul connect_timeout=%d",
+		host, cfg.Username, cfg.Password, cfg.DatabaseName, port, connectAttemptTimeout)
+}
 
-	connectionSting := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Europe/Istanbul", host, user, password, dbname, port)
-	return connectionSting
+func (cfg *Database) poolOptions() database.PoolOptions {
+	return database.PoolOptions{
+		MaxOpenConns:    cfg.MaxOpenConns,
+		MaxIdleConns:    cfg.MaxIdleConns,
+		ConnMaxLifetime: cfg.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.ConnMaxIdleTime,
+	}
 }
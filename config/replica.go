@@ -0,0 +1,59 @@
+package config
+
+import (
+	"go-app/database"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"net"
+)
+
+/*
+ConnectReplicas routes the user reads of db to the replicas of POSTGRES_REPLICAS.
+It returns nil when there are no replicas, or when the database is not Postgres.
+*/
+func ConnectReplicas(db *gorm.DB, cfg *Database, logger *zap.Logger) (*database.ReplicaRouter, error) {
+	if len(cfg.Replicas) == 0 || db.Dialector.Name() != "postgres" {
+		return nil, nil
+	}
+
+	replicas := make([]database.Replica, 0, len(cfg.Replicas))
+	closeReplicas := func() {
+		for _, replica := range replicas {
+			_ = replica.Pool.Close()
+		}
+	}
+	for _, address := range cfg.Replicas {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			closeReplicas()
+			return nil, err
+		}
+		// Opened without a ping, the health checks find out whether the replica is up.
+		replicaDb, err := gorm.Open(postgres.Open(cfg.connectionString(host, port)), &gorm.Config{DisableAutomaticPing: true})
+		if err != nil {
+			closeReplicas()
+			return nil, err
+		}
+		pool, err := replicaDb.DB()
+		if err != nil {
+			closeReplicas()
+			return nil, err
+		}
+		database.ConfigurePool(pool, cfg.poolOptions())
+		replicas = append(replicas, database.Replica{Name: address, Pool: pool})
+	}
+
+	router, err := database.UseReplicas(db, replicas, func(pool gorm.ConnPool) gorm.Dialector {
+		return postgres.New(postgres.Config{Conn: pool})
+	}, logger, database.ReplicaOptions{
+		HealthInterval: cfg.ReplicaHealthInterval,
+		HealthTimeout:  cfg.ReplicaHealthTimeout,
+	}, &domain.User{})
+	if err != nil {
+		closeReplicas()
+		return nil, err
+	}
+	return router, nil
+}
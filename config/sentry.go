@@ -1,18 +1,14 @@
 package config
 
 import (
-	"fmt"
 	"github.com/getsentry/sentry-go"
-	"os"
 )
 
-func SentryConfig() {
-	if err := sentry.Init(sentry.ClientOptions{
-		Dsn:              config().Sentry.Dsn,
+// SentryConfig initializes the Sentry client, it is shared by every instance in the process.
+func SentryConfig(cfg *Sentry) error {
+	return sentry.Init(sentry.ClientOptions{
+		Dsn:              cfg.Dsn,
 		EnableTracing:    true,
 		TracesSampleRate: 1.0,
-	}); err != nil {
-		fmt.Printf("Sentry initialization failed: %v", err)
-		os.Exit(1)
-	}
+	})
 }
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"github.com/glebarez/sqlite"
+	"go-app/database"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Writers wait for the database lock instead of failing at once, WAL lets readers run next to a writer.
+const sqlitePragmas = "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+
+func ConnectSQLite(cfg *Database, logger *zap.Logger) (*gorm.DB, error) {
+	gormLogger := database.NewGormLogger(logger, cfg.SlowQueryThreshold)
+	return OpenSQLite(cfg.SQLitePath, &gorm.Config{Logger: gormLogger})
+}
+
+// OpenSQLite opens the SQLite database file at path, unique and foreign key violations are reported as gorm errors.
+func OpenSQLite(path string, gormConfig *gorm.Config) (*gorm.DB, error) {
+	gormConfig.TranslateError = true
+	return gorm.Open(sqlite.Open(path+sqlitePragmas), gormConfig)
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without touching the database while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("database circuit breaker is open")
+
+/*
+CircuitBreaker opens after threshold consecutive transient failures and fails statements at once until the cooldown ends.
+After the cooldown statements go through again, the first failure opens it for another cooldown, a success closes it.
+A threshold of zero turns the breaker off.
+*/
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	logger    *zap.Logger
+	now       func() time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration, logger *zap.Logger) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, logger: logger, now: time.Now}
+}
+
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.isOpen() && b.now().Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// RecordSuccess closes the breaker, any answer of the database counts, errors such as constraint violations included.
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.isOpen() {
+		b.logger.Info("Database answers again, circuit breaker closed.")
+		metrics.DbCircuitBreakerOpen.Set(0)
+	}
+	b.failures = 0
+}
+
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if !b.isOpen() {
+		return
+	}
+	if b.failures == b.threshold {
+		b.logger.Error(fmt.Sprintf("Database failed %d times in a row, circuit breaker opened for %s.", b.failures, b.cooldown))
+		metrics.DbCircuitBreakerOpen.Set(1)
+	}
+	b.openUntil = b.now().Add(b.cooldown)
+}
+
+func (b *CircuitBreaker) isOpen() bool {
+	return b.threshold > 0 && b.failures >= b.threshold
+}
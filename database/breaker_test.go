@@ -0,0 +1,45 @@
+package database
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func Test_Should_Open_Circuit_Breaker_After_Threshold_And_Retry_After_Cooldown(t *testing.T) {
+	breaker := NewCircuitBreaker(2, time.Minute, zap.NewNop())
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	// WHEN
+	breaker.RecordFailure()
+	allowedBelowThreshold := breaker.Allow()
+	breaker.RecordFailure()
+	deniedWhileOpen := breaker.Allow()
+	now = now.Add(time.Minute)
+	allowedAfterCooldown := breaker.Allow()
+	breaker.RecordFailure()
+	deniedAfterFailedTrial := breaker.Allow()
+	breaker.RecordSuccess()
+	allowedAfterSuccess := breaker.Allow()
+
+	// THEN
+	assert.Nil(t, allowedBelowThreshold)
+	assert.Equal(t, ErrCircuitOpen, deniedWhileOpen)
+	assert.Nil(t, allowedAfterCooldown)
+	assert.Equal(t, ErrCircuitOpen, deniedAfterFailedTrial)
+	assert.Nil(t, allowedAfterSuccess)
+}
+
+func Test_Should_Never_Open_Circuit_Breaker_Without_Threshold(t *testing.T) {
+	breaker := NewCircuitBreaker(0, time.Minute, zap.NewNop())
+
+	// WHEN
+	for i := 0; i < 10; i++ {
+		breaker.RecordFailure()
+	}
+
+	// THEN
+	assert.Nil(t, breaker.Allow())
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ConnectOptions struct {
+	// Connect gives up once the timeout passes.
+	Timeout   time.Duration
+	RetryBase time.Duration
+	RetryMax  time.Duration
+}
+
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// Connect calls open until the database answers, so the application can start before its database does.
+func Connect(ctx context.Context, open func() (*gorm.DB, error), logger *zap.Logger, options ConnectOptions) (*gorm.DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	backoff := options.RetryBase
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		closeDB(db)
+
+		logger.Warn(fmt.Sprintf("Database is not reachable, retrying in %s. Attempt: %d", backoff, attempt), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("database is not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, options.RetryMax)
+	}
+}
+
+func ConfigurePool(db *sql.DB, options PoolOptions) {
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	db.SetMaxIdleConns(options.MaxIdleConns)
+	db.SetConnMaxLifetime(options.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(options.ConnMaxIdleTime)
+}
+
+/*
+ConnectionMonitor pings the database in the background. database/sql reconnects by itself,
+the monitor reports the outage and closes the circuit breaker as soon as the database answers again.
+*/
+type ConnectionMonitor struct {
+	db       *sql.DB
+	breaker  *CircuitBreaker
+	logger   *zap.Logger
+	interval time.Duration
+	timeout  time.Duration
+	up       bool
+}
+
+func NewConnectionMonitor(db *gorm.DB, logger *zap.Logger, interval time.Duration, timeout time.Duration) (*ConnectionMonitor, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return &ConnectionMonitor{db: sqlDB, breaker: Breaker(db), logger: logger, interval: interval, timeout: timeout, up: true}, nil
+}
+
+// Start checks the connection right away and then on every interval until the context is cancelled.
+func (m *ConnectionMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			m.logger.Info("Database connection monitor stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *ConnectionMonitor) Check(ctx context.Context) bool {
+	pingCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	err := m.db.PingContext(pingCtx)
+	cancel()
+
+	up := err == nil
+	switch {
+	case up && !m.up:
+		m.logger.Info("Database connection restored.")
+	case !up && m.up:
+		m.logger.Error("Database connection lost.", zap.Error(err))
+	}
+	m.up = up
+
+	stats := m.db.Stats()
+	metrics.DbOpenConnections.WithLabelValues("in_use").Set(float64(stats.InUse))
+	metrics.DbOpenConnections.WithLabelValues("idle").Set(float64(stats.Idle))
+	if up {
+		metrics.DbUp.Set(1)
+		if m.breaker != nil {
+			m.breaker.RecordSuccess()
+		}
+	} else {
+		metrics.DbUp.Set(0)
+	}
+	return up
+}
+
+func closeDB(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+}
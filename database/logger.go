@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var tableRegex = regexp.MustCompile(`(?i)(?:FROM|INTO|UPDATE|JOIN)\s+"?([a-zA-Z0-9_.]+)"?`)
+
+type gormLogger struct {
+	logger        *zap.Logger
+	logLevel      gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+/*
+NewGormLogger writes gorm logs to the application zap logger instead of stdout.
+Query parameters are never logged, statements slower than slowThreshold are logged as warnings
+and every statement is observed by the db_query_duration_seconds histogram.
+*/
+func NewGormLogger(logger *zap.Logger, slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{logger: logger, logLevel: gormlogger.Warn, slowThreshold: slowThreshold}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	newLogger := *l
+	newLogger.logLevel = level
+	return &newLogger
+}
+
+func (l *gormLogger) Info(_ context.Context, msg string, data ...interface{}) {
+	if l.logLevel >= gormlogger.Info {
+		l.logger.Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Warn(_ context.Context, msg string, data ...interface{}) {
+	if l.logLevel >= gormlogger.Warn {
+		l.logger.Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Error(_ context.Context, msg string, data ...interface{}) {
+	if l.logLevel >= gormlogger.Error {
+		l.logger.Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	sql, rows := fc()
+
+	// DB Query Duration
+	metrics.DbQueryDuration.WithLabelValues(queryOperation(sql), queryTable(sql)).Observe(elapsed.Seconds())
+
+	if l.logLevel <= gormlogger.Silent {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Duration("elapsed", elapsed),
+	}
+
+	switch {
+	case err != nil && l.logLevel >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		l.logger.Error(err.Error(), fields...)
+	case l.slowThreshold != 0 && elapsed > l.slowThreshold && l.logLevel >= gormlogger.Warn:
+		l.logger.Warn(fmt.Sprintf("SLOW SQL >= %v", l.slowThreshold), fields...)
+	case l.logLevel == gormlogger.Info:
+		l.logger.Info("SQL", fields...)
+	}
+}
+
+// ParamsFilter drops the query parameters, so user data never reaches the logs.
+func (l *gormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func queryOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return strings.ToUpper(fields[0])
+}
+
+func queryTable(sql string) string {
+	match := tableRegex.FindStringSubmatch(sql)
+	if len(match) < 2 {
+		return "unknown"
+	}
+	return match[1]
+}
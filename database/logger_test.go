@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+func Test_Should_Log_Slow_Query_As_Warning(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	gormLogger := NewGormLogger(zap.New(core), 10*time.Millisecond)
+
+	// GIVEN
+	sql := `SELECT * FROM "users" WHERE id = $1`
+
+	// WHEN
+	gormLogger.Trace(context.Background(), time.Now().Add(-time.Second), func() (string, int64) { return sql, 1 }, nil)
+
+	// THEN
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+	assert.Equal(t, sql, logs.All()[0].ContextMap()["sql"])
+}
+
+func Test_Should_Log_Query_Error(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	gormLogger := NewGormLogger(zap.New(core), time.Second)
+
+	// WHEN
+	gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) { return `DELETE FROM "users"`, 0 }, errors.New("db error"))
+
+	// THEN
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "db error", logs.All()[0].Message)
+}
+
+func Test_Should_Redact_Query_Params(t *testing.T) {
+	gormLogger := NewGormLogger(zap.NewNop(), time.Second).(*gormLogger)
+
+	// WHEN
+	sql, params := gormLogger.ParamsFilter(context.Background(), `INSERT INTO "users" ("name") VALUES ($1)`, "secret")
+
+	// THEN
+	assert.Nil(t, params)
+	assert.Equal(t, `INSERT INTO "users" ("name") VALUES ($1)`, sql)
+}
+
+func Test_Should_Parse_Query_Operation_And_Table(t *testing.T) {
+	assert.Equal(t, "SELECT", queryOperation(`SELECT * FROM "users" WHERE id = $1`))
+	assert.Equal(t, "users", queryTable(`SELECT * FROM "users" WHERE id = $1`))
+	assert.Equal(t, "users", queryTable(`INSERT INTO "users" ("name") VALUES ($1)`))
+	assert.Equal(t, "users", queryTable(`UPDATE "users" SET "name"=$1`))
+	assert.Equal(t, "unknown", queryTable(`SELECT 1`))
+}
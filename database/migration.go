@@ -1,10 +1,78 @@
 package database
 
 import (
+	"github.com/google/uuid"
 	"go-app/domain"
 	"gorm.io/gorm"
+	"strings"
 )
 
-func Migrate(db *gorm.DB) {
-	db.AutoMigrate(&domain.User{})
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&domain.User{}, &domain.IdempotencyRecord{}, &domain.OutboxEvent{}, &domain.WebhookSubscription{}, &domain.WebhookDelivery{}, &domain.AuditEntry{}, &domain.APIKey{}); err != nil {
+		return err
+	}
+	if err := protectAuditTrail(db); err != nil {
+		return err
+	}
+	// The unique index on every email is replaced by idx_users_email_present, which leaves out users without one.
+	if db.Migrator().HasIndex(&domain.User{}, "idx_users_email") {
+		if err := db.Migrator().DropIndex(&domain.User{}, "idx_users_email"); err != nil {
+			return err
+		}
+	}
+	return backfillUsers(db)
+}
+
+// protectAuditTrail makes the user_audit table append-only, updates and deletes fail in the database itself.
+func protectAuditTrail(db *gorm.DB) error {
+	if db.Dialector.Name() == "postgres" {
+		statements := []string{
+			`CREATE OR REPLACE FUNCTION user_audit_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'user_audit is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+			"DROP TRIGGER IF EXISTS user_audit_append_only ON user_audit",
+			"CREATE TRIGGER user_audit_append_only BEFORE UPDATE OR DELETE ON user_audit FOR EACH ROW EXECUTE FUNCTION user_audit_append_only()",
+		}
+		for _, statement := range statements {
+			if err := db.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, operation := range []string{"UPDATE", "DELETE"} {
+		err := db.Exec("CREATE TRIGGER IF NOT EXISTS user_audit_append_only_" + strings.ToLower(operation) +
+			" BEFORE " + operation + " ON user_audit BEGIN SELECT RAISE(ABORT, 'user_audit is append-only'); END").Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillUsers fills the columns added to users after rows already existed, those users get a random UUID and no email.
+func backfillUsers(db *gorm.DB) error {
+	if db.Dialector.Name() == "postgres" {
+		if err := db.Exec("UPDATE users SET uuid = gen_random_uuid() WHERE uuid IS NULL").Error; err != nil {
+			return err
+		}
+	} else {
+		var ids []uint
+		if err := db.Model(&domain.User{}).Unscoped().Where("uuid IS NULL").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := db.Model(&domain.User{}).Unscoped().Where("id = ?", id).UpdateColumn("uuid", uuid.New()).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := db.Exec("UPDATE users SET email = '' WHERE email IS NULL").Error; err != nil {
+		return err
+	}
+	return db.Exec("UPDATE users SET updated_at = created_date WHERE updated_at IS NULL").Error
 }
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_Should_Backfill_Users_Created_Before_New_Columns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GIVEN
+	statements := []string{
+		"CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, name text, age integer, created_date datetime, deleted_at datetime, version integer NOT NULL DEFAULT 1)",
+		"INSERT INTO users (name, age, created_date) VALUES ('Ada', 36, '2024-05-01 12:00:00'), ('Grace', 40, '2024-05-01 12:00:00')",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// WHEN
+	err = Migrate(db)
+	var users []domain.User
+	db.Order("id").Find(&users)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, users, 2)
+	assert.NotEqual(t, uuid.Nil, users[0].UUID)
+	assert.NotEqual(t, users[0].UUID, users[1].UUID)
+	assert.Empty(t, users[0].Email)
+	assert.Equal(t, domain.UserActive, users[1].Status)
+	assert.Equal(t, domain.Roles{}, users[1].Roles)
+	assert.True(t, users[0].UpdatedAt.Equal(users[0].CreatedDate))
+	assert.Nil(t, Migrate(db))
+}
+
+func Test_Should_Replace_Email_Index_With_One_For_Users_With_Email(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// GIVEN
+	statements := []string{
+		"CREATE TABLE users (id integer PRIMARY KEY AUTOINCREMENT, name text, age integer, email varchar(254), created_date datetime, deleted_at datetime, version integer NOT NULL DEFAULT 1)",
+		"CREATE UNIQUE INDEX idx_users_email ON users (email)",
+		"INSERT INTO users (name, age, email, created_date) VALUES ('Ada', 36, '', '2024-05-01 12:00:00')",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// WHEN
+	err = Migrate(db)
+	noEmailErr := db.Create(&domain.User{Name: "Grace", Age: 40, UUID: uuid.New()}).Error
+	emailErr := db.Create(&domain.User{Name: "Linus", Age: 30, Email: "linus@example.com", UUID: uuid.New()}).Error
+	duplicateErr := db.Create(&domain.User{Name: "Linus", Age: 30, Email: "linus@example.com", UUID: uuid.New()}).Error
+
+	// THEN
+	assert.Nil(t, err)
+	assert.False(t, db.Migrator().HasIndex(&domain.User{}, "idx_users_email"))
+	assert.Nil(t, noEmailErr)
+	assert.Nil(t, emailErr)
+	assert.NotNil(t, duplicateErr)
+}
+
+func Test_Should_Reject_Updates_And_Deletes_Of_Audit_Entries(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "audit.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	// GIVEN
+	entry := domain.AuditEntry{UserID: 1, Action: domain.AuditCreate, Changes: domain.AuditChanges{}, CreatedAt: time.Now()}
+	assert.Nil(t, db.Create(&entry).Error)
+
+	// WHEN
+	updateErr := db.Model(&entry).Update("actor", "someone-else").Error
+	deleteErr := db.Delete(&entry).Error
+
+	// THEN
+	assert.ErrorContains(t, updateErr, "append-only")
+	assert.ErrorContains(t, deleteErr, "append-only")
+}
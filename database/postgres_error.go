@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"net"
+	"strings"
+)
+
+// Postgres SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgCheckViolation       = "23514"
+	pgNotNullViolation     = "23502"
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+	pgTooManyConnections   = "53300"
+	pgAdminShutdown        = "57P01"
+	pgCrashShutdown        = "57P02"
+	pgCannotConnectNow     = "57P03"
+)
+
+/*
+TranslateError maps a database error to a domain error.
+The response message never contains SQL or driver details, the original error is kept as the cause,
+so it still reaches the logs and Sentry.
+*/
+func TranslateError(err error) *domain.AppError {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation:
+			return domain.NewConflictError("Resource already exists.").WithCause(err)
+		case pgErr.Code == pgForeignKeyViolation, pgErr.Code == pgCheckViolation, pgErr.Code == pgNotNullViolation:
+			return domain.NewConstraintViolationError("Request violates a data constraint.").WithCause(err)
+		case pgErr.Code == pgSerializationFailure, pgErr.Code == pgDeadlockDetected:
+			return domain.NewRetryableError("Concurrent update detected, please retry.").WithCause(err)
+		case isUnavailable(pgErr):
+			return domain.NewServiceUnavailableError("Database is unavailable.").WithCause(err)
+		}
+	}
+
+	// Errors of the other dialects, translated by gorm.
+	switch {
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return domain.NewConflictError("Resource already exists.").WithCause(err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return domain.NewConstraintViolationError("Request violates a data constraint.").WithCause(err)
+	}
+
+	if isConnectionError(err) || errors.Is(err, ErrCircuitOpen) {
+		return domain.NewServiceUnavailableError("Database is unavailable.").WithCause(err)
+	}
+
+	return domain.NewUnexpectedError("Unexpected database error.").WithCause(err)
+}
+
+func isConnectionError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		pgconn.Timeout(err)
+}
+
+// IsTransientError tells whether the database may answer the same statement a moment later.
+func IsTransientError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return isUnavailable(pgErr)
+	}
+	// Context errors belong to the caller, retrying can not help.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return isConnectionError(err)
+}
+
+// isSafeToRetry tells whether a failed statement surely never reached the server, so even a write can run again.
+func isSafeToRetry(err error) bool {
+	var connectErr *pgconn.ConnectError
+	return pgconn.SafeToRetry(err) || errors.As(err, &connectErr) || errors.Is(err, driver.ErrBadConn)
+}
+
+func isUnavailable(pgErr *pgconn.PgError) bool {
+	return strings.HasPrefix(pgErr.Code, "08") || pgErr.Code == pgTooManyConnections ||
+		pgErr.Code == pgAdminShutdown || pgErr.Code == pgCrashShutdown || pgErr.Code == pgCannotConnectNow
+}
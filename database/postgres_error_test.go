@@ -0,0 +1,38 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"net/http"
+	"testing"
+)
+
+func Test_Should_Translate_Postgres_Errors(t *testing.T) {
+	tests := []struct {
+		err       error
+		status    int
+		code      domain.ErrorCode
+		retryable bool
+	}{
+		{&pgconn.PgError{Code: pgUniqueViolation}, http.StatusConflict, domain.ErrCodeConflict, false},
+		{&pgconn.PgError{Code: pgForeignKeyViolation}, http.StatusUnprocessableEntity, domain.ErrCodeConstraint, false},
+		{&pgconn.PgError{Code: pgCheckViolation}, http.StatusUnprocessableEntity, domain.ErrCodeConstraint, false},
+		{&pgconn.PgError{Code: pgSerializationFailure}, http.StatusConflict, domain.ErrCodeRetryable, true},
+		{&pgconn.PgError{Code: "08006"}, http.StatusServiceUnavailable, domain.ErrCodeUnavailable, true},
+		{driver.ErrBadConn, http.StatusServiceUnavailable, domain.ErrCodeUnavailable, true},
+		{errors.New("syntax error at or near SELECT"), http.StatusInternalServerError, domain.ErrCodeUnexpected, false},
+	}
+
+	for _, test := range tests {
+		appErr := TranslateError(test.err)
+
+		assert.Equal(t, test.status, appErr.Status)
+		assert.Equal(t, test.code, appErr.Code)
+		assert.Equal(t, test.retryable, appErr.Retryable)
+		assert.True(t, errors.Is(appErr, test.err))
+		assert.NotContains(t, appErr.Message, "SELECT")
+	}
+}
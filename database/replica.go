@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+type primaryReadsKey struct{}
+
+// Replica is a read-only copy of the primary database.
+type Replica struct {
+	Name string
+	Pool *sql.DB
+}
+
+type ReplicaOptions struct {
+	HealthInterval time.Duration
+	HealthTimeout  time.Duration
+}
+
+/*
+ReplicaRouter sends the reads of the registered tables to a healthy replica through gorm dbresolver.
+Writes, locking reads and everything inside a transaction stay on the primary, so do reads while every replica is down.
+*/
+type ReplicaRouter struct {
+	primary  gorm.ConnPool
+	replicas []*replicaState
+	logger   *zap.Logger
+	options  ReplicaOptions
+}
+
+type replicaState struct {
+	Replica
+	healthy atomic.Bool
+}
+
+/*
+UseReplicas registers the replicas for the tables of the given models.
+dialector wraps an open connection pool in a dialector of the primary's database.
+*/
+func UseReplicas(db *gorm.DB, replicas []Replica, dialector func(pool gorm.ConnPool) gorm.Dialector, logger *zap.Logger, options ReplicaOptions, models ...interface{}) (*ReplicaRouter, error) {
+	// The pool of gorm, not the *sql.DB, so primary reads keep the retries of UseResilience.
+	primary := db.Config.ConnPool
+	router := &ReplicaRouter{primary: primary, logger: logger, options: options}
+	dialectors := make([]gorm.Dialector, 0, len(replicas)+1)
+	for _, replica := range replicas {
+		state := &replicaState{Replica: replica}
+		state.healthy.Store(true)
+		router.replicas = append(router.replicas, state)
+		dialectors = append(dialectors, dialector(replica.Pool))
+		metrics.DbReplicaHealthy.WithLabelValues(replica.Name).Set(1)
+	}
+	// The primary is the fallback of Resolve, dbresolver also skips the policy when there is a single replica.
+	dialectors = append(dialectors, dialector(primary))
+
+	// dbresolver opens the replicas with the settings of the primary, a replica that is down must not stop the start up.
+	db.Config.DisableAutomaticPing = true
+	if err := db.Use(dbresolver.Register(dbresolver.Config{Replicas: dialectors, Policy: router}, models...)); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
+// Resolve implements dbresolver.Policy, it picks a random healthy replica or the primary.
+func (r *ReplicaRouter) Resolve([]gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]gorm.ConnPool, 0, len(r.replicas))
+	for _, replica := range r.replicas {
+		if replica.healthy.Load() {
+			healthy = append(healthy, replica.Pool)
+		}
+	}
+	if len(healthy) == 0 {
+		return r.primary
+	}
+	return healthy[rand.Intn(len(healthy))]
+}
+
+// Start checks the replicas right away and then on every interval until the context is cancelled.
+func (r *ReplicaRouter) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.options.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckHealth(ctx)
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Read replica health checks stopped.")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth pings every replica, the reads skip a replica until it answers again.
+func (r *ReplicaRouter) CheckHealth(ctx context.Context) {
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, r.options.HealthTimeout)
+		err := replica.Pool.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			if healthy {
+				r.logger.Info(fmt.Sprintf("Read replica is healthy again. Replica: %s", replica.Name))
+			} else {
+				r.logger.Warn(fmt.Sprintf("Read replica is down, its reads go to the other replicas or the primary. Replica: %s", replica.Name), zap.Error(err))
+			}
+		}
+		if healthy {
+			metrics.DbReplicaHealthy.WithLabelValues(replica.Name).Set(1)
+		} else {
+			metrics.DbReplicaHealthy.WithLabelValues(replica.Name).Set(0)
+		}
+	}
+}
+
+func (r *ReplicaRouter) Close() {
+	for _, replica := range r.replicas {
+		_ = replica.Pool.Close()
+	}
+}
+
+// WithPrimaryReads makes the reads of the context go to the primary, so a client reads its own writes.
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+func PrimaryReads(ctx context.Context) bool {
+	primaryReads, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return primaryReads
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openSQLite(t *testing.T, name string, user domain.User) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), name)), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&domain.User{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// mockReplicaSetup keeps a different user with ID 1 in the primary and the replica, so a read tells where it went.
+func mockReplicaSetup(t *testing.T) (*gorm.DB, *sql.DB, *ReplicaRouter) {
+	primary := openSQLite(t, "primary.db", domain.User{ID: 1, Name: "primary", Age: 30, Email: "primary@example.com"})
+	replicaDb := openSQLite(t, "replica.db", domain.User{ID: 1, Name: "replica", Age: 30, Email: "replica@example.com"})
+	replicaPool, _ := replicaDb.DB()
+
+	router, err := UseReplicas(primary, []Replica{{Name: "replica", Pool: replicaPool}}, func(pool gorm.ConnPool) gorm.Dialector {
+		return &sqlite.Dialector{Conn: pool}
+	}, zap.NewNop(), ReplicaOptions{HealthInterval: time.Minute, HealthTimeout: time.Second}, &domain.User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return primary, replicaPool, router
+}
+
+func readUserName(ctx context.Context, db *gorm.DB) string {
+	var user domain.User
+	Conn(ctx, db).First(&user, 1)
+	return user.Name
+}
+
+func Test_Should_Route_User_Reads_To_Replica(t *testing.T) {
+	db, _, _ := mockReplicaSetup(t)
+
+	// WHEN
+	name := readUserName(context.Background(), db)
+
+	// THEN
+	assert.Equal(t, "replica", name)
+}
+
+func Test_Should_Route_Writes_And_Primary_Reads_To_Primary(t *testing.T) {
+	db, _, _ := mockReplicaSetup(t)
+	ctx := context.Background()
+
+	// WHEN
+	err := Conn(ctx, db).Create(&domain.User{ID: 2, UUID: uuid.New(), Name: "written", Age: 30, Email: "written@example.com"}).Error
+	var count int64
+	Conn(WithPrimaryReads(ctx), db).Model(&domain.User{}).Count(&count)
+	name := readUserName(WithPrimaryReads(ctx), db)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, "primary", name)
+}
+
+func Test_Should_Read_From_Primary_Inside_Transaction(t *testing.T) {
+	db, _, _ := mockReplicaSetup(t)
+	txManager := NewTxManager(db, zap.NewNop(), sql.LevelDefault, 0, time.Millisecond)
+
+	// WHEN
+	var name string
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		name = readUserName(ctx, db)
+		return nil
+	})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "primary", name)
+}
+
+func Test_Should_Read_From_Primary_When_Replica_Is_Down(t *testing.T) {
+	db, replicaPool, router := mockReplicaSetup(t)
+	_ = replicaPool.Close()
+
+	// WHEN
+	router.CheckHealth(context.Background())
+	name := readUserName(context.Background(), db)
+
+	// THEN
+	assert.Equal(t, "primary", name)
+}
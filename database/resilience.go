@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ResilienceOptions struct {
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+/*
+resilientPool wraps the connection pool of gorm. Statements outside a transaction are retried on transient errors,
+writes only when the error shows they never reached the server. Every transient failure counts for the circuit breaker.
+Statements inside a transaction go to the *sql.Tx, the transaction manager retries whole transactions.
+*/
+type resilientPool struct {
+	db      *sql.DB
+	breaker *CircuitBreaker
+	logger  *zap.Logger
+	options ResilienceOptions
+}
+
+// UseResilience routes the statements of db through retries and the circuit breaker, it must run before db is used.
+func UseResilience(db *gorm.DB, breaker *CircuitBreaker, logger *zap.Logger, options ResilienceOptions) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	pool := &resilientPool{db: sqlDB, breaker: breaker, logger: logger, options: options}
+	db.Config.ConnPool = pool
+	db.Statement.ConnPool = pool
+	return nil
+}
+
+// Breaker returns the circuit breaker of db, or nil when db does not use one.
+func Breaker(db *gorm.DB) *CircuitBreaker {
+	if pool, ok := db.Config.ConnPool.(*resilientPool); ok {
+		return pool.breaker
+	}
+	return nil
+}
+
+// GetDBConn implements gorm.GetDBConnector, so db.DB() still returns the *sql.DB.
+func (p *resilientPool) GetDBConn() (*sql.DB, error) {
+	return p.db, nil
+}
+
+func (p *resilientPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	var stmt *sql.Stmt
+	err := p.do(ctx, true, func() (err error) {
+		stmt, err = p.db.PrepareContext(ctx, query)
+		return err
+	})
+	return stmt, err
+}
+
+func (p *resilientPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := p.do(ctx, false, func() (err error) {
+		result, err = p.db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+func (p *resilientPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := p.do(ctx, true, func() (err error) {
+		rows, err = p.db.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext is retried, but never stopped by the open breaker, a *sql.Row can not carry an error of ours.
+func (p *resilientPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	_ = p.retry(ctx, true, func() error {
+		row = p.db.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+// BeginTx implements gorm.TxBeginner, a failed BEGIN leaves nothing behind, so it is always retried.
+func (p *resilientPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := p.do(ctx, true, func() (err error) {
+		tx, err = p.db.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+func (p *resilientPool) do(ctx context.Context, idempotent bool, fn func() error) error {
+	return p.retry(ctx, idempotent, func() error {
+		if err := p.breaker.Allow(); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+func (p *resilientPool) retry(ctx context.Context, idempotent bool, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if errors.Is(err, ErrCircuitOpen) {
+			return err
+		}
+		if err == nil || !IsTransientError(err) {
+			p.breaker.RecordSuccess()
+			return err
+		}
+
+		p.breaker.RecordFailure()
+		if attempt == p.options.MaxRetries || !(idempotent || isSafeToRetry(err)) {
+			return err
+		}
+
+		metrics.DbQueryRetries.Inc()
+		backoff := p.options.RetryBackoff << attempt
+		p.logger.Warn(fmt.Sprintf("Database statement failed, retrying in %s.", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var errCannotConnectNow = &pgconn.PgError{Code: pgCannotConnectNow}
+
+func mockResilientSetup(breakerThreshold int, maxRetries int) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		panic("Failed to create sqlmock.")
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		panic("Failed to open gorm db.")
+	}
+	breaker := NewCircuitBreaker(breakerThreshold, time.Minute, zap.NewNop())
+	if err := UseResilience(gormDB, breaker, zap.NewNop(), ResilienceOptions{MaxRetries: maxRetries, RetryBackoff: time.Millisecond}); err != nil {
+		panic(err)
+	}
+	return gormDB, mock
+}
+
+func Test_Should_Retry_Read_On_Transient_Error(t *testing.T) {
+	db, mock := mockResilientSetup(5, 2)
+
+	// WHEN
+	mock.ExpectQuery(`SELECT`).WillReturnError(errCannotConnectNow)
+	mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "test"))
+
+	var user domain.User
+	err := db.First(&user, 1).Error
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "test", user.Name)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Retry_Write_Only_When_It_Never_Reached_The_Server(t *testing.T) {
+	db, mock := mockResilientSetup(5, 2)
+
+	// WHEN
+	mock.ExpectExec(`UPDATE users`).WillReturnError(&pgconn.ConnectError{Config: &pgconn.Config{}})
+	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users`).WillReturnError(errCannotConnectNow)
+
+	retriedErr := db.Exec("UPDATE users SET age = 1").Error
+	notRetriedErr := db.Exec("UPDATE users SET age = 2").Error
+
+	// THEN
+	assert.Nil(t, retriedErr)
+	assert.True(t, errors.Is(notRetriedErr, errCannotConnectNow))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Not_Retry_Non_Transient_Error(t *testing.T) {
+	db, mock := mockResilientSetup(1, 2)
+
+	// WHEN
+	mock.ExpectQuery(`SELECT`).WillReturnError(&pgconn.PgError{Code: pgUniqueViolation})
+	mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	var user domain.User
+	firstErr := db.First(&user, 1).Error
+	secondErr := db.First(&user, 1).Error
+
+	// THEN
+	assert.NotNil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Fail_Fast_While_Circuit_Breaker_Is_Open(t *testing.T) {
+	db, mock := mockResilientSetup(1, 0)
+
+	// WHEN
+	mock.ExpectQuery(`SELECT`).WillReturnError(errCannotConnectNow)
+
+	var user domain.User
+	_ = db.First(&user, 1).Error
+	err := db.First(&user, 1).Error
+
+	// THEN
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, http.StatusServiceUnavailable, TranslateError(err).Status)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Close_Circuit_Breaker_When_Monitor_Reaches_Database(t *testing.T) {
+	db, mock := mockResilientSetup(1, 0)
+	monitor, _ := NewConnectionMonitor(db, zap.NewNop(), time.Minute, time.Second)
+
+	// GIVEN
+	mock.ExpectQuery(`SELECT`).WillReturnError(errCannotConnectNow)
+	var user domain.User
+	_ = db.First(&user, 1).Error
+
+	// WHEN
+	mock.ExpectPing().WillReturnError(errCannotConnectNow)
+	mock.ExpectPing()
+	downCheck := monitor.Check(context.Background())
+	upCheck := monitor.Check(context.Background())
+
+	// THEN
+	assert.False(t, downCheck)
+	assert.True(t, upCheck)
+	assert.Nil(t, Breaker(db).Allow())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Retry_Connect_Until_Database_Answers(t *testing.T) {
+	attempts := 0
+	open := func() (*gorm.DB, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return &gorm.DB{}, nil
+	}
+
+	// WHEN
+	db, err := Connect(context.Background(), open, zap.NewNop(), ConnectOptions{Timeout: time.Second, RetryBase: time.Millisecond, RetryMax: time.Millisecond})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.NotNil(t, db)
+	assert.Equal(t, 3, attempts)
+}
+
+func Test_Should_Give_Up_Connect_After_Timeout(t *testing.T) {
+	open := func() (*gorm.DB, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	// WHEN
+	_, err := Connect(context.Background(), open, zap.NewNop(), ConnectOptions{Timeout: 20 * time.Millisecond, RetryBase: time.Millisecond, RetryMax: 5 * time.Millisecond})
+
+	// THEN
+	assert.ErrorContains(t, err, "connection refused")
+}
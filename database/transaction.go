@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type txKey struct{}
+
+type txHooksKey struct{}
+
+//go:generate mockgen -destination=../mocks/mockTxManager.go -package=mocks go-app/domain TxManager
+type txManager struct {
+	db           *gorm.DB
+	logger       *zap.Logger
+	isolation    sql.IsolationLevel
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+func NewTxManager(db *gorm.DB, logger *zap.Logger, isolation sql.IsolationLevel, maxRetries int, retryBackoff time.Duration) domain.TxManager {
+	return &txManager{db: db, logger: logger, isolation: isolation, maxRetries: maxRetries, retryBackoff: retryBackoff}
+}
+
+/*
+WithinTx retries the whole transaction when Postgres aborts it with a serialization failure or a deadlock.
+Savepoints are never retried on their own, the outer transaction is already aborted at that point.
+*/
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...domain.TxOption) *domain.AppError {
+	options := domain.TxOptions{Isolation: m.isolation}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return m.run(ctx, tx, fn, nil)
+	}
+
+	txOptions := &sql.TxOptions{Isolation: options.Isolation, ReadOnly: options.ReadOnly}
+	for attempt := 0; ; attempt++ {
+		err := m.run(ctx, m.db.WithContext(ctx), fn, txOptions)
+		if err == nil || !IsSerializationFailure(err) || attempt == m.maxRetries {
+			return err
+		}
+
+		metrics.DbTransactionRetries.Inc()
+		backoff := m.retryBackoff << attempt
+		backoff += time.Duration(rand.Int63n(int64(backoff) + 1))
+		m.logger.Warn(fmt.Sprintf("Transaction aborted by a concurrent update, retrying in %s.", backoff), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return TranslateError(ctx.Err())
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// run starts a transaction on db, or a savepoint when db is already a transaction.
+func (m *txManager) run(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error, txOptions *sql.TxOptions) *domain.AppError {
+	var txOpts []*sql.TxOptions
+	if txOptions != nil {
+		txOpts = append(txOpts, txOptions)
+	}
+
+	hooks, nested := ctx.Value(txHooksKey{}).(*txHooks)
+	if !nested {
+		hooks = &txHooks{}
+		ctx = context.WithValue(ctx, txHooksKey{}, hooks)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	}, txOpts...)
+	if err == nil {
+		if !nested {
+			hooks.runAfterCommit()
+		}
+		return nil
+	}
+
+	var appErr *domain.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return TranslateError(err)
+}
+
+/*
+Conn returns the transaction stored in the context, or db bound to the context when there is none.
+Outside a transaction, reads may go to a replica unless the context asks for primary reads.
+*/
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	if PrimaryReads(ctx) {
+		return db.WithContext(ctx).Clauses(dbresolver.Write)
+	}
+	return db.WithContext(ctx)
+}
+
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+// AfterCommit runs fn once the transaction of the context commits, or right away when there is none.
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok || !InTx(ctx) {
+		fn()
+		return
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.afterCommit = append(hooks.afterCommit, fn)
+}
+
+type txHooks struct {
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+func (h *txHooks) runAfterCommit() {
+	h.mu.Lock()
+	afterCommit := h.afterCommit
+	h.mu.Unlock()
+	for _, fn := range afterCommit {
+		fn()
+	}
+}
+
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected)
+}
+
+// ParseIsolationLevel reads the isolation level names used in the configuration.
+func ParseIsolationLevel(level string) (sql.IsolationLevel, error) {
+	switch level {
+	case "", "default":
+		return sql.LevelDefault, nil
+	case "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	default:
+		return sql.LevelDefault, fmt.Errorf("unknown isolation level: %s", level)
+	}
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func mockTxManagerSetup(maxRetries int) (*gorm.DB, sqlmock.Sqlmock, domain.TxManager) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		panic("Failed to create sqlmock.")
+	}
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}), &gorm.Config{})
+	if err != nil {
+		panic("Failed to open gorm db.")
+	}
+
+	return gormDB, mock, NewTxManager(gormDB, zap.NewNop(), sql.LevelDefault, maxRetries, time.Millisecond)
+}
+
+func Test_Should_Commit_Transaction_And_Share_It_Through_Context(t *testing.T) {
+	db, mock, txManager := mockTxManagerSetup(0)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		tx := Conn(ctx, db)
+		tx.Exec("UPDATE users SET age = 1")
+		tx.Exec("UPDATE users SET age = 2")
+		return nil
+	})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Roll_Back_Transaction_And_Return_App_Error(t *testing.T) {
+	_, mock, txManager := mockTxManagerSetup(0)
+
+	// GIVEN
+	expectedErr := domain.NewUserNotFoundError(1)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		return expectedErr
+	})
+
+	// THEN
+	assert.Equal(t, expectedErr, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Roll_Back_Only_The_Savepoint_Of_Nested_Transaction(t *testing.T) {
+	_, mock, txManager := mockTxManagerSetup(0)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var nestedErr *domain.AppError
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		nestedErr = txManager.WithinTx(ctx, func(ctx context.Context) error {
+			return domain.NewConflictError("Nested failure.")
+		})
+		return nil
+	})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.ErrCodeConflict, nestedErr.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Run_After_Commit_Hooks_Only_Once_Outer_Transaction_Commits(t *testing.T) {
+	_, mock, txManager := mockTxManagerSetup(0)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var calls []string
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		_ = txManager.WithinTx(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, func() { calls = append(calls, "nested") })
+			return nil
+		})
+		AfterCommit(ctx, func() { calls = append(calls, "outer") })
+		assert.Empty(t, calls)
+		return nil
+	})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"nested", "outer"}, calls)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Skip_After_Commit_Hooks_On_Rollback(t *testing.T) {
+	_, mock, txManager := mockTxManagerSetup(0)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	called := false
+	_ = txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		AfterCommit(ctx, func() { called = true })
+		return domain.NewConflictError("Failure.")
+	})
+	AfterCommit(context.Background(), func() { called = !called })
+
+	// THEN
+	assert.True(t, called)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Retry_Transaction_On_Serialization_Failure(t *testing.T) {
+	_, mock, txManager := mockTxManagerSetup(2)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	attempts := 0
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return TranslateError(&pgconn.PgError{Code: pgSerializationFailure})
+		}
+		return nil
+	}, domain.WithIsolation(sql.LevelSerializable))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Give_Up_After_Max_Retries(t *testing.T) {
+	_, mock, txManager := mockTxManagerSetup(1)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := txManager.WithinTx(context.Background(), func(ctx context.Context) error {
+		return &pgconn.PgError{Code: pgDeadlockDetected}
+	})
+
+	// THEN
+	assert.Equal(t, domain.ErrCodeRetryable, err.Code)
+	assert.True(t, errors.As(err, new(*pgconn.PgError)))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
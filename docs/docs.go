@@ -15,9 +15,30 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/users": {
-            "put": {
-                "description": "Update User.",
+        "/api/v1/api-keys": {
+            "get": {
+                "description": "List all API keys, revoked ones included, without the keys themselves.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "List API Keys",
+                "responses": {
+                    "200": {
+                        "description": "Returns API keys",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.APIKey"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Issue an API key with the given scopes, the caller has to hold them. The response holds the key, it is not returned again.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,37 +46,178 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "api-keys"
                 ],
-                "summary": "Update User",
+                "summary": "Issue API Key",
                 "parameters": [
                     {
-                        "description": "User to be updated",
-                        "name": "user",
+                        "description": "API key to be issued",
+                        "name": "apiKey",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.User"
+                            "$ref": "#/definitions/domain.APIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Returns updated user",
+                        "description": "Returns issued API key",
                         "schema": {
-                            "$ref": "#/definitions/domain.User"
+                            "$ref": "#/definitions/domain.APIKey"
                         }
                     },
                     "400": {
                         "description": "Returns error",
                         "schema": {
-                            "$ref": "#/definitions/domain.AppError"
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns error when the caller does not hold a scope",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/api-keys/{id}": {
+            "get": {
+                "description": "Retrieve an API key, without the key itself.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Get an API key by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns API key",
+                        "schema": {
+                            "$ref": "#/definitions/domain.APIKey"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
                         }
                     }
                 }
             },
+            "put": {
+                "description": "Replace the name, scopes and expiry of an API key, the caller has to hold the scopes. The key itself does not change.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Update API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "API key to be updated",
+                        "name": "apiKey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.APIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns updated API key",
+                        "schema": {
+                            "$ref": "#/definitions/domain.APIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns error when the caller does not hold a scope",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Returns error when the key is revoked",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/api-keys/{id}/revoke": {
+            "post": {
+                "description": "Stop an API key from authenticating. The key stays listed with the time it was revoked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Revoke API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns revoked API key",
+                        "schema": {
+                            "$ref": "#/definitions/domain.APIKey"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users": {
             "post": {
-                "description": "Create User.",
+                "description": "Create User. A user starts active or pending, roles are set through the roles endpoint.",
                 "consumes": [
                     "application/json"
                 ],
@@ -75,6 +237,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/domain.User"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key to safely retry the request",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -87,89 +255,1592 @@ const docTemplate = `{
                     "400": {
                         "description": "Returns error",
                         "schema": {
-                            "$ref": "#/definitions/domain.AppError"
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Request with the same key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Key was used for a different request",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
                         }
                     }
                 }
             }
         },
-        "/api/v1/users/{id}": {
+        "/api/v1/users/events": {
             "get": {
-                "description": "Retrieve a user using their ID from the database.",
-                "consumes": [
-                    "application/json"
+                "description": "Server-Sent Events stream of user changes. The event ID can be sent back as Last-Event-ID to resume,\na \"gap\" event tells the client that events were missed and the users should be reloaded.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "users"
                 ],
+                "summary": "Stream user events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "user.created,user.deleted",
+                        "description": "Comma separated event types",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Event-ID for clients that can not set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of events",
+                        "schema": {
+                            "$ref": "#/definitions/domain.EventEnvelope"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "503": {
+                        "description": "Too many subscribers",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/export": {
+            "get": {
+                "description": "Stream the users matching the filters as CSV, NDJSON or Parquet. The output is gzip compressed when the client accepts it.",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.apache.parquet"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Get a user by ID",
+                "summary": "Export users",
                 "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson",
+                            "parquet"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Part of the user name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "suspended",
+                            "pending"
+                        ],
+                        "type": "string",
+                        "description": "User status",
+                        "name": "status",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Minimum age",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at or after (RFC 3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created before (RFC 3339)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also export soft deleted users, takes the users:admin scope",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "gzip to compress the output",
+                        "name": "Accept-Encoding",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Returns user",
+                        "description": "Returns users",
                         "schema": {
-                            "$ref": "#/definitions/domain.User"
+                            "type": "file"
                         }
                     },
-                    "404": {
+                    "400": {
                         "description": "Returns error",
                         "schema": {
-                            "$ref": "#/definitions/domain.AppError"
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns error when include_deleted is asked without the users:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a user using their ID from the database.",
+            }
+        },
+        "/api/v1/users/import": {
+            "post": {
+                "description": "Create users from an uploaded CSV (with name and age and optional email and status columns) or NDJSON file. Every row is validated and invalid rows are reported by line number.\nFiles larger than the async threshold are imported as a background job, poll the Location header for its status.",
                 "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Import users from a file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or NDJSON file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "File format, taken from the file extension when empty",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate the rows",
+                        "name": "dry_run",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Import in the background regardless of the file size",
+                        "name": "async",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns the import report",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ImportReport"
+                        }
+                    },
+                    "202": {
+                        "description": "Returns the pending import job",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "415": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "422": {
+                        "description": "Returns the report of a file that could not be read",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ImportReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/import/{jobId}": {
+            "get": {
+                "description": "Returns the progress of a background import, the report is complete once the status is completed or failed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Delete a user by ID",
+                "summary": "Get the status of an import job",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Import job ID",
+                        "name": "jobId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "Returns the import report",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ImportReport"
+                        }
                     },
-                    "500": {
+                    "404": {
                         "description": "Returns error",
                         "schema": {
-                            "$ref": "#/definitions/domain.AppError"
+                            "$ref": "#/definitions/domain.ProblemDetails"
                         }
                     }
                 }
             }
-        }
-    },
+        },
+        "/api/v1/users/{id}": {
+            "get": {
+                "description": "Retrieve a user using their ID from the database.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get a user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also return a soft deleted user, takes the users:admin scope",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached user",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "304": {
+                        "description": "User has not been modified"
+                    },
+                    "403": {
+                        "description": "Returns error when include_deleted is asked without the users:admin scope",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace all fields of an existing user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the user to be updated",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "User to be updated",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns updated user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "412": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "428": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a user using their ID from the database.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete a user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the user to be deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "412": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "500": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Partially update a user with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) document.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Patch User",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the user to be patched",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Patch document",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns patched user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "412": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "415": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/activate": {
+            "post": {
+                "description": "Move a pending or suspended user to the active status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Activate a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason and actor of the change",
+                        "name": "change",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/domain.StatusChange"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns activated user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Status does not allow the transition",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/deactivate": {
+            "post": {
+                "description": "Move a user to the deactivated status for good, a reason is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Deactivate a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason and actor of the change",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.StatusChange"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns deactivated user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Status does not allow the transition",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/history": {
+            "get": {
+                "description": "Changes of a user, newest first, with who made them and from where.\nWith as_of only the changes made until then are listed and the user is returned as it was at that time.\nThe actor is the authenticated subject, without authentication it is the unverified X-Actor header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Audit trail of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "next_before of the previous page",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries, 50 by default, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time to rebuild the user at",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns a page of the audit trail",
+                        "schema": {
+                            "$ref": "#/definitions/domain.UserHistory"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/restore": {
+            "post": {
+                "description": "Restore a soft deleted user before it is purged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Restore a deleted user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns restored user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/roles": {
+            "put": {
+                "description": "Replace all roles of a user, the other user endpoints keep them. Takes the users:admin scope, the endpoint is absent while authentication is off.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Set the roles of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the user to be changed",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Roles of the user",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.RolesChange"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns changed user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "403": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "412": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "428": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{id}/suspend": {
+            "post": {
+                "description": "Move an active user to the suspended status, a reason is required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Suspend a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or UUID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason and actor of the change",
+                        "name": "change",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.StatusChange"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns suspended user",
+                        "schema": {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "409": {
+                        "description": "Status does not allow the transition",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users:batch": {
+            "post": {
+                "description": "Apply one operation to many users. Atomic batches are written in one transaction, best effort batches write every item that succeeds.\nDeletes only read the id and version of each item. Updates and deletes check the version of each item, version 0 skips the concurrency check like If-Match: *.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create, update or delete users in bulk",
+                "parameters": [
+                    {
+                        "description": "Batch to be applied",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.BatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns a result per item",
+                        "schema": {
+                            "$ref": "#/definitions/domain.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "413": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks": {
+            "get": {
+                "description": "List all webhook subscriptions.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List Webhook Subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "Returns subscriptions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.WebhookSubscription"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribe a URL to user events. The response holds the signing secret, it is not returned again.\nLoopback, private and link local addresses are refused unless WEBHOOK_ALLOW_PRIVATE_NETWORKS is set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create Webhook Subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription to be created",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.WebhookSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Returns created subscription",
+                        "schema": {
+                            "$ref": "#/definitions/domain.WebhookSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}": {
+            "get": {
+                "description": "Retrieve a webhook subscription, without its secret.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get a webhook subscription by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns subscription",
+                        "schema": {
+                            "$ref": "#/definitions/domain.WebhookSubscription"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the URL, event types and state of a subscription. The secret is rotated when one is given.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update Webhook Subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription to be updated",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.WebhookSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns updated subscription",
+                        "schema": {
+                            "$ref": "#/definitions/domain.WebhookSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a subscription together with its delivery log.",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete Webhook Subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Subscription deleted"
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "Delivery log of a subscription, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List Webhook Deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "succeeded",
+                            "dead_letter"
+                        ],
+                        "type": "string",
+                        "description": "Only deliveries in this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries, 50 by default, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Returns deliveries",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.WebhookDelivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver": {
+            "post": {
+                "description": "Queue a delivery again with fresh attempts, typically one in the dead letter state.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Redeliver Webhook Delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Returns the queued delivery",
+                        "schema": {
+                            "$ref": "#/definitions/domain.WebhookDelivery"
+                        }
+                    },
+                    "404": {
+                        "description": "Returns error",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ProblemDetails"
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
-        "domain.AppError": {
+        "domain.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix identifies the key in listings and logs, it is the start of the key.",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.APIKeyRequest": {
             "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
             "properties": {
-                "code": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.AuditAction": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "AuditCreate",
+                "AuditUpdate",
+                "AuditDelete"
+            ]
+        },
+        "domain.AuditChanges": {
+            "type": "object",
+            "additionalProperties": {
+                "$ref": "#/definitions/domain.FieldChange"
+            }
+        },
+        "domain.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/domain.AuditAction"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "changes": {
+                    "$ref": "#/definitions/domain.AuditChanges"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/domain.ProblemDetails"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "user": {
+                    "$ref": "#/definitions/domain.User"
+                }
+            }
+        },
+        "domain.BatchMode": {
+            "type": "string",
+            "enum": [
+                "atomic",
+                "best_effort"
+            ],
+            "x-enum-varnames": [
+                "BatchAtomic",
+                "BatchBestEffort"
+            ]
+        },
+        "domain.BatchOperation": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "delete"
+            ],
+            "x-enum-varnames": [
+                "BatchCreate",
+                "BatchUpdate",
+                "BatchDelete"
+            ]
+        },
+        "domain.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.User"
+                    }
+                },
+                "mode": {
+                    "enum": [
+                        "atomic",
+                        "best_effort"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.BatchMode"
+                        }
+                    ]
+                },
+                "operation": {
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.BatchOperation"
+                        }
+                    ]
+                }
+            }
+        },
+        "domain.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "$ref": "#/definitions/domain.BatchMode"
+                },
+                "operation": {
+                    "$ref": "#/definitions/domain.BatchOperation"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.BatchItemResult"
+                    }
+                },
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.DeliveryStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "succeeded",
+                "dead_letter"
+            ],
+            "x-enum-varnames": [
+                "DeliveryPending",
+                "DeliverySucceeded",
+                "DeliveryDeadLetter"
+            ]
+        },
+        "domain.ErrorCode": {
+            "type": "string",
+            "enum": [
+                "BAD_REQUEST",
+                "UNAUTHORIZED",
+                "FORBIDDEN",
+                "VALIDATION_FAILED",
+                "NOT_FOUND",
+                "USER_NOT_FOUND",
+                "USER_ALREADY_EXISTS",
+                "CONFLICT",
+                "CONSTRAINT_VIOLATION",
+                "RETRYABLE_CONFLICT",
+                "SERVICE_UNAVAILABLE",
+                "INVALID_PATCH",
+                "UNSUPPORTED_MEDIA_TYPE",
+                "PRECONDITION_FAILED",
+                "PRECONDITION_REQUIRED",
+                "IDEMPOTENCY_KEY_REUSED",
+                "IDEMPOTENCY_REQUEST_IN_PROGRESS",
+                "BATCH_TOO_LARGE",
+                "BATCH_ABORTED",
+                "INVALID_STATUS_TRANSITION",
+                "UNEXPECTED_ERROR"
+            ],
+            "x-enum-varnames": [
+                "ErrCodeBadRequest",
+                "ErrCodeUnauthorized",
+                "ErrCodeForbidden",
+                "ErrCodeValidationFailed",
+                "ErrCodeNotFound",
+                "ErrCodeUserNotFound",
+                "ErrCodeUserAlreadyExists",
+                "ErrCodeConflict",
+                "ErrCodeConstraint",
+                "ErrCodeRetryable",
+                "ErrCodeUnavailable",
+                "ErrCodeInvalidPatch",
+                "ErrCodeUnsupportedMedia",
+                "ErrCodePreconditionFail",
+                "ErrCodePreconditionReq",
+                "ErrCodeIdempotencyReused",
+                "ErrCodeIdempotencyBusy",
+                "ErrCodeBatchTooLarge",
+                "ErrCodeBatchAborted",
+                "ErrCodeInvalidTransition",
+                "ErrCodeUnexpected"
+            ]
+        },
+        "domain.EventEnvelope": {
+            "type": "object",
+            "properties": {
+                "aggregate_id": {
+                    "type": "string"
+                },
+                "aggregate_type": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "type": {
+                    "$ref": "#/definitions/domain.EventType"
+                }
+            }
+        },
+        "domain.EventType": {
+            "type": "string",
+            "enum": [
+                "user.created",
+                "user.updated",
+                "user.deleted"
+            ],
+            "x-enum-varnames": [
+                "UserCreated",
+                "UserUpdated",
+                "UserDeleted"
+            ]
+        },
+        "domain.FieldChange": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                }
+            }
+        },
+        "domain.ImportReport": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "Error is set when the whole file could not be read.",
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.ImportRowError"
+                    }
+                },
+                "errors_truncated": {
+                    "type": "boolean"
+                },
+                "failed_rows": {
+                    "type": "integer"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "imported_rows": {
+                    "type": "integer"
+                },
+                "status": {
+                    "enum": [
+                        "pending",
+                        "running",
+                        "completed",
+                        "failed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.ImportStatus"
+                        }
+                    ]
+                },
+                "total_rows": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "details": {},
+                "line": {
                     "type": "integer"
                 },
                 "message": {
@@ -177,22 +1848,279 @@ const docTemplate = `{
                 }
             }
         },
+        "domain.ImportStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "ImportPending",
+                "ImportRunning",
+                "ImportCompleted",
+                "ImportFailed"
+            ]
+        },
+        "domain.ProblemDetails": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/domain.ErrorCode"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "details": {},
+                "instance": {
+                    "type": "string"
+                },
+                "retryable": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.RolesChange": {
+            "type": "object",
+            "properties": {
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.StatusChange": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "Unverified, it is only used for anonymous requests, the subject of the principal replaces it.",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
         "domain.User": {
             "type": "object",
             "properties": {
                 "age": {
-                    "type": "integer"
+                    "type": "integer",
+                    "maximum": 150,
+                    "minimum": 1
                 },
                 "created_date": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "email": {
+                    "description": "Optional, stored in lower case, so the unique index of the users with an email ignores the case.",
+                    "type": "string",
+                    "maxLength": 254
+                },
                 "id": {
                     "type": "integer"
                 },
                 "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "roles": {
+                    "description": "Granted through the roles endpoint by an admin, created users have none and updates and patches keep them.",
+                    "type": "array",
+                    "maxItems": 10,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "description": "Users are created active or pending, then changed through the lifecycle endpoints, updates and patches keep the current status.",
+                    "enum": [
+                        "active",
+                        "suspended",
+                        "pending",
+                        "deactivated"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.UserStatus"
+                        }
+                    ]
+                },
+                "status_changed_at": {
+                    "type": "string"
+                },
+                "status_changed_by": {
+                    "type": "string"
+                },
+                "status_reason": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "description": "External identifier, clients can use it in place of the numeric ID.",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.UserHistory": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.AuditEntry"
+                    }
+                },
+                "next_before": {
+                    "description": "Before value of the next page, omitted on the last page.",
+                    "type": "integer"
+                },
+                "user": {
+                    "description": "The user as it was at as_of, only set when as_of is given.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.User"
+                        }
+                    ]
+                }
+            }
+        },
+        "domain.UserStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "suspended",
+                "pending",
+                "deactivated"
+            ],
+            "x-enum-varnames": [
+                "UserActive",
+                "UserSuspended",
+                "UserPending",
+                "UserDeactivated"
+            ]
+        },
+        "domain.WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "event_id": {
+                    "type": "string"
+                },
+                "event_type": {
+                    "$ref": "#/definitions/domain.EventType"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_status_code": {
+                    "type": "integer"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "status": {
+                    "$ref": "#/definitions/domain.DeliveryStatus"
+                },
+                "subscription_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.WebhookSubscription": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.EventType"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
+        },
+        "domain.WebhookSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "event_types",
+                "url"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "event_types": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/domain.EventType"
+                    }
+                },
+                "secret": {
+                    "type": "string",
+                    "maxLength": 256,
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
         }
     }
 }`
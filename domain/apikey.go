@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"time"
+)
+
+const (
+	// APIKeyIssuer is the issuer of the principals authenticated by an API key.
+	APIKeyIssuer = "api-key"
+	// ScopeAPIKeysAdmin allows to issue, change and revoke API keys.
+	ScopeAPIKeysAdmin = "api-keys:admin"
+)
+
+/*
+APIKey authenticates an internal service with the scopes it was issued.
+Only the SHA-256 hash of the key is stored, the key itself is returned once, in the response that issues it.
+*/
+type APIKey struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Name string `gorm:"size:100;not null" json:"name"`
+	// Prefix identifies the key in listings and logs, it is the start of the key.
+	Prefix     string     `gorm:"size:16;uniqueIndex;not null" json:"prefix"`
+	Hash       string     `gorm:"size:64;not null" json:"-"`
+	Scopes     []string   `gorm:"serializer:json;not null" json:"scopes"`
+	CreatedBy  string     `gorm:"size:100" json:"created_by,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Key string `gorm:"-" json:"key,omitempty"`
+}
+
+// Usable tells whether the key may still authenticate at the given time.
+func (k APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// APIKeyRequest issues or changes a key, a key without expiry is valid until it is revoked.
+type APIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,max=50,unique,dive,required,max=100,scope"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r APIKeyRequest) Validate(now time.Time) *AppError {
+	var violations []FieldViolation
+
+	var validationErrors validator.ValidationErrors
+	err := validate.Struct(r)
+	if errors.As(err, &validationErrors) {
+		for _, fieldErr := range validationErrors {
+			violations = append(violations, FieldViolation{Field: fieldErr.Field(), Rule: fieldErr.Tag(), Message: violationMessage(fieldErr)})
+		}
+	} else if err != nil {
+		return NewUnexpectedError(err.Error()).WithCause(err)
+	}
+
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		violations = append(violations, FieldViolation{Field: "expires_at", Rule: "future", Message: "must be in the future"})
+	}
+	if len(violations) > 0 {
+		return NewValidationError("Validation failed.").WithDetails(violations)
+	}
+	return nil
+}
+
+// APIKeyAuthenticator checks an API key and returns the principal it was issued to.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (Principal, *AppError)
+}
+
+type APIKeyUseCase interface {
+	APIKeyAuthenticator
+	// CreateAPIKey issues a key, the returned APIKey is the only one holding the key.
+	CreateAPIKey(ctx context.Context, request APIKeyRequest) (APIKey, *AppError)
+	GetAPIKey(ctx context.Context, id uint) (APIKey, *AppError)
+	ListAPIKeys(ctx context.Context) ([]APIKey, *AppError)
+	// UpdateAPIKey replaces the name, scopes and expiry of a key, the key itself stays the same.
+	UpdateAPIKey(ctx context.Context, id uint, request APIKeyRequest) (APIKey, *AppError)
+	// RevokeAPIKey stops a key from authenticating, revoking a revoked key changes nothing.
+	RevokeAPIKey(ctx context.Context, id uint) (APIKey, *AppError)
+}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, apiKey APIKey) (APIKey, *AppError)
+	GetAPIKey(ctx context.Context, id uint) (APIKey, *AppError)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (APIKey, *AppError)
+	ListAPIKeys(ctx context.Context) ([]APIKey, *AppError)
+	UpdateAPIKey(ctx context.Context, apiKey APIKey) (APIKey, *AppError)
+	// TouchAPIKey sets the last use of a key, without changing its updated_at.
+	TouchAPIKey(ctx context.Context, id uint, usedAt time.Time) *AppError
+}
@@ -0,0 +1,176 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+)
+
+// FieldChange holds the JSON values of a user field before and after a change, null when the field had no value.
+type FieldChange struct {
+	Before json.RawMessage `json:"before" swaggertype:"object"`
+	After  json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+// AuditChanges are the changed fields of a user by JSON name, kept as JSON in a text column.
+type AuditChanges map[string]FieldChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	value, err := json.Marshal(map[string]FieldChange(c))
+	return string(value), err
+}
+
+func (c *AuditChanges) Scan(value any) error {
+	switch v := value.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case []byte:
+		return json.Unmarshal(v, c)
+	default:
+		return errors.New("audit changes must be stored as text")
+	}
+}
+
+/*
+AuditEntry records one change of a user with who made it and from where.
+Entries are written in the transaction of the change, the user_audit table only accepts inserts.
+The actor is the subject of the authenticated principal, without authentication it is whatever the client claimed in X-Actor.
+*/
+type AuditEntry struct {
+	ID        uint64       `gorm:"primaryKey" json:"id"`
+	UserID    uint         `gorm:"index;not null" json:"user_id"`
+	Action    AuditAction  `gorm:"size:16;not null" json:"action"`
+	Changes   AuditChanges `gorm:"type:text;not null" json:"changes"`
+	Actor     string       `gorm:"size:100" json:"actor,omitempty"`
+	RequestID string       `gorm:"size:64" json:"request_id,omitempty"`
+	ClientIP  string       `gorm:"size:45" json:"client_ip,omitempty"`
+	CreatedAt time.Time    `gorm:"not null" json:"created_at"`
+}
+
+func (AuditEntry) TableName() string {
+	return "user_audit"
+}
+
+// AuditMetadata tells who made a change and from where, it travels in the request context. The actor is only verified when there is a principal.
+type AuditMetadata struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+}
+
+type auditMetadataKey struct{}
+
+func WithAuditMetadata(ctx context.Context, metadata AuditMetadata) context.Context {
+	return context.WithValue(ctx, auditMetadataKey{}, metadata)
+}
+
+// AuditMetadataFrom returns the metadata of the context, empty outside a request.
+func AuditMetadataFrom(ctx context.Context) AuditMetadata {
+	metadata, _ := ctx.Value(auditMetadataKey{}).(AuditMetadata)
+	return metadata
+}
+
+/*
+NewAuditEntry compares the user before and after a change field by field.
+Before is nil for a created user, the entry of a created user holds every field.
+*/
+func NewAuditEntry(ctx context.Context, action AuditAction, before *User, after User, now time.Time) (AuditEntry, *AppError) {
+	beforeFields, err := userFields(before)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	afterFields, err := userFields(&after)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+
+	changes := AuditChanges{}
+	for field, value := range afterFields {
+		if previous, ok := beforeFields[field]; !ok || !bytes.Equal(previous, value) {
+			changes[field] = FieldChange{Before: beforeFields[field], After: value}
+		}
+	}
+
+	metadata := AuditMetadataFrom(ctx)
+	return AuditEntry{
+		UserID:    after.ID,
+		Action:    action,
+		Changes:   changes,
+		Actor:     metadata.Actor,
+		RequestID: metadata.RequestID,
+		ClientIP:  metadata.ClientIP,
+		CreatedAt: now,
+	}, nil
+}
+
+func userFields(user *User) (map[string]json.RawMessage, *AppError) {
+	fields := map[string]json.RawMessage{}
+	if user == nil {
+		return fields, nil
+	}
+	value, err := json.Marshal(user)
+	if err == nil {
+		err = json.Unmarshal(value, &fields)
+	}
+	if err != nil {
+		return nil, NewUnexpectedError("User could not be serialized for the audit trail.").WithCause(err)
+	}
+	return fields, nil
+}
+
+// ReplayAudit rebuilds a user from its audit entries, which must be in the order they were written.
+func ReplayAudit(entries []AuditEntry) (User, *AppError) {
+	fields := map[string]json.RawMessage{}
+	for _, entry := range entries {
+		for field, change := range entry.Changes {
+			fields[field] = change.After
+		}
+	}
+
+	var user User
+	value, err := json.Marshal(fields)
+	if err == nil {
+		err = json.Unmarshal(value, &user)
+	}
+	if err != nil {
+		return user, NewUnexpectedError("User could not be rebuilt from the audit trail.").WithCause(err)
+	}
+	return user, nil
+}
+
+// HistoryQuery selects a page of the audit trail of a user, zero values are ignored.
+type HistoryQuery struct {
+	// Only entries with an ID below Before, the next_before of the previous page.
+	Before uint64
+	Limit  int
+	// Only entries written at or before AsOf, the user is rebuilt as it was at that time.
+	AsOf time.Time
+}
+
+type UserHistory struct {
+	Entries []AuditEntry `json:"entries"`
+	// Before value of the next page, omitted on the last page.
+	NextBefore uint64 `json:"next_before,omitempty"`
+	// The user as it was at as_of, only set when as_of is given.
+	User *User `json:"user,omitempty"`
+}
+
+type AuditRepository interface {
+	// Add stores the entries in the transaction of the context.
+	Add(ctx context.Context, entries ...AuditEntry) *AppError
+	// ListByUser returns up to limit entries of the user with an ID below before, newest first. Zero before and until are ignored.
+	ListByUser(ctx context.Context, userID uint, before uint64, until time.Time, limit int) ([]AuditEntry, *AppError)
+	// ListByUserUntil returns every entry of the user written at or before until, oldest first.
+	ListByUserUntil(ctx context.Context, userID uint, until time.Time) ([]AuditEntry, *AppError)
+}
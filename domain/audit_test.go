@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Record_Only_Changed_Fields_In_Audit_Entry(t *testing.T) {
+	// GIVEN
+	ctx := WithAuditMetadata(context.Background(), AuditMetadata{Actor: "admin", RequestID: "request-1", ClientIP: "10.0.0.1"})
+	before := User{ID: 1, Name: "Ada", Age: 36, Email: "ada@example.com", Roles: Roles{}, Version: 1}
+	after := before
+	after.Age = 37
+	after.Version = 2
+
+	// WHEN
+	entry, err := NewAuditEntry(ctx, AuditUpdate, &before, after, time.Now())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), entry.UserID)
+	assert.Equal(t, AuditChanges{
+		"age":     {Before: []byte("36"), After: []byte("37")},
+		"version": {Before: []byte("1"), After: []byte("2")},
+	}, entry.Changes)
+	assert.Equal(t, "admin", entry.Actor)
+	assert.Equal(t, "request-1", entry.RequestID)
+	assert.Equal(t, "10.0.0.1", entry.ClientIP)
+}
+
+func Test_Should_Replay_Audit_Entries(t *testing.T) {
+	// GIVEN
+	now := time.Now()
+	created := User{ID: 1, Name: "Ada", Age: 36, Email: "ada@example.com", Status: UserActive, Roles: Roles{"admin"}, Version: 1}
+	updated := created
+	updated.Name = "Ada Lovelace"
+	updated.Version = 2
+	createEntry, _ := NewAuditEntry(context.Background(), AuditCreate, nil, created, now)
+	updateEntry, _ := NewAuditEntry(context.Background(), AuditUpdate, &created, updated, now)
+
+	// WHEN
+	asCreated, createErr := ReplayAudit([]AuditEntry{createEntry})
+	asUpdated, updateErr := ReplayAudit([]AuditEntry{createEntry, updateEntry})
+
+	// THEN
+	assert.Nil(t, createErr)
+	assert.Nil(t, updateErr)
+	assert.Equal(t, created, asCreated)
+	assert.Equal(t, updated, asUpdated)
+}
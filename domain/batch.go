@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"fmt"
+)
+
+type BatchOperation string
+
+const (
+	BatchCreate BatchOperation = "create"
+	BatchUpdate BatchOperation = "update"
+	BatchDelete BatchOperation = "delete"
+)
+
+/*
+BatchMode decides what happens when an item fails.
+Atomic batches run in one transaction and nothing is written if any item fails,
+best effort batches write every item that succeeds.
+*/
+type BatchMode string
+
+const (
+	BatchAtomic     BatchMode = "atomic"
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+/*
+BatchRequest holds users for create and update, deletes only read the id and version of each item.
+Updates and deletes are checked against the version of each item, version 0 skips the check like "If-Match: *".
+*/
+type BatchRequest struct {
+	Operation BatchOperation `json:"operation" enums:"create,update,delete"`
+	Mode      BatchMode      `json:"mode" enums:"atomic,best_effort"`
+	Items     []User         `json:"items"`
+}
+
+type BatchItemResult struct {
+	Index  int             `json:"index"`
+	Status int             `json:"status"`
+	User   *User           `json:"user,omitempty"`
+	Error  *ProblemDetails `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Operation BatchOperation    `json:"operation"`
+	Mode      BatchMode         `json:"mode"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+func (r BatchRequest) Validate(maxSize int) *AppError {
+	switch r.Operation {
+	case BatchCreate, BatchUpdate, BatchDelete:
+	default:
+		return NewBadRequestError("Invalid batch operation: " + string(r.Operation))
+	}
+	switch r.Mode {
+	case BatchAtomic, BatchBestEffort:
+	default:
+		return NewBadRequestError("Invalid batch mode: " + string(r.Mode))
+	}
+	if len(r.Items) == 0 {
+		return NewBadRequestError("Batch must contain at least one item.")
+	}
+	if len(r.Items) > maxSize {
+		return NewBatchTooLargeError(fmt.Sprintf("Batch must not contain more than %d items.", maxSize))
+	}
+	return nil
+}
@@ -1,36 +1,199 @@
 package domain
 
 import (
+	"fmt"
 	"net/http"
+	"runtime"
 )
 
+type ErrorCode string
+
+const (
+	ErrCodeBadRequest        ErrorCode = "BAD_REQUEST"
+	ErrCodeUnauthorized      ErrorCode = "UNAUTHORIZED"
+	ErrCodeForbidden         ErrorCode = "FORBIDDEN"
+	ErrCodeValidationFailed  ErrorCode = "VALIDATION_FAILED"
+	ErrCodeNotFound          ErrorCode = "NOT_FOUND"
+	ErrCodeUserNotFound      ErrorCode = "USER_NOT_FOUND"
+	ErrCodeUserAlreadyExists ErrorCode = "USER_ALREADY_EXISTS"
+	ErrCodeConflict          ErrorCode = "CONFLICT"
+	ErrCodeConstraint        ErrorCode = "CONSTRAINT_VIOLATION"
+	ErrCodeRetryable         ErrorCode = "RETRYABLE_CONFLICT"
+	ErrCodeUnavailable       ErrorCode = "SERVICE_UNAVAILABLE"
+	ErrCodeInvalidPatch      ErrorCode = "INVALID_PATCH"
+	ErrCodeUnsupportedMedia  ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodePreconditionFail  ErrorCode = "PRECONDITION_FAILED"
+	ErrCodePreconditionReq   ErrorCode = "PRECONDITION_REQUIRED"
+	ErrCodeIdempotencyReused ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodeIdempotencyBusy   ErrorCode = "IDEMPOTENCY_REQUEST_IN_PROGRESS"
+	ErrCodeBatchTooLarge     ErrorCode = "BATCH_TOO_LARGE"
+	ErrCodeBatchAborted      ErrorCode = "BATCH_ABORTED"
+	ErrCodeInvalidTransition ErrorCode = "INVALID_STATUS_TRANSITION"
+	ErrCodeUnexpected        ErrorCode = "UNEXPECTED_ERROR"
+)
+
+const ProblemContentType = "application/problem+json"
+
 type AppError struct {
-	Code    int    `json:",omitempty"`
-	Message string `json:"message"`
+	Status    int
+	Code      ErrorCode
+	Message   string
+	Details   interface{}
+	Retryable bool
+	Cause     error
+	stack     []uintptr
+}
+
+// ProblemDetails is the RFC 7807 representation of an AppError.
+type ProblemDetails struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail"`
+	Instance  string      `json:"instance,omitempty"`
+	Code      ErrorCode   `json:"code"`
+	Details   interface{} `json:"details,omitempty"`
+	Retryable bool        `json:"retryable,omitempty"`
+}
+
+func newAppError(status int, code ErrorCode, message string) *AppError {
+	// Skip runtime.Callers, newAppError and the exported constructor.
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	return &AppError{Status: status, Code: code, Message: message, stack: pcs[:n]}
+}
+
+func (e *AppError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// StackTrace returns the program counters captured when the error was created. It is picked up by Sentry.
+func (e *AppError) StackTrace() []uintptr {
+	return e.stack
+}
+
+func (e *AppError) WithCause(cause error) *AppError {
+	e.Cause = cause
+	return e
 }
 
-func (e AppError) AsMessageError() *AppError {
-	return &AppError{
-		Message: e.Message,
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	e.Details = details
+	return e
+}
+
+func (e *AppError) Problem(instance string) ProblemDetails {
+	return ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Code,
+		Details:   e.Details,
+		Retryable: e.Retryable,
 	}
 }
 
 func NewNotFoundError(message string) *AppError {
-	return &AppError{Code: http.StatusNotFound, Message: message}
+	return newAppError(http.StatusNotFound, ErrCodeNotFound, message)
+}
+
+func NewUserNotFoundError(id uint) *AppError {
+	return newAppError(http.StatusNotFound, ErrCodeUserNotFound, fmt.Sprintf("User not found, ID: %d", id))
+}
+
+func NewUserUUIDNotFoundError(userUUID string) *AppError {
+	return newAppError(http.StatusNotFound, ErrCodeUserNotFound, "User not found, UUID: "+userUUID)
 }
 
 func NewUnexpectedError(message string) *AppError {
-	return &AppError{Code: http.StatusInternalServerError, Message: message}
+	return newAppError(http.StatusInternalServerError, ErrCodeUnexpected, message)
 }
 
 func NewBadRequestError(message string) *AppError {
-	return &AppError{Code: http.StatusBadRequest, Message: message}
+	return newAppError(http.StatusBadRequest, ErrCodeBadRequest, message)
+}
+
+func NewUnauthorizedError(message string) *AppError {
+	return newAppError(http.StatusUnauthorized, ErrCodeUnauthorized, message)
+}
+
+func NewForbiddenError(message string) *AppError {
+	return newAppError(http.StatusForbidden, ErrCodeForbidden, message)
+}
+
+func NewDeletedUserNotFoundError(id uint) *AppError {
+	return newAppError(http.StatusNotFound, ErrCodeUserNotFound, fmt.Sprintf("Deleted user not found, ID: %d", id))
 }
 
 func NewUserAlreadyExistError(message string) *AppError {
-	return &AppError{Code: http.StatusConflict, Message: message}
+	return newAppError(http.StatusConflict, ErrCodeUserAlreadyExists, message)
 }
 
 func NewValidationError(message string) *AppError {
-	return &AppError{Code: http.StatusBadRequest, Message: message}
+	return newAppError(http.StatusBadRequest, ErrCodeValidationFailed, message)
+}
+
+func NewConflictError(message string) *AppError {
+	return newAppError(http.StatusConflict, ErrCodeConflict, message)
+}
+
+func NewConstraintViolationError(message string) *AppError {
+	return newAppError(http.StatusUnprocessableEntity, ErrCodeConstraint, message)
+}
+
+func NewRetryableError(message string) *AppError {
+	err := newAppError(http.StatusConflict, ErrCodeRetryable, message)
+	err.Retryable = true
+	return err
+}
+
+func NewServiceUnavailableError(message string) *AppError {
+	err := newAppError(http.StatusServiceUnavailable, ErrCodeUnavailable, message)
+	err.Retryable = true
+	return err
+}
+
+func NewInvalidPatchError(message string) *AppError {
+	return newAppError(http.StatusUnprocessableEntity, ErrCodeInvalidPatch, message)
+}
+
+func NewUnsupportedMediaTypeError(message string) *AppError {
+	return newAppError(http.StatusUnsupportedMediaType, ErrCodeUnsupportedMedia, message)
+}
+
+func NewPreconditionFailedError(message string) *AppError {
+	return newAppError(http.StatusPreconditionFailed, ErrCodePreconditionFail, message)
+}
+
+func NewPreconditionRequiredError(message string) *AppError {
+	return newAppError(http.StatusPreconditionRequired, ErrCodePreconditionReq, message)
+}
+
+func NewIdempotencyKeyReusedError(message string) *AppError {
+	return newAppError(http.StatusUnprocessableEntity, ErrCodeIdempotencyReused, message)
+}
+
+func NewIdempotencyInProgressError(message string) *AppError {
+	return newAppError(http.StatusConflict, ErrCodeIdempotencyBusy, message)
+}
+
+func NewBatchTooLargeError(message string) *AppError {
+	return newAppError(http.StatusRequestEntityTooLarge, ErrCodeBatchTooLarge, message)
+}
+
+func NewBatchAbortedError(message string) *AppError {
+	return newAppError(http.StatusFailedDependency, ErrCodeBatchAborted, message)
+}
+
+func NewInvalidTransitionError(message string) *AppError {
+	return newAppError(http.StatusConflict, ErrCodeInvalidTransition, message)
 }
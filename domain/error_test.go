@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func Test_Should_Unwrap_Cause_Of_App_Error(t *testing.T) {
+	// GIVEN
+	cause := errors.New("connection refused")
+
+	// WHEN
+	var err error = NewUnexpectedError("Unexpected error.").WithCause(cause)
+
+	// THEN
+	var appErr *AppError
+	assert.True(t, errors.Is(err, cause))
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, ErrCodeUnexpected, appErr.Code)
+	assert.NotEmpty(t, appErr.StackTrace())
+}
+
+func Test_Should_Convert_App_Error_To_Problem_Details(t *testing.T) {
+	// WHEN
+	problem := NewUserNotFoundError(1).Problem("/api/v1/users/1")
+
+	// THEN
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, http.StatusText(http.StatusNotFound), problem.Title)
+	assert.Equal(t, ErrCodeUserNotFound, problem.Code)
+	assert.Equal(t, "User not found, ID: 1", problem.Detail)
+	assert.Equal(t, "/api/v1/users/1", problem.Instance)
+}
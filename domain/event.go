@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"strconv"
+	"time"
+)
+
+type EventType string
+
+const (
+	UserCreated EventType = "user.created"
+	UserUpdated EventType = "user.updated"
+	UserDeleted EventType = "user.deleted"
+)
+
+const UserAggregate = "user"
+
+func (t EventType) Valid() bool {
+	switch t {
+	case UserCreated, UserUpdated, UserDeleted:
+		return true
+	default:
+		return false
+	}
+}
+
+/*
+OutboxEvent is a domain event waiting in the outbox table until the relay delivers it.
+Events are written in the transaction of the change they describe and delivered in ID order per aggregate.
+*/
+type OutboxEvent struct {
+	ID            uint64    `gorm:"primaryKey"`
+	EventID       string    `gorm:"uniqueIndex;not null"`
+	AggregateType string    `gorm:"not null"`
+	AggregateID   string    `gorm:"index;not null"`
+	EventType     EventType `gorm:"not null"`
+	Payload       []byte    `gorm:"not null"`
+	CreatedAt     time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   *time.Time `gorm:"index"`
+	// DeadLetteredAt is set once the attempts are used up, the event is not delivered anymore.
+	DeadLetteredAt *time.Time `gorm:"index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox"
+}
+
+// EventEnvelope is the representation of an event handed to the sinks.
+type EventEnvelope struct {
+	ID            string          `json:"id"`
+	Type          EventType       `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+func (e OutboxEvent) Envelope() EventEnvelope {
+	return EventEnvelope{
+		ID:            e.EventID,
+		Type:          e.EventType,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		OccurredAt:    e.CreatedAt,
+		Data:          e.Payload,
+	}
+}
+
+func NewUserEvent(eventType EventType, user User) (OutboxEvent, *AppError) {
+	payload, err := json.Marshal(user)
+	if err != nil {
+		return OutboxEvent{}, NewUnexpectedError("User event could not be serialized.").WithCause(err)
+	}
+
+	now := time.Now()
+	return OutboxEvent{
+		EventID:       uuid.NewString(),
+		AggregateType: UserAggregate,
+		AggregateID:   strconv.FormatUint(uint64(user.ID), 10),
+		EventType:     eventType,
+		Payload:       payload,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+type OutboxRepository interface {
+	// Add stores the events in the transaction of the context.
+	Add(ctx context.Context, events ...OutboxEvent) *AppError
+	// TryLock takes a transaction scoped lock, so only one relay delivers at a time.
+	TryLock(ctx context.Context) (bool, *AppError)
+	// FetchPending returns the oldest events due now, unless an earlier event of their aggregate waits for a retry.
+	FetchPending(ctx context.Context, limit int) ([]OutboxEvent, *AppError)
+	// Reschedule moves the next attempt of the events, the relay claims events by moving it past their delivery.
+	Reschedule(ctx context.Context, ids []uint64, nextAttemptAt time.Time) *AppError
+	MarkPublished(ctx context.Context, id uint64, publishedAt time.Time) *AppError
+	MarkFailed(ctx context.Context, id uint64, attempts int, nextAttemptAt time.Time, lastError string) *AppError
+	MarkDeadLettered(ctx context.Context, id uint64, attempts int, deadLetteredAt time.Time, lastError string) *AppError
+	// OldestPendingCreatedAt returns nil when every event is published or dead lettered.
+	OldestPendingCreatedAt(ctx context.Context) (*time.Time, *AppError)
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, *AppError)
+	// FetchAfter returns the events with an ID above afterID in ID order, published or not.
+	FetchAfter(ctx context.Context, afterID uint64, limit int) ([]OutboxEvent, *AppError)
+	// FetchLatest returns the newest events in ID order.
+	FetchLatest(ctx context.Context, limit int) ([]OutboxEvent, *AppError)
+}
+
+// EventSink delivers events to a downstream system, it must be safe to deliver the same event more than once.
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, envelope EventEnvelope) error
+}
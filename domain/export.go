@@ -0,0 +1,29 @@
+package domain
+
+type ExportFormat string
+
+const (
+	ExportCSV     ExportFormat = "csv"
+	ExportNDJSON  ExportFormat = "ndjson"
+	ExportParquet ExportFormat = "parquet"
+)
+
+func (f ExportFormat) Valid() bool {
+	switch f {
+	case ExportCSV, ExportNDJSON, ExportParquet:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/vnd.apache.parquet"
+	}
+}
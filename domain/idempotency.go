@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type IdempotencyRecord struct {
+	Key                 string `gorm:"primaryKey"`
+	Fingerprint         string `gorm:"not null"`
+	Completed           bool   `gorm:"not null;default:false"`
+	ResponseStatus      int
+	ResponseContentType string
+	ResponseBody        []byte
+	CreatedAt           time.Time
+	ExpiresAt           time.Time `gorm:"index"`
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+type IdempotencyRepository interface {
+	// Reserve stores a new in-flight record. It returns false when a live record with the same key already exists.
+	Reserve(ctx context.Context, record IdempotencyRecord) (bool, *AppError)
+	GetByKey(ctx context.Context, key string) (IdempotencyRecord, *AppError)
+	Complete(ctx context.Context, record IdempotencyRecord) *AppError
+	DeleteByKey(ctx context.Context, key string) *AppError
+	DeleteExpired(ctx context.Context, now time.Time) (int64, *AppError)
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+)
+
+type ImportFormat string
+
+const (
+	ImportCSV    ImportFormat = "csv"
+	ImportNDJSON ImportFormat = "ndjson"
+)
+
+type ImportStatus string
+
+const (
+	ImportPending   ImportStatus = "pending"
+	ImportRunning   ImportStatus = "running"
+	ImportCompleted ImportStatus = "completed"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// ImportRowError points at the line of the uploaded file that could not be imported.
+type ImportRowError struct {
+	Line    int         `json:"line"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+type ImportReport struct {
+	ID              string           `json:"id,omitempty"`
+	Status          ImportStatus     `json:"status" enums:"pending,running,completed,failed"`
+	DryRun          bool             `json:"dry_run"`
+	TotalRows       int              `json:"total_rows"`
+	ImportedRows    int              `json:"imported_rows"`
+	FailedRows      int              `json:"failed_rows"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+	// Error is set when the whole file could not be read.
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+type UserTransition string
+
+const (
+	TransitionActivate   UserTransition = "activate"
+	TransitionSuspend    UserTransition = "suspend"
+	TransitionDeactivate UserTransition = "deactivate"
+)
+
+type transitionRule struct {
+	from []UserStatus
+	to   UserStatus
+	// The transition is rejected without a reason.
+	reasonRequired bool
+}
+
+// Statuses each transition starts from and the status it leads to. Deactivated users can not leave that status.
+var transitionRules = map[UserTransition]transitionRule{
+	TransitionActivate:   {from: []UserStatus{UserPending, UserSuspended}, to: UserActive},
+	TransitionSuspend:    {from: []UserStatus{UserActive}, to: UserSuspended, reasonRequired: true},
+	TransitionDeactivate: {from: []UserStatus{UserPending, UserActive, UserSuspended}, to: UserDeactivated, reasonRequired: true},
+}
+
+// StatusChange describes why and by whom the status of a user is changed.
+type StatusChange struct {
+	Reason string `json:"reason"`
+	// Unverified, it is only used for anonymous requests, the subject of the principal replaces it.
+	Actor string `json:"actor"`
+}
+
+func (c StatusChange) validate(rule transitionRule) *AppError {
+	var violations []FieldViolation
+	if rule.reasonRequired && c.Reason == "" {
+		violations = append(violations, FieldViolation{Field: "reason", Rule: "required", Message: "is required"})
+	}
+	if utf8.RuneCountInString(c.Reason) > 500 {
+		violations = append(violations, FieldViolation{Field: "reason", Rule: "max", Message: "must be at most 500 characters long"})
+	}
+	if utf8.RuneCountInString(c.Actor) > 100 {
+		violations = append(violations, FieldViolation{Field: "actor", Rule: "max", Message: "must be at most 100 characters long"})
+	}
+	if len(violations) > 0 {
+		return NewValidationError("Validation failed.").WithDetails(violations)
+	}
+	return nil
+}
+
+/*
+ApplyTransition moves the user to the target status of the transition and records the change on the user.
+It fails with a conflict when the current status of the user does not allow the transition.
+*/
+func ApplyTransition(user User, transition UserTransition, change StatusChange, now time.Time) (User, *AppError) {
+	rule, ok := transitionRules[transition]
+	if !ok {
+		return user, NewBadRequestError("Unknown status transition: " + string(transition))
+	}
+	if err := change.validate(rule); err != nil {
+		return user, err
+	}
+
+	current := user.Status
+	if current == "" {
+		current = UserActive
+	}
+	if !rule.allows(current) {
+		return user, NewInvalidTransitionError(fmt.Sprintf("User can not %s from status %s, ID: %d", transition, current, user.ID)).
+			WithDetails(map[string]UserStatus{"from": current, "to": rule.to})
+	}
+
+	user.Status = rule.to
+	user.StatusReason = change.Reason
+	user.StatusChangedAt = &now
+	user.StatusChangedBy = change.Actor
+	return user, nil
+}
+
+func (r transitionRule) allows(status UserStatus) bool {
+	for _, from := range r.from {
+		if from == status {
+			return true
+		}
+	}
+	return false
+}
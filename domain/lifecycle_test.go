@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Apply_Allowed_Transitions(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		from       UserStatus
+		transition UserTransition
+		to         UserStatus
+	}{
+		{UserPending, TransitionActivate, UserActive},
+		{UserActive, TransitionSuspend, UserSuspended},
+		{UserSuspended, TransitionActivate, UserActive},
+		{UserPending, TransitionDeactivate, UserDeactivated},
+		{UserActive, TransitionDeactivate, UserDeactivated},
+		{UserSuspended, TransitionDeactivate, UserDeactivated},
+	}
+
+	for _, c := range cases {
+		user, err := ApplyTransition(User{ID: 1, Status: c.from}, c.transition, StatusChange{Reason: "reason", Actor: "admin"}, now)
+
+		assert.Nil(t, err, "%s from %s", c.transition, c.from)
+		assert.Equal(t, c.to, user.Status)
+		assert.Equal(t, "reason", user.StatusReason)
+		assert.Equal(t, "admin", user.StatusChangedBy)
+		assert.Equal(t, &now, user.StatusChangedAt)
+	}
+}
+
+func Test_Should_Reject_Invalid_Transitions(t *testing.T) {
+	cases := []struct {
+		from       UserStatus
+		transition UserTransition
+	}{
+		{UserActive, TransitionActivate},
+		{UserPending, TransitionSuspend},
+		{UserSuspended, TransitionSuspend},
+		{UserDeactivated, TransitionActivate},
+		{UserDeactivated, TransitionDeactivate},
+	}
+
+	for _, c := range cases {
+		user := User{ID: 1, Status: c.from}
+		result, err := ApplyTransition(user, c.transition, StatusChange{Reason: "reason"}, time.Now())
+
+		assert.NotNil(t, err, "%s from %s", c.transition, c.from)
+		assert.Equal(t, ErrCodeInvalidTransition, err.Code)
+		assert.Equal(t, 409, err.Status)
+		assert.Equal(t, user, result)
+	}
+}
+
+func Test_Should_Require_Reason_For_Suspend(t *testing.T) {
+	_, err := ApplyTransition(User{ID: 1, Status: UserActive}, TransitionSuspend, StatusChange{}, time.Now())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, ErrCodeValidationFailed, err.Code)
+	assert.Equal(t, []FieldViolation{{Field: "reason", Rule: "required", Message: "is required"}}, err.Details)
+}
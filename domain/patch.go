@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"reflect"
+)
+
+type PatchType string
+
+const (
+	MergePatch PatchType = "application/merge-patch+json"
+	JSONPatch  PatchType = "application/json-patch+json"
+)
+
+// Fields a patch is never allowed to change.
+var immutableUserFields = []string{"ID", "UUID", "Status", "StatusReason", "StatusChangedAt", "StatusChangedBy", "Roles", "CreatedDate", "UpdatedAt", "DeletedAt", "Version"}
+
+/*
+ApplyUserPatch applies a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902) document to the user.
+It returns the patched user and the Go names of the fields that were changed.
+*/
+func ApplyUserPatch(user User, patchType PatchType, patch []byte) (User, []string, *AppError) {
+	original, err := json.Marshal(user)
+	if err != nil {
+		return user, nil, NewUnexpectedError("User could not be serialized.").WithCause(err)
+	}
+
+	var patched []byte
+	switch patchType {
+	case MergePatch:
+		patched, err = jsonpatch.MergePatch(original, patch)
+		if err != nil {
+			return user, nil, NewBadRequestError("Invalid merge patch document.").WithCause(err)
+		}
+	case JSONPatch:
+		operations, decodeErr := jsonpatch.DecodePatch(patch)
+		if decodeErr != nil {
+			return user, nil, NewBadRequestError("Invalid JSON patch document.").WithCause(decodeErr)
+		}
+		patched, err = operations.Apply(original)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return user, nil, NewConflictError("JSON patch test operation failed.").WithCause(err)
+		}
+		if err != nil {
+			return user, nil, NewInvalidPatchError("JSON patch could not be applied.").WithCause(err)
+		}
+	default:
+		return user, nil, NewUnsupportedMediaTypeError("Unsupported patch type: " + string(patchType))
+	}
+
+	var patchedUser User
+	if err := json.Unmarshal(patched, &patchedUser); err != nil {
+		return user, nil, NewInvalidPatchError("Patched user is not valid.").WithCause(err)
+	}
+
+	originalValue, patchedValue := reflect.ValueOf(&user).Elem(), reflect.ValueOf(&patchedUser).Elem()
+	for _, field := range immutableUserFields {
+		patchedValue.FieldByName(field).Set(originalValue.FieldByName(field))
+	}
+
+	var changedFields []string
+	for i := 0; i < originalValue.NumField(); i++ {
+		originalField, _ := json.Marshal(originalValue.Field(i).Interface())
+		patchedField, _ := json.Marshal(patchedValue.Field(i).Interface())
+		if string(originalField) != string(patchedField) {
+			changedFields = append(changedFields, originalValue.Type().Field(i).Name)
+		}
+	}
+
+	return patchedUser, changedFields, nil
+}
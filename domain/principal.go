@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"slices"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Issuer  string
+	Scopes  []string
+}
+
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Scopes of the user endpoints, ScopeUsersAdmin allows to read soft deleted users and to change roles.
+const (
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeUsersAdmin = "users:admin"
+)
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the caller of the request, false when the request was not authenticated.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// CallerHasScope tells whether the caller of the request holds the scope.
+// Requests only go without a principal when authentication is turned off, every scope is granted to them.
+func CallerHasScope(ctx context.Context, scope string) bool {
+	principal, ok := PrincipalFrom(ctx)
+	return !ok || principal.HasScope(scope)
+}
+
+// TokenVerifier checks a bearer token and returns the principal it was issued to.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (Principal, *AppError)
+}
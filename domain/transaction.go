@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+)
+
+// TxManager runs several repository calls in one database transaction.
+type TxManager interface {
+	/*
+		WithinTx runs fn in a transaction that the repositories pick up from the context passed to fn.
+		The transaction is committed when fn returns nil and rolled back otherwise. Called inside
+		another transaction it runs in a savepoint, so only the work of fn is rolled back.
+	*/
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) *AppError
+}
+
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+}
+
+type TxOption func(options *TxOptions)
+
+// WithIsolation sets the isolation level of the transaction, it is ignored for savepoints.
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(options *TxOptions) {
+		options.Isolation = level
+	}
+}
+
+func ReadOnly() TxOption {
+	return func(options *TxOptions) {
+		options.ReadOnly = true
+	}
+}
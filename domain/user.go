@@ -1,26 +1,149 @@
 package domain
 
 import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"io"
+	"strings"
 	"time"
 )
 
 type User struct {
-	ID          uint      `gorm:"primaryKey" json:"id"`
-	Name        string    `json:"name"`
-	Age         int       `json:"age"`
-	CreatedDate time.Time `json:"created_date"`
+	ID uint `gorm:"primaryKey" json:"id"`
+	// External identifier, clients can use it in place of the numeric ID.
+	UUID uuid.UUID `gorm:"type:uuid;uniqueIndex" json:"uuid" swaggertype:"string" format:"uuid"`
+	Name string    `json:"name" validate:"min=2,max=100,name_chars"`
+	Age  int       `json:"age" validate:"min=1,max=150"`
+	// Optional, stored in lower case, so the unique index of the users with an email ignores the case.
+	Email string `gorm:"size:254;uniqueIndex:idx_users_email_present,where:email <> ''" json:"email" validate:"omitempty,max=254,email"`
+	// Users are created active or pending, then changed through the lifecycle endpoints, updates and patches keep the current status.
+	Status          UserStatus `gorm:"size:16;not null;default:active" json:"status" validate:"omitempty,oneof=active suspended pending deactivated"`
+	StatusReason    string     `gorm:"size:500" json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	StatusChangedBy string     `gorm:"size:100" json:"status_changed_by,omitempty"`
+	// Granted through the roles endpoint by an admin, created users have none and updates and patches keep them.
+	Roles       Roles          `gorm:"type:text;not null;default:'[]'" json:"roles" validate:"max=10,unique,dive,role_name" swaggertype:"array,string"`
+	CreatedDate time.Time      `json:"created_date"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at" swaggertype:"string" format:"date-time"`
+	Version     uint           `gorm:"not null;default:1" json:"version"`
 }
 
+type UserStatus string
+
+const (
+	UserActive      UserStatus = "active"
+	UserSuspended   UserStatus = "suspended"
+	UserPending     UserStatus = "pending"
+	UserDeactivated UserStatus = "deactivated"
+)
+
+func (s UserStatus) Valid() bool {
+	switch s {
+	case UserActive, UserSuspended, UserPending, UserDeactivated:
+		return true
+	default:
+		return false
+	}
+}
+
+// Initial tells whether a user may be created with the status, the others are only reached through a transition.
+func (s UserStatus) Initial() bool {
+	return s == UserActive || s == UserPending
+}
+
+// Roles are kept as a JSON array in a text column, so they work the same on Postgres and SQLite.
+type Roles []string
+
+func (r Roles) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	value, err := json.Marshal([]string(r))
+	return string(value), err
+}
+
+func (r *Roles) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*r = Roles{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	case []byte:
+		return json.Unmarshal(v, r)
+	default:
+		return errors.New("roles must be stored as text")
+	}
+}
+
+// RolesChange replaces all roles of a user.
+type RolesChange struct {
+	Roles Roles `json:"roles" swaggertype:"array,string"`
+}
+
+// NormalizeEmail lower-cases the email, two emails that only differ in case belong to the same user.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(email)
+}
+
+// PrepareNewUser fills the server side fields of a user about to be created.
+func PrepareNewUser(user User, now time.Time) User {
+	user.UUID = uuid.New()
+	user.Email = NormalizeEmail(user.Email)
+	if user.Status == "" {
+		user.Status = UserActive
+	}
+	// Only status transitions record why and by whom the status was set.
+	user.StatusReason, user.StatusChangedAt, user.StatusChangedBy = "", nil, ""
+	user.Roles = Roles{}
+	user.CreatedDate = now
+	user.UpdatedAt = now
+	return user
+}
+
+// AnyVersion skips the optimistic concurrency check, it is used for "If-Match: *".
+const AnyVersion uint = 0
+
 type UserUseCase interface {
-	CreateUser(user User) (User, *AppError)
-	GetUserById(id uint) (User, *AppError)
-	UpdateUser(user User) (User, *AppError)
-	DeleteUserById(id uint) *AppError
+	CreateUser(ctx context.Context, user User) (User, *AppError)
+	GetUserById(ctx context.Context, id uint) (User, *AppError)
+	GetUserByIdIncludingDeleted(ctx context.Context, id uint) (User, *AppError)
+	// ResolveUserId returns the ID of the user with the given UUID, soft deleted users included.
+	ResolveUserId(ctx context.Context, userUUID uuid.UUID) (uint, *AppError)
+	UpdateUser(ctx context.Context, user User) (User, *AppError)
+	PatchUser(ctx context.Context, id uint, version uint, patchType PatchType, patch []byte) (User, *AppError)
+	DeleteUserById(ctx context.Context, id uint, version uint) *AppError
+	RestoreUserById(ctx context.Context, id uint) (User, *AppError)
+	// SetUserRoles replaces the roles of the user, it is the only way to change them.
+	SetUserRoles(ctx context.Context, id uint, version uint, roles Roles) (User, *AppError)
+	// TransitionUser moves the user to the status of the transition, if the current status allows it.
+	TransitionUser(ctx context.Context, id uint, transition UserTransition, change StatusChange) (User, *AppError)
+	// GetUserHistory returns a page of the audit trail of the user, newest first.
+	GetUserHistory(ctx context.Context, id uint, query HistoryQuery) (UserHistory, *AppError)
+	BatchUsers(ctx context.Context, request BatchRequest) BatchResponse
+	ExportUsers(ctx context.Context, filter UserFilter, format ExportFormat, w io.Writer) *AppError
+	ImportUsers(ctx context.Context, format ImportFormat, r io.Reader, dryRun bool) ImportReport
+	// StartImportJob imports in the background and closes r when done, the returned report holds the job ID.
+	StartImportJob(ctx context.Context, format ImportFormat, r io.ReadCloser, dryRun bool) ImportReport
+	GetImportJob(ctx context.Context, id string) (ImportReport, *AppError)
 }
 
 type UserRepository interface {
-	CreateUser(user User) (User, *AppError)
-	GetUserById(id uint) (User, *AppError)
-	UpdateUser(user User) (User, *AppError)
-	DeleteUserById(id uint) *AppError
+	CreateUser(ctx context.Context, user User) (User, *AppError)
+	GetUserById(ctx context.Context, id uint) (User, *AppError)
+	GetUserByIdIncludingDeleted(ctx context.Context, id uint) (User, *AppError)
+	// GetUserIdByUUID looks up soft deleted users too, so they can still be restored by UUID.
+	GetUserIdByUUID(ctx context.Context, userUUID uuid.UUID) (uint, *AppError)
+	UpdateUser(ctx context.Context, user User) (User, *AppError)
+	DeleteUserById(ctx context.Context, id uint, version uint) *AppError
+	RestoreUserById(ctx context.Context, id uint) *AppError
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, *AppError)
+	CreateUsers(ctx context.Context, users []User) ([]User, *AppError)
+	// StreamUsers calls fn for every user matching the filter, one row at a time, and stops at the first error fn returns.
+	StreamUsers(ctx context.Context, filter UserFilter, fn func(user User) error) *AppError
 }
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"time"
+)
+
+// UserFilter narrows down queries over many users, zero values are ignored.
+type UserFilter struct {
+	Name           string     `form:"name"`
+	MinAge         int        `form:"min_age"`
+	MaxAge         int        `form:"max_age"`
+	Status         UserStatus `form:"status"`
+	CreatedAfter   time.Time  `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore  time.Time  `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	IncludeDeleted bool       `form:"include_deleted"`
+}
+
+func (f UserFilter) Validate() *AppError {
+	if f.MinAge < 0 || f.MaxAge < 0 {
+		return NewBadRequestError("Age filters must not be negative.")
+	}
+	if f.Status != "" && !f.Status.Valid() {
+		return NewBadRequestError("Invalid status: " + string(f.Status))
+	}
+	if f.MaxAge != 0 && f.MinAge > f.MaxAge {
+		return NewBadRequestError("min_age must not be greater than max_age.")
+	}
+	if !f.CreatedAfter.IsZero() && !f.CreatedBefore.IsZero() && f.CreatedAfter.After(f.CreatedBefore) {
+		return NewBadRequestError("created_after must not be later than created_before.")
+	}
+	return nil
+}
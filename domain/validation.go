@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+type Operation string
+
+const (
+	OperationCreate Operation = "create"
+	OperationUpdate Operation = "update"
+	OperationPatch  Operation = "patch"
+)
+
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+var (
+	validate      = newValidator()
+	nameCharRegex = regexp.MustCompile(`^[\p{L}\p{N} .'_-]+$`)
+	roleNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+	scopeRegex    = regexp.MustCompile(`^[A-Za-z0-9:._/-]+$`)
+
+	// Fields that must be set for each operation. Patch only validates the fields it receives.
+	requiredUserFields = map[Operation][]string{
+		OperationCreate: {"Name", "Age"},
+		OperationUpdate: {"ID", "Name", "Age"},
+	}
+)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+	_ = v.RegisterValidation("name_chars", func(fl validator.FieldLevel) bool {
+		return nameCharRegex.MatchString(fl.Field().String())
+	})
+	_ = v.RegisterValidation("role_name", func(fl validator.FieldLevel) bool {
+		return roleNameRegex.MatchString(fl.Field().String())
+	})
+	_ = v.RegisterValidation("scope", func(fl validator.FieldLevel) bool {
+		return scopeRegex.MatchString(fl.Field().String())
+	})
+	return v
+}
+
+/*
+ValidateUser checks the user against the rules declared on domain.User.
+Fields are the Go field names to validate on a patch; create and update always validate the whole user.
+*/
+func ValidateUser(user User, operation Operation, fields ...string) *AppError {
+	var violations []FieldViolation
+
+	for _, field := range requiredUserFields[operation] {
+		value := reflect.ValueOf(user).FieldByName(field)
+		if value.IsZero() {
+			violations = append(violations, FieldViolation{Field: jsonFieldName(field), Rule: "required", Message: "is required"})
+		}
+	}
+
+	var err error
+	if operation == OperationPatch {
+		err = validate.StructPartial(user, fields...)
+	} else {
+		err = validate.Struct(user)
+	}
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fieldErr := range validationErrors {
+			if hasViolation(violations, fieldErr.Field()) {
+				continue
+			}
+			violations = append(violations, FieldViolation{Field: fieldErr.Field(), Rule: fieldErr.Tag(), Message: violationMessage(fieldErr)})
+		}
+	} else if err != nil {
+		return NewUnexpectedError(err.Error()).WithCause(err)
+	}
+
+	if operation == OperationCreate && user.Status.Valid() && !user.Status.Initial() {
+		violations = append(violations, FieldViolation{Field: "status", Rule: "initial_status", Message: "must be one of: active pending"})
+	}
+	if len(violations) > 0 {
+		return NewValidationError("Validation failed.").WithDetails(violations)
+	}
+	return nil
+}
+
+func jsonFieldName(field string) string {
+	structField, ok := reflect.TypeOf(User{}).FieldByName(field)
+	if !ok {
+		return field
+	}
+	return strings.SplitN(structField.Tag.Get("json"), ",", 2)[0]
+}
+
+func hasViolation(violations []FieldViolation, field string) bool {
+	for _, violation := range violations {
+		if violation.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func violationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be greater than or equal to %s", fieldErr.Param())
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fieldErr.Param())
+		}
+		if fieldErr.Kind() == reflect.Slice {
+			return fmt.Sprintf("must have at most %s items", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be less than or equal to %s", fieldErr.Param())
+	case "required":
+		return "is required"
+	case "http_url":
+		return "must be an http or https URL"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fieldErr.Param())
+	case "email":
+		return "must be a valid email address"
+	case "unique":
+		return "must not contain duplicates"
+	case "role_name":
+		return "must be 2 to 32 lower case letters, digits, _ or -, starting with a letter"
+	case "scope":
+		return "may only contain letters, digits and : . _ / -"
+	case "name_chars":
+		return "may only contain letters, digits, spaces and . ' _ -"
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fieldErr.Tag())
+	}
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_Should_Validate_User_On_Create(t *testing.T) {
+	assert.Nil(t, ValidateUser(User{Name: "John Doe", Age: 30, Email: "john@example.com"}, OperationCreate))
+
+	err := ValidateUser(User{Name: "J", Age: 151, Email: "john@example.com"}, OperationCreate)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, ErrCodeValidationFailed, err.Code)
+	assert.Equal(t, []FieldViolation{
+		{Field: "name", Rule: "min", Message: "must be at least 2 characters long"},
+		{Field: "age", Rule: "max", Message: "must be less than or equal to 150"},
+	}, err.Details)
+}
+
+func Test_Should_Reject_Invalid_Name_Characters(t *testing.T) {
+	err := ValidateUser(User{Name: "<script>", Age: 30, Email: "john@example.com"}, OperationCreate)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "name_chars", err.Details.([]FieldViolation)[0].Rule)
+}
+
+func Test_Should_Require_Id_On_Update(t *testing.T) {
+	err := ValidateUser(User{Name: "John Doe", Age: 30, Email: "john@example.com"}, OperationUpdate)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []FieldViolation{{Field: "id", Rule: "required", Message: "is required"}}, err.Details)
+}
+
+func Test_Should_Validate_Email_Status_And_Roles(t *testing.T) {
+	assert.Nil(t, ValidateUser(User{Name: "John Doe", Age: 30, Email: "john@example.com", Status: UserPending, Roles: Roles{"admin", "billing-viewer"}}, OperationCreate))
+
+	err := ValidateUser(User{Name: "John Doe", Age: 30, Email: "john", Status: "banned", Roles: Roles{"Admin"}}, OperationCreate)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, []FieldViolation{
+		{Field: "email", Rule: "email", Message: "must be a valid email address"},
+		{Field: "status", Rule: "oneof", Message: "must be one of: active suspended pending deactivated"},
+		{Field: "roles[0]", Rule: "role_name", Message: "must be 2 to 32 lower case letters, digits, _ or -, starting with a letter"},
+	}, err.Details)
+}
+
+func Test_Should_Only_Create_Active_Or_Pending_Users(t *testing.T) {
+	for _, status := range []UserStatus{"", UserActive, UserPending} {
+		assert.Nil(t, ValidateUser(User{Name: "John Doe", Age: 30, Status: status}, OperationCreate), status)
+	}
+
+	for _, status := range []UserStatus{UserSuspended, UserDeactivated} {
+		err := ValidateUser(User{Name: "John Doe", Age: 30, Status: status}, OperationCreate)
+
+		assert.NotNil(t, err, status)
+		assert.Equal(t, []FieldViolation{{Field: "status", Rule: "initial_status", Message: "must be one of: active pending"}}, err.Details, status)
+	}
+	assert.Nil(t, ValidateUser(User{ID: 1, Name: "John Doe", Age: 30, Status: UserSuspended}, OperationUpdate))
+}
+
+func Test_Should_Accept_User_Without_Email(t *testing.T) {
+	assert.Nil(t, ValidateUser(User{Name: "John Doe", Age: 30}, OperationCreate))
+	assert.Nil(t, ValidateUser(User{ID: 1, Name: "John Doe", Age: 30}, OperationUpdate))
+}
+
+func Test_Should_Validate_Only_Given_Fields_On_Patch(t *testing.T) {
+	assert.Nil(t, ValidateUser(User{Name: "John Doe"}, OperationPatch, "Name"))
+
+	err := ValidateUser(User{Age: 200}, OperationPatch, "Age")
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "age", err.Details.([]FieldViolation)[0].Field)
+}
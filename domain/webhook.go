@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"time"
+)
+
+// ScopeWebhooksAdmin allows to manage webhook subscriptions and their deliveries, the endpoints are absent while authentication is off.
+const ScopeWebhooksAdmin = "webhooks:admin"
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending    DeliveryStatus = "pending"
+	DeliverySucceeded  DeliveryStatus = "succeeded"
+	DeliveryDeadLetter DeliveryStatus = "dead_letter"
+)
+
+// WebhookSubscription receives the user events of the listed types. The secret is only returned when the subscription is created.
+type WebhookSubscription struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	URL        string      `gorm:"not null" json:"url"`
+	EventTypes []EventType `gorm:"serializer:json;not null" json:"event_types"`
+	Secret     string      `gorm:"not null" json:"secret,omitempty"`
+	Active     bool        `gorm:"not null" json:"active"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+func (s WebhookSubscription) Subscribes(eventType EventType) bool {
+	for _, subscribed := range s.EventTypes {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscriptionRequest creates or replaces a subscription, a secret is generated when none is given.
+type WebhookSubscriptionRequest struct {
+	URL        string      `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []EventType `json:"event_types" validate:"required,min=1,dive,oneof=user.created user.updated user.deleted"`
+	Secret     string      `json:"secret" validate:"omitempty,min=16,max=256"`
+	Active     *bool       `json:"active"`
+}
+
+func (r WebhookSubscriptionRequest) Validate() *AppError {
+	err := validate.Struct(r)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		violations := make([]FieldViolation, 0, len(validationErrors))
+		for _, fieldErr := range validationErrors {
+			violations = append(violations, FieldViolation{Field: fieldErr.Field(), Rule: fieldErr.Tag(), Message: violationMessage(fieldErr)})
+		}
+		return NewValidationError("Validation failed.").WithDetails(violations)
+	}
+	if err != nil {
+		return NewUnexpectedError(err.Error()).WithCause(err)
+	}
+	return nil
+}
+
+/*
+WebhookDelivery is one event sent to one subscription. Failed deliveries are retried with backoff
+until the attempts are used up, then they stay in the dead letter state until redelivered by hand.
+*/
+type WebhookDelivery struct {
+	ID             uint64          `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint            `gorm:"uniqueIndex:idx_webhook_delivery_event;not null" json:"subscription_id"`
+	EventID        string          `gorm:"uniqueIndex:idx_webhook_delivery_event;not null" json:"event_id"`
+	EventType      EventType       `gorm:"not null" json:"event_type"`
+	Payload        json.RawMessage `gorm:"not null" json:"payload" swaggertype:"object"`
+	Status         DeliveryStatus  `gorm:"index;not null" json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `gorm:"index" json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+type WebhookUseCase interface {
+	CreateSubscription(ctx context.Context, request WebhookSubscriptionRequest) (WebhookSubscription, *AppError)
+	GetSubscription(ctx context.Context, id uint) (WebhookSubscription, *AppError)
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, *AppError)
+	UpdateSubscription(ctx context.Context, id uint, request WebhookSubscriptionRequest) (WebhookSubscription, *AppError)
+	DeleteSubscription(ctx context.Context, id uint) *AppError
+	// ListDeliveries returns the newest deliveries of a subscription first, status is optional.
+	ListDeliveries(ctx context.Context, subscriptionID uint, status DeliveryStatus, limit int) ([]WebhookDelivery, *AppError)
+	// Redeliver queues a delivery again with fresh attempts, whatever its status.
+	Redeliver(ctx context.Context, subscriptionID uint, deliveryID uint64) (WebhookDelivery, *AppError)
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription WebhookSubscription) (WebhookSubscription, *AppError)
+	GetSubscription(ctx context.Context, id uint) (WebhookSubscription, *AppError)
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, *AppError)
+	UpdateSubscription(ctx context.Context, subscription WebhookSubscription) (WebhookSubscription, *AppError)
+	// DeleteSubscription also deletes the deliveries of the subscription.
+	DeleteSubscription(ctx context.Context, id uint) *AppError
+	ListActiveSubscriptions(ctx context.Context) ([]WebhookSubscription, *AppError)
+	// AddDeliveries ignores deliveries already stored for the same subscription and event.
+	AddDeliveries(ctx context.Context, deliveries ...WebhookDelivery) *AppError
+	// FetchDueDeliveries locks the pending deliveries due now, rows locked by another instance are skipped.
+	FetchDueDeliveries(ctx context.Context, limit int) ([]WebhookDelivery, *AppError)
+	GetDelivery(ctx context.Context, subscriptionID uint, deliveryID uint64) (WebhookDelivery, *AppError)
+	ListDeliveries(ctx context.Context, subscriptionID uint, status DeliveryStatus, limit int) ([]WebhookDelivery, *AppError)
+	UpdateDelivery(ctx context.Context, delivery WebhookDelivery) *AppError
+}
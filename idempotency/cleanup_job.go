@@ -0,0 +1,40 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"time"
+)
+
+// CleanupJob removes expired idempotency keys, expired keys are already ignored by the middleware.
+type CleanupJob struct {
+	repo     domain.IdempotencyRepository
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+func NewCleanupJob(repo domain.IdempotencyRepository, logger *zap.Logger, interval time.Duration) *CleanupJob {
+	return &CleanupJob{repo: repo, logger: logger, interval: interval}
+}
+
+func (j *CleanupJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.logger.Info("Idempotency key cleanup job stopped.")
+			return
+		case <-ticker.C:
+			deleted, err := j.repo.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				j.logger.Error(err.Message, zap.Error(err))
+				continue
+			}
+			j.logger.Info(fmt.Sprintf("Idempotency key cleanup completed. Deleted: %d", deleted))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"go-app/database"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks/mockIdempotencyRepository.go -package=mocks go-app/domain IdempotencyRepository
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) domain.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record domain.IdempotencyRecord) (bool, *domain.AppError) {
+	// An expired record is taken over, a live one is left untouched.
+	result := database.Conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fingerprint", "completed", "response_status", "response_content_type", "response_body", "created_at", "expires_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Lt{Column: clause.Column{Table: "idempotency_keys", Name: "expires_at"}, Value: record.CreatedAt}}},
+	}).Create(&record)
+	if result.Error != nil {
+		return false, database.TranslateError(result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *idempotencyRepository) GetByKey(ctx context.Context, key string) (domain.IdempotencyRecord, *domain.AppError) {
+	var record domain.IdempotencyRecord
+	err := database.Conn(ctx, r.db).Where("key = ?", key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return record, domain.NewNotFoundError("Idempotency key not found.")
+	}
+	if err != nil {
+		return record, database.TranslateError(err)
+	}
+	return record, nil
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, record domain.IdempotencyRecord) *domain.AppError {
+	err := database.Conn(ctx, r.db).Model(&record).Select("completed", "response_status", "response_content_type", "response_body").
+		Updates(domain.IdempotencyRecord{
+			Completed:           true,
+			ResponseStatus:      record.ResponseStatus,
+			ResponseContentType: record.ResponseContentType,
+			ResponseBody:        record.ResponseBody,
+		}).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteByKey(ctx context.Context, key string) *domain.AppError {
+	if err := database.Conn(ctx, r.db).Where("key = ?", key).Delete(&domain.IdempotencyRecord{}).Error; err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, *domain.AppError) {
+	result := database.Conn(ctx, r.db).Where("expires_at < ?", now).Delete(&domain.IdempotencyRecord{})
+	if result.Error != nil {
+		return 0, database.TranslateError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -8,9 +8,15 @@ import (
 type BodyLogWriter struct {
 	gin.ResponseWriter
 	Body *bytes.Buffer
+	// Limit caps the number of bytes kept in Body, 0 keeps everything.
+	Limit int
 }
 
 func (w BodyLogWriter) Write(b []byte) (int, error) {
-	w.Body.Write(b)
+	if w.Limit == 0 {
+		w.Body.Write(b)
+	} else if remaining := w.Limit - w.Body.Len(); remaining > 0 {
+		w.Body.Write(b[:min(len(b), remaining)])
+	}
 	return w.ResponseWriter.Write(b)
 }
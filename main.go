@@ -3,17 +3,9 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
-	"github.com/gin-gonic/gin"
-	"github.com/newrelic/go-agent/v3/newrelic"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"go-app/app"
 	"go-app/config"
-	"go-app/database"
-	"go-app/docs"
-	"go-app/middleware"
-	"go-app/user"
+	"log"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,8 +13,6 @@ import (
 	"time"
 )
 
-var logger = config.ZapTestConfig()
-
 // @title           Go Monitoring App
 // @version         1.0
 // @description     Go HTTP server with Gin framework.
@@ -30,46 +20,46 @@ var logger = config.ZapTestConfig()
 // @BasePath  /
 func main() {
 
-	// Postgres Config & Migration
-	db := config.ConnectPostgres()
-	database.Migrate(db)
+	// Config & Application Container
+	cfg, err := config.Load(context.Background())
+	if err != nil {
+		log.Fatalf("config: %s", err)
+	}
+	application, err := app.New(context.Background(), cfg)
+	if err != nil {
+		log.Fatalf("startup: %s", err)
+	}
+	logger := application.Logger
 	defer func() {
-		logger.Info("DB connection closing...")
-		dbInstance, _ := db.DB()
-		_ = dbInstance.Close()
+		if err := application.Close(); err != nil {
+			logger.Error("Shutdown failed: " + err.Error())
+		}
 	}()
 
-	// Sentry Config, New Relic Config & Zap Config
-	config.SentryConfig()
-	newRelicConfig := config.NewRelicConfig()
-	logger = config.ZapConfig(newRelicConfig)
+	// Background Jobs
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	application.Start(jobCtx)
 
-	// User Repository, User UseCase & User Handler
-	userRepo := user.NewUserRepository(db)
-	userUseCase := user.NewUserUseCase(userRepo, logger)
-	userHandler := user.NewUserHandler(userUseCase, logger)
-
-	// Setup Router
-	router := setupRouter(newRelicConfig, userHandler)
-
-	srv := &http.Server{Addr: ":8080", Handler: router}
+	srv := &http.Server{Addr: ":8080", Handler: application.Router}
 
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			logger.Fatal(fmt.Sprintf("listen: %s\n", err))
+			logger.Fatal("listen: " + err.Error())
 		}
 	}()
 
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Shutdown Server ...")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		logger.Fatal(fmt.Sprintf("Server Shutdown: %s\n", err))
+		logger.Fatal("Server Shutdown: " + err.Error())
 	}
 
 	select {
@@ -78,29 +68,3 @@ func main() {
 	}
 	logger.Info("Server exiting")
 }
-
-func setupRouter(newRelicConfig *newrelic.Application, handler *user.Handler) *gin.Engine {
-	router := gin.Default()
-
-	// Swagger => http://localhost:8080/swagger/index.html
-	docs.SwaggerInfo.BasePath = "/"
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// Middlewares
-	_middleware := middleware.NewMiddleware(newRelicConfig, logger)
-	router.Use(_middleware.NewRelicMiddleWare())
-	router.Use(_middleware.SentryMiddleware())
-	router.Use(_middleware.LogMiddleware)
-
-	// Prometheus Metrics
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
-
-	// Endpoints
-	v1 := router.Group("/api/v1/users")
-	v1.POST("", handler.CreateUser)
-	v1.GET("/:id", handler.GetUserById)
-	v1.PUT("", handler.UpdateUser)
-	v1.DELETE("/:id", handler.DeleteUserById)
-
-	return router
-}
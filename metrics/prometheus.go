@@ -21,9 +21,269 @@ var (
 		},
 		[]string{"path"},
 	)
+
+	// PROMQL => histogram_quantile(0.95, sum(rate(db_query_duration_seconds_bucket{}[5m])) by (le, operation, table))
+	DbQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "db_query_duration_seconds",
+			Help: "Duration of database queries.",
+		},
+		[]string{"operation", "table"},
+	)
+
+	DbTransactionRetries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "db_transaction_retries_total",
+			Help: "Number of transactions retried after a serialization failure or deadlock.",
+		},
+	)
+
+	// PROMQL => sum(rate(user_cache_requests_total{result="hit"}[5m])) / sum(rate(user_cache_requests_total{}[5m]))
+	UserCacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_cache_requests_total",
+			Help: "Number of user lookups by ID answered by the cache (hit) or the database (miss).",
+		},
+		[]string{"result"},
+	)
+
+	UserCacheInvalidations = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "user_cache_invalidations_total",
+			Help: "Number of cached users removed after a write.",
+		},
+	)
+
+	CacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "Number of cache entries dropped by the cache itself, because it was full or the entry expired.",
+		},
+		[]string{"cache", "reason"},
+	)
+
+	DbQueryRetries = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "db_query_retries_total",
+			Help: "Number of database statements retried after a transient error.",
+		},
+	)
+
+	DbCircuitBreakerOpen = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "db_circuit_breaker_open",
+			Help: "1 while the database circuit breaker fails statements without trying them, 0 otherwise.",
+		},
+	)
+
+	DbUp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "db_up",
+			Help: "1 when the database answered its last ping, 0 otherwise.",
+		},
+	)
+
+	DbOpenConnections = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_open_connections",
+			Help: "Number of open database connections by state.",
+		},
+		[]string{"state"},
+	)
+
+	DbReplicaHealthy = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "db_replica_healthy",
+			Help: "1 when the read replica answered its last health check, 0 otherwise.",
+		},
+		[]string{"replica"},
+	)
+
+	UserPurgeRuns = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_purge_runs_total",
+			Help: "Number of soft deleted user purge runs by status.",
+		},
+		[]string{"status"},
+	)
+
+	UserPurgedCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "user_purged_total",
+			Help: "Number of soft deleted users removed permanently.",
+		},
+	)
+
+	UserPurgeDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name: "user_purge_duration_seconds",
+			Help: "Duration of soft deleted user purge runs.",
+		},
+	)
+
+	UserPurgeLastSuccess = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "user_purge_last_success_timestamp_seconds",
+			Help: "Unix time of the last successful soft deleted user purge run.",
+		},
+	)
+	// PROMQL => histogram_quantile(0.95, sum(rate(user_batch_duration_seconds_bucket{}[5m])) by (le, operation, mode))
+	UserBatchDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "user_batch_duration_seconds",
+			Help: "Duration of user batch requests.",
+		},
+		[]string{"operation", "mode"},
+	)
+
+	UserBatchItems = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_batch_items_total",
+			Help: "Number of user batch items by operation and result.",
+		},
+		[]string{"operation", "result"},
+	)
+	// PROMQL => rate(user_export_rows_total{}[1m])
+	UserExportRows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_export_rows_total",
+			Help: "Number of users written by exports.",
+		},
+		[]string{"format"},
+	)
+
+	UserExportsInProgress = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "user_exports_in_progress",
+			Help: "Number of user exports currently streaming.",
+		},
+	)
+
+	UserExportDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "user_export_duration_seconds",
+			Help:    "Duration of user exports.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+		},
+		[]string{"format", "status"},
+	)
+	UserImportRows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_import_rows_total",
+			Help: "Number of imported user rows by result.",
+		},
+		[]string{"result"},
+	)
+
+	// PROMQL => sum(rate(user_status_transitions_total{result="rejected"}[5m])) by (transition)
+	UserStatusTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "user_status_transitions_total",
+			Help: "Number of user status transitions by transition, source status and result: applied or rejected.",
+		},
+		[]string{"transition", "from", "result"},
+	)
+	AuthJWKSRefreshes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_jwks_refreshes_total",
+			Help: "Number of JWKS fetches from the identity provider by result: succeeded or failed.",
+		},
+		[]string{"result"},
+	)
+	AuthAPIKeyAuthentications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "auth_api_key_authentications_total",
+			Help: "Number of API key authentications by result: succeeded, invalid, expired or revoked.",
+		},
+		[]string{"result"},
+	)
+	OutboxEventsPublished = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_published_total",
+			Help: "Number of outbox events delivered to every sink, by event type.",
+		},
+		[]string{"type"},
+	)
+
+	OutboxDeliveryFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_delivery_failures_total",
+			Help: "Number of failed outbox event deliveries by sink.",
+		},
+		[]string{"sink"},
+	)
+
+	OutboxEventsDeadLettered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "outbox_events_dead_lettered_total",
+			Help: "Number of outbox events given up on after the maximum attempts, by event type.",
+		},
+		[]string{"type"},
+	)
+
+	WebhookDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "webhook_deliveries_total",
+			Help: "Number of webhook delivery attempts by result: succeeded, failed or dead_letter.",
+		},
+		[]string{"result"},
+	)
+
+	WebhookDeliveryDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "webhook_delivery_duration_seconds",
+			Help:    "Duration of webhook delivery attempts.",
+			Buckets: prometheus.DefBuckets,
+		},
+	)
+
+	UserEventSubscribers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "user_event_subscribers",
+			Help: "Number of connected user event stream subscribers.",
+		},
+	)
+
+	// Age of the oldest undelivered event, 0 when the outbox is drained.
+	OutboxLag = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "outbox_lag_seconds",
+			Help: "Age of the oldest pending outbox event.",
+		},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(HttpRequestCountWithPath)
 	prometheus.MustRegister(HttpRequestDuration)
+	prometheus.MustRegister(DbQueryDuration)
+	prometheus.MustRegister(DbTransactionRetries)
+	prometheus.MustRegister(DbQueryRetries)
+	prometheus.MustRegister(DbCircuitBreakerOpen)
+	prometheus.MustRegister(DbUp)
+	prometheus.MustRegister(DbOpenConnections)
+	prometheus.MustRegister(DbReplicaHealthy)
+	prometheus.MustRegister(UserCacheRequests)
+	prometheus.MustRegister(UserCacheInvalidations)
+	prometheus.MustRegister(CacheEvictions)
+	prometheus.MustRegister(UserPurgeRuns)
+	prometheus.MustRegister(UserPurgedCount)
+	prometheus.MustRegister(UserPurgeDuration)
+	prometheus.MustRegister(UserPurgeLastSuccess)
+	prometheus.MustRegister(UserBatchDuration)
+	prometheus.MustRegister(UserBatchItems)
+	prometheus.MustRegister(UserExportRows)
+	prometheus.MustRegister(UserExportsInProgress)
+	prometheus.MustRegister(UserExportDuration)
+	prometheus.MustRegister(UserImportRows)
+	prometheus.MustRegister(UserStatusTransitions)
+	prometheus.MustRegister(AuthJWKSRefreshes)
+	prometheus.MustRegister(AuthAPIKeyAuthentications)
+	prometheus.MustRegister(OutboxEventsPublished)
+	prometheus.MustRegister(OutboxDeliveryFailures)
+	prometheus.MustRegister(OutboxEventsDeadLettered)
+	prometheus.MustRegister(OutboxLag)
+	prometheus.MustRegister(WebhookDeliveries)
+	prometheus.MustRegister(WebhookDeliveryDuration)
+	prometheus.MustRegister(UserEventSubscribers)
 }
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/getsentry/sentry-go"
+	sentrygin "github.com/getsentry/sentry-go/gin"
+	"github.com/gin-gonic/gin"
+	"github.com/newrelic/go-agent/v3/integrations/nrgin"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"strings"
+)
+
+// APIKeyHeader carries an API key, the Authorization header with the ApiKey scheme is accepted as well.
+const APIKeyHeader = "X-API-Key"
+
+/*
+AuthMiddleware rejects requests without a valid bearer token or API key with 401, either may be nil to turn it off.
+The principal is put in the request context and its subject tags the log, Sentry and New Relic.
+*/
+func (m middleware) AuthMiddleware(verifier domain.TokenVerifier, apiKeys domain.APIKeyAuthenticator) gin.HandlerFunc {
+	var schemes []string
+	if verifier != nil {
+		schemes = append(schemes, "Bearer")
+	}
+	if apiKeys != nil {
+		schemes = append(schemes, "ApiKey")
+	}
+
+	return func(ctx *gin.Context) {
+		var scheme, credentials string
+		if apiKeys != nil {
+			scheme, credentials = "ApiKey", strings.TrimSpace(ctx.GetHeader(APIKeyHeader))
+		}
+		if credentials == "" {
+			scheme, credentials, _ = strings.Cut(ctx.GetHeader("Authorization"), " ")
+			credentials = strings.TrimSpace(credentials)
+		}
+
+		var principal domain.Principal
+		var err *domain.AppError
+		switch {
+		case credentials == "":
+			err = domain.NewUnauthorizedError(fmt.Sprintf("Credentials are required, one of: %s.", strings.Join(schemes, ", ")))
+		case verifier != nil && strings.EqualFold(scheme, "Bearer"):
+			principal, err = verifier.Verify(ctx.Request.Context(), credentials)
+		case apiKeys != nil && strings.EqualFold(scheme, "ApiKey"):
+			principal, err = apiKeys.Authenticate(ctx.Request.Context(), credentials)
+		default:
+			err = domain.NewUnauthorizedError(fmt.Sprintf("Unsupported authorization scheme, one of: %s.", strings.Join(schemes, ", ")))
+		}
+		if err != nil {
+			if credentials != "" {
+				m.logger.Warn(err.Message, zap.Error(err))
+			}
+			for _, challenge := range schemes {
+				if credentials != "" && strings.EqualFold(scheme, challenge) {
+					challenge += ` error="invalid_token"`
+				}
+				ctx.Writer.Header().Add("WWW-Authenticate", challenge)
+			}
+			abortWithError(ctx, err)
+			return
+		}
+
+		requestCtx := domain.WithPrincipal(ctx.Request.Context(), principal)
+		// The authenticated subject replaces the actor a client may name itself.
+		metadata := domain.AuditMetadataFrom(requestCtx)
+		metadata.Actor = principal.Subject
+		ctx.Request = ctx.Request.WithContext(domain.WithAuditMetadata(requestCtx, metadata))
+
+		if hub := sentrygin.GetHubFromContext(ctx); hub != nil {
+			hub.Scope().SetUser(sentry.User{ID: principal.Subject})
+		}
+		if txn := nrgin.Transaction(ctx); txn != nil {
+			txn.AddAttribute("enduser.id", principal.Subject)
+		}
+		ctx.Next()
+	}
+}
+
+// RequireScope rejects authenticated requests whose principal lacks the scope with 403, it has to run after AuthMiddleware.
+func (m middleware) RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, ok := domain.PrincipalFrom(ctx.Request.Context())
+		if !ok || !principal.HasScope(scope) {
+			abortWithError(ctx, domain.NewForbiddenError(fmt.Sprintf("The %s scope is required.", scope)))
+			return
+		}
+		ctx.Next()
+	}
+}
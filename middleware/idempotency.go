@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"go-app/domain"
+	"go-app/logging"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+/*
+IdempotencyMiddleware makes retries of non-idempotent requests safe.
+The first response for an Idempotency-Key is stored and replayed for retries with the same request,
+a different request under the same key gets 422 and a retry while the first one is still running gets 409.
+Keys of authenticated requests belong to the principal, it has to run after AuthMiddleware.
+*/
+func (m middleware) IdempotencyMiddleware(repo domain.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+		if len(key) > 255 {
+			abortWithError(ctx, domain.NewBadRequestError("Idempotency-Key must not be longer than 255 characters."))
+			return
+		}
+
+		now := time.Now()
+		key = scopedIdempotencyKey(ctx, key)
+		record := domain.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: requestFingerprint(ctx),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+
+		reserved, err := repo.Reserve(ctx.Request.Context(), record)
+		if err != nil {
+			m.logger.Error(err.Message, zap.Error(err))
+			abortWithError(ctx, err)
+			return
+		}
+		if !reserved {
+			m.replay(ctx, repo, record)
+			return
+		}
+
+		// Server errors, panics and responses that could not be stored are not replayed, the key is released so the
+		// client can retry with it. A panic keeps unwinding after the deferred release, up to the recovery middleware.
+		// The handler has run by then, a client that went away must not keep its key reserved.
+		storeCtx := context.WithoutCancel(ctx.Request.Context())
+		defer func() {
+			if !record.Completed {
+				if err := repo.DeleteByKey(storeCtx, key); err != nil {
+					m.logger.Error(err.Message, zap.Error(err))
+				}
+			}
+		}()
+
+		responseBody := logging.HandleResponseBody(ctx.Writer)
+		ctx.Writer = responseBody
+		ctx.Next()
+
+		if ctx.Writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		completed := record
+		completed.Completed = true
+		completed.ResponseStatus = ctx.Writer.Status()
+		completed.ResponseContentType = ctx.Writer.Header().Get("Content-Type")
+		completed.ResponseBody = responseBody.Body.Bytes()
+		if err := repo.Complete(storeCtx, completed); err != nil {
+			m.logger.Error(err.Message, zap.Error(err))
+			return
+		}
+		record = completed
+	}
+}
+
+func (m middleware) replay(ctx *gin.Context, repo domain.IdempotencyRepository, record domain.IdempotencyRecord) {
+	stored, err := repo.GetByKey(ctx.Request.Context(), record.Key)
+	if err != nil {
+		m.logger.Error(err.Message, zap.Error(err))
+		abortWithError(ctx, err)
+		return
+	}
+
+	switch {
+	case stored.Fingerprint != record.Fingerprint:
+		abortWithError(ctx, domain.NewIdempotencyKeyReusedError("Idempotency-Key was already used for a different request."))
+	case !stored.Completed:
+		abortWithError(ctx, domain.NewIdempotencyInProgressError("A request with the same Idempotency-Key is still in progress."))
+	default:
+		ctx.Header("Idempotent-Replayed", "true")
+		ctx.Data(stored.ResponseStatus, stored.ResponseContentType, stored.ResponseBody)
+		ctx.Abort()
+	}
+}
+
+// scopedIdempotencyKey prefixes the key with a hash of the principal, so principals never see or block the responses of each other.
+func scopedIdempotencyKey(ctx *gin.Context, key string) string {
+	principal, ok := domain.PrincipalFrom(ctx.Request.Context())
+	if !ok {
+		return key
+	}
+	scope := sha256.Sum256([]byte(principal.Issuer + "\x00" + principal.Subject))
+	return hex.EncodeToString(scope[:16]) + ":" + key
+}
+
+func requestFingerprint(ctx *gin.Context) string {
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Request.Method))
+	hash.Write([]byte(ctx.Request.URL.RequestURI()))
+	hash.Write([]byte(logging.HandleRequestBody(ctx.Request)))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func abortWithError(ctx *gin.Context, err *domain.AppError) {
+	ctx.Header("Content-Type", domain.ProblemContentType)
+	ctx.AbortWithStatusJSON(err.Status, err.Problem(ctx.Request.URL.Path))
+}
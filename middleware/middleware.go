@@ -8,21 +8,56 @@ import (
 	"github.com/newrelic/go-agent/v3/integrations/nrgin"
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"github.com/prometheus/client_golang/prometheus"
+	"go-app/database"
+	"go-app/domain"
 	"go-app/logging"
 	"go-app/metrics"
 	"go.uber.org/zap"
 	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// ReadYourWritesHeader asks for reads from the primary database, a replica may not have the client's latest write yet.
+const ReadYourWritesHeader = "X-Read-Your-Writes"
+
+const (
+	// RequestIDHeader carries the ID of the request, a client may send its own and gets it back in the response.
+	RequestIDHeader = "X-Request-ID"
+	// ActorHeader names who makes an anonymous request, the audit trail records it unverified. It is ignored once the request is authenticated.
+	ActorHeader = "X-Actor"
+)
+
+// Longest request ID and actor accepted from a client, they are stored in the audit trail.
+const (
+	maxRequestIdLength = 64
+	maxActorLength     = 100
+)
+
+// Response bytes kept for the request log, streamed responses such as exports can be far larger.
+const maxLoggedResponseBody = 64 * 1024
+
 type middleware struct {
 	newRelicConfig *newrelic.Application
 	logger         *zap.Logger
+	// Routes serving event streams, LogMiddleware leaves them out of the request log and the duration metric.
+	eventStreams map[string]bool
 }
 
 func NewMiddleware(newRelicConfig *newrelic.Application, logger *zap.Logger) middleware {
 	return middleware{newRelicConfig: newRelicConfig, logger: logger}
 }
 
+// WithEventStreams returns the middleware with the routes that serve event streams, as registered with the router.
+func (m middleware) WithEventStreams(routes ...string) middleware {
+	m.eventStreams = make(map[string]bool, len(routes))
+	for _, route := range routes {
+		m.eventStreams[route] = true
+	}
+	return m
+}
+
 func (m middleware) NewRelicMiddleWare() gin.HandlerFunc {
 	return nrgin.Middleware(m.newRelicConfig)
 }
@@ -31,6 +66,50 @@ func (m middleware) SentryMiddleware() gin.HandlerFunc {
 	return sentrygin.New(sentrygin.Options{Repanic: true})
 }
 
+// ReadYourWritesMiddleware sends the reads of the request to the primary database when the client asks for it.
+func (m middleware) ReadYourWritesMiddleware(ctx *gin.Context) {
+	if strings.EqualFold(ctx.GetHeader(ReadYourWritesHeader), "true") {
+		ctx.Request = ctx.Request.WithContext(database.WithPrimaryReads(ctx.Request.Context()))
+	}
+	ctx.Next()
+}
+
+/*
+CustomMethodMiddleware answers 404 to a custom method other than the given one, such as "users:purge" for "users:batch".
+gin 1.9 can not escape the colon of a custom method, the route is registered with the param and checked here.
+*/
+func (m middleware) CustomMethodMiddleware(param, method string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Param(param) != ":"+method {
+			abortWithError(ctx, domain.NewNotFoundError("Unknown method: "+strings.TrimPrefix(ctx.Param(param), ":")))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// AuditMiddleware puts the request ID, the actor and the client IP in the request context for the audit trail.
+func (m middleware) AuditMiddleware(ctx *gin.Context) {
+	actor := ctx.GetHeader(ActorHeader)
+	if utf8.RuneCountInString(actor) > maxActorLength {
+		abortWithError(ctx, domain.NewBadRequestError(fmt.Sprintf("%s header must be at most %d characters long.", ActorHeader, maxActorLength)))
+		return
+	}
+
+	requestId := ctx.GetHeader(RequestIDHeader)
+	if requestId == "" || len(requestId) > maxRequestIdLength || strings.ContainsFunc(requestId, unicode.IsControl) {
+		requestId = uuid.NewString()
+	}
+	ctx.Header(RequestIDHeader, requestId)
+
+	ctx.Request = ctx.Request.WithContext(domain.WithAuditMetadata(ctx.Request.Context(), domain.AuditMetadata{
+		Actor:     actor,
+		RequestID: requestId,
+		ClientIP:  ctx.ClientIP(),
+	}))
+	ctx.Next()
+}
+
 /*
 Log all HTTP requests and responses to New Relic.
 Generates a custom count metric for Prometheus. It uses an HTTP request path and an HTTP method.
@@ -38,13 +117,26 @@ Generates a custom count metric for Prometheus. It uses an HTTP request path and
 func (m middleware) LogMiddleware(ctx *gin.Context) {
 	reqMethodAndPath := fmt.Sprintf("[%s] %s", ctx.Request.Method, ctx.FullPath())
 
+	// Event streams stay open for hours, neither their duration nor their body belong in the request metrics and log.
+	if m.eventStreams[ctx.FullPath()] {
+		ctx.Next()
+		metrics.HttpRequestCountWithPath.With(prometheus.Labels{"url": reqMethodAndPath}).Inc()
+		m.logger.Info(fmt.Sprintf("Event stream closed. Status: [%d], Url: %s", ctx.Writer.Status(), ctx.Request.URL.String()))
+		return
+	}
+
 	// HTTP Request Response Duration
 	timer := prometheus.NewTimer(metrics.HttpRequestDuration.WithLabelValues(reqMethodAndPath))
 	defer timer.ObserveDuration()
 
 	var responseBody = logging.HandleResponseBody(ctx.Writer)
+	responseBody.Limit = maxLoggedResponseBody
 	var requestBody = logging.HandleRequestBody(ctx.Request)
-	requestId := uuid.NewString()
+	// Set by AuditMiddleware, so the log and the audit trail share the request ID.
+	requestId := domain.AuditMetadataFrom(ctx.Request.Context()).RequestID
+	if requestId == "" {
+		requestId = uuid.NewString()
+	}
 
 	if hub := sentrygin.GetHubFromContext(ctx); hub != nil {
 		hub.Scope().SetTag("requestId", requestId)
@@ -57,11 +149,15 @@ func (m middleware) LogMiddleware(ctx *gin.Context) {
 	metrics.HttpRequestCountWithPath.With(prometheus.Labels{"url": reqMethodAndPath}).Inc()
 	logMessage := logging.FormatRequestAndResponse(statusCode, ctx.Request, responseBody.Body.String(), requestId, requestBody)
 
+	var fields []zap.Field
+	if principal, ok := domain.PrincipalFrom(ctx.Request.Context()); ok {
+		fields = append(fields, zap.String("subject", principal.Subject))
+	}
 	if logMessage != "" {
 		if isSuccessStatusCode(statusCode) {
-			m.logger.Info(logMessage)
+			m.logger.Info(logMessage, fields...)
 		} else {
-			m.logger.Error(logMessage)
+			m.logger.Error(logMessage, fields...)
 		}
 	}
 }
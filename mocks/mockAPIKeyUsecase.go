@@ -0,0 +1,126 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: go-app/domain (interfaces: APIKeyUseCase)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-app/domain"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAPIKeyUseCase is a mock of APIKeyUseCase interface.
+type MockAPIKeyUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyUseCaseMockRecorder
+}
+
+// MockAPIKeyUseCaseMockRecorder is the mock recorder for MockAPIKeyUseCase.
+type MockAPIKeyUseCaseMockRecorder struct {
+	mock *MockAPIKeyUseCase
+}
+
+// NewMockAPIKeyUseCase creates a new mock instance.
+func NewMockAPIKeyUseCase(ctrl *gomock.Controller) *MockAPIKeyUseCase {
+	mock := &MockAPIKeyUseCase{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyUseCase) EXPECT() *MockAPIKeyUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKeyUseCase) Authenticate(arg0 context.Context, arg1 string) (domain.Principal, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", arg0, arg1)
+	ret0, _ := ret[0].(domain.Principal)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeyUseCaseMockRecorder) Authenticate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKeyUseCase)(nil).Authenticate), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockAPIKeyUseCase) CreateAPIKey(arg0 context.Context, arg1 domain.APIKeyRequest) (domain.APIKey, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(domain.APIKey)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockAPIKeyUseCaseMockRecorder) CreateAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyUseCase)(nil).CreateAPIKey), arg0, arg1)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockAPIKeyUseCase) GetAPIKey(arg0 context.Context, arg1 uint) (domain.APIKey, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(domain.APIKey)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockAPIKeyUseCaseMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockAPIKeyUseCase)(nil).GetAPIKey), arg0, arg1)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockAPIKeyUseCase) ListAPIKeys(arg0 context.Context) ([]domain.APIKey, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", arg0)
+	ret0, _ := ret[0].([]domain.APIKey)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockAPIKeyUseCaseMockRecorder) ListAPIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockAPIKeyUseCase)(nil).ListAPIKeys), arg0)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyUseCase) RevokeAPIKey(arg0 context.Context, arg1 uint) (domain.APIKey, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(domain.APIKey)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyUseCaseMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyUseCase)(nil).RevokeAPIKey), arg0, arg1)
+}
+
+// UpdateAPIKey mocks base method.
+func (m *MockAPIKeyUseCase) UpdateAPIKey(arg0 context.Context, arg1 uint, arg2 domain.APIKeyRequest) (domain.APIKey, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAPIKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(domain.APIKey)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// UpdateAPIKey indicates an expected call of UpdateAPIKey.
+func (mr *MockAPIKeyUseCaseMockRecorder) UpdateAPIKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAPIKey", reflect.TypeOf((*MockAPIKeyUseCase)(nil).UpdateAPIKey), arg0, arg1, arg2)
+}
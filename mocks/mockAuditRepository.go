@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: go-app/domain (interfaces: AuditRepository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-app/domain"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockAuditRepository) Add(arg0 context.Context, arg1 ...domain.AuditEntry) *domain.AppError {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Add", varargs...)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockAuditRepositoryMockRecorder) Add(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockAuditRepository)(nil).Add), varargs...)
+}
+
+// ListByUser mocks base method.
+func (m *MockAuditRepository) ListByUser(arg0 context.Context, arg1 uint, arg2 uint64, arg3 time.Time, arg4 int) ([]domain.AuditEntry, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]domain.AuditEntry)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockAuditRepositoryMockRecorder) ListByUser(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockAuditRepository)(nil).ListByUser), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ListByUserUntil mocks base method.
+func (m *MockAuditRepository) ListByUserUntil(arg0 context.Context, arg1 uint, arg2 time.Time) ([]domain.AuditEntry, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUserUntil", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.AuditEntry)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// ListByUserUntil indicates an expected call of ListByUserUntil.
+func (mr *MockAuditRepositoryMockRecorder) ListByUserUntil(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUserUntil", reflect.TypeOf((*MockAuditRepository)(nil).ListByUserUntil), arg0, arg1, arg2)
+}
@@ -0,0 +1,202 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: go-app/domain (interfaces: OutboxRepository)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	domain "go-app/domain"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOutboxRepository is a mock of OutboxRepository interface.
+type MockOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxRepositoryMockRecorder
+}
+
+// MockOutboxRepositoryMockRecorder is the mock recorder for MockOutboxRepository.
+type MockOutboxRepositoryMockRecorder struct {
+	mock *MockOutboxRepository
+}
+
+// NewMockOutboxRepository creates a new mock instance.
+func NewMockOutboxRepository(ctrl *gomock.Controller) *MockOutboxRepository {
+	mock := &MockOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutboxRepository) EXPECT() *MockOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockOutboxRepository) Add(arg0 context.Context, arg1 ...domain.OutboxEvent) *domain.AppError {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Add", varargs...)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockOutboxRepositoryMockRecorder) Add(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockOutboxRepository)(nil).Add), varargs...)
+}
+
+// DeletePublishedBefore mocks base method.
+func (m *MockOutboxRepository) DeletePublishedBefore(arg0 context.Context, arg1 time.Time) (int64, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublishedBefore", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// DeletePublishedBefore indicates an expected call of DeletePublishedBefore.
+func (mr *MockOutboxRepositoryMockRecorder) DeletePublishedBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublishedBefore", reflect.TypeOf((*MockOutboxRepository)(nil).DeletePublishedBefore), arg0, arg1)
+}
+
+// FetchAfter mocks base method.
+func (m *MockOutboxRepository) FetchAfter(arg0 context.Context, arg1 uint64, arg2 int) ([]domain.OutboxEvent, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchAfter", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]domain.OutboxEvent)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// FetchAfter indicates an expected call of FetchAfter.
+func (mr *MockOutboxRepositoryMockRecorder) FetchAfter(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAfter", reflect.TypeOf((*MockOutboxRepository)(nil).FetchAfter), arg0, arg1, arg2)
+}
+
+// FetchLatest mocks base method.
+func (m *MockOutboxRepository) FetchLatest(arg0 context.Context, arg1 int) ([]domain.OutboxEvent, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLatest", arg0, arg1)
+	ret0, _ := ret[0].([]domain.OutboxEvent)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// FetchLatest indicates an expected call of FetchLatest.
+func (mr *MockOutboxRepositoryMockRecorder) FetchLatest(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLatest", reflect.TypeOf((*MockOutboxRepository)(nil).FetchLatest), arg0, arg1)
+}
+
+// FetchPending mocks base method.
+func (m *MockOutboxRepository) FetchPending(arg0 context.Context, arg1 int) ([]domain.OutboxEvent, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchPending", arg0, arg1)
+	ret0, _ := ret[0].([]domain.OutboxEvent)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// FetchPending indicates an expected call of FetchPending.
+func (mr *MockOutboxRepositoryMockRecorder) FetchPending(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchPending", reflect.TypeOf((*MockOutboxRepository)(nil).FetchPending), arg0, arg1)
+}
+
+// MarkDeadLettered mocks base method.
+func (m *MockOutboxRepository) MarkDeadLettered(arg0 context.Context, arg1 uint64, arg2 int, arg3 time.Time, arg4 string) *domain.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDeadLettered", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// MarkDeadLettered indicates an expected call of MarkDeadLettered.
+func (mr *MockOutboxRepositoryMockRecorder) MarkDeadLettered(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDeadLettered", reflect.TypeOf((*MockOutboxRepository)(nil).MarkDeadLettered), arg0, arg1, arg2, arg3, arg4)
+}
+
+// MarkFailed mocks base method.
+func (m *MockOutboxRepository) MarkFailed(arg0 context.Context, arg1 uint64, arg2 int, arg3 time.Time, arg4 string) *domain.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockOutboxRepositoryMockRecorder) MarkFailed(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockOutboxRepository)(nil).MarkFailed), arg0, arg1, arg2, arg3, arg4)
+}
+
+// MarkPublished mocks base method.
+func (m *MockOutboxRepository) MarkPublished(arg0 context.Context, arg1 uint64, arg2 time.Time) *domain.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPublished", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// MarkPublished indicates an expected call of MarkPublished.
+func (mr *MockOutboxRepositoryMockRecorder) MarkPublished(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPublished", reflect.TypeOf((*MockOutboxRepository)(nil).MarkPublished), arg0, arg1, arg2)
+}
+
+// OldestPendingCreatedAt mocks base method.
+func (m *MockOutboxRepository) OldestPendingCreatedAt(arg0 context.Context) (*time.Time, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OldestPendingCreatedAt", arg0)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// OldestPendingCreatedAt indicates an expected call of OldestPendingCreatedAt.
+func (mr *MockOutboxRepositoryMockRecorder) OldestPendingCreatedAt(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OldestPendingCreatedAt", reflect.TypeOf((*MockOutboxRepository)(nil).OldestPendingCreatedAt), arg0)
+}
+
+// Reschedule mocks base method.
+func (m *MockOutboxRepository) Reschedule(arg0 context.Context, arg1 []uint64, arg2 time.Time) *domain.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reschedule", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// Reschedule indicates an expected call of Reschedule.
+func (mr *MockOutboxRepositoryMockRecorder) Reschedule(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reschedule", reflect.TypeOf((*MockOutboxRepository)(nil).Reschedule), arg0, arg1, arg2)
+}
+
+// TryLock mocks base method.
+func (m *MockOutboxRepository) TryLock(arg0 context.Context) (bool, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryLock", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// TryLock indicates an expected call of TryLock.
+func (mr *MockOutboxRepositoryMockRecorder) TryLock(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLock", reflect.TypeOf((*MockOutboxRepository)(nil).TryLock), arg0)
+}
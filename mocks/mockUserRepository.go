@@ -5,10 +5,13 @@
 package mocks
 
 import (
+	context "context"
 	domain "go-app/domain"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // MockUserRepository is a mock of UserRepository interface.
@@ -35,60 +38,148 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockUserRepository) CreateUser(arg0 domain.User) (domain.User, *domain.AppError) {
+func (m *MockUserRepository) CreateUser(arg0 context.Context, arg1 domain.User) (domain.User, *domain.AppError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0)
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(*domain.AppError)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserRepositoryMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserRepository)(nil).CreateUser), arg0, arg1)
+}
+
+// CreateUsers mocks base method.
+func (m *MockUserRepository) CreateUsers(arg0 context.Context, arg1 []domain.User) ([]domain.User, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUsers", arg0, arg1)
+	ret0, _ := ret[0].([]domain.User)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// CreateUsers indicates an expected call of CreateUsers.
+func (mr *MockUserRepositoryMockRecorder) CreateUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUsers", reflect.TypeOf((*MockUserRepository)(nil).CreateUsers), arg0, arg1)
 }
 
 // DeleteUserById mocks base method.
-func (m *MockUserRepository) DeleteUserById(arg0 uint) *domain.AppError {
+func (m *MockUserRepository) DeleteUserById(arg0 context.Context, arg1, arg2 uint) *domain.AppError {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUserById", arg0)
+	ret := m.ctrl.Call(m, "DeleteUserById", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*domain.AppError)
 	return ret0
 }
 
 // DeleteUserById indicates an expected call of DeleteUserById.
-func (mr *MockUserRepositoryMockRecorder) DeleteUserById(arg0 interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) DeleteUserById(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserById", reflect.TypeOf((*MockUserRepository)(nil).DeleteUserById), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserById", reflect.TypeOf((*MockUserRepository)(nil).DeleteUserById), arg0, arg1, arg2)
 }
 
 // GetUserById mocks base method.
-func (m *MockUserRepository) GetUserById(arg0 uint) (domain.User, *domain.AppError) {
+func (m *MockUserRepository) GetUserById(arg0 context.Context, arg1 uint) (domain.User, *domain.AppError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", arg0)
+	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(*domain.AppError)
 	return ret0, ret1
 }
 
 // GetUserById indicates an expected call of GetUserById.
-func (mr *MockUserRepositoryMockRecorder) GetUserById(arg0 interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserRepository)(nil).GetUserById), arg0, arg1)
+}
+
+// GetUserByIdIncludingDeleted mocks base method.
+func (m *MockUserRepository) GetUserByIdIncludingDeleted(arg0 context.Context, arg1 uint) (domain.User, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdIncludingDeleted", arg0, arg1)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// GetUserByIdIncludingDeleted indicates an expected call of GetUserByIdIncludingDeleted.
+func (mr *MockUserRepositoryMockRecorder) GetUserByIdIncludingDeleted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdIncludingDeleted", reflect.TypeOf((*MockUserRepository)(nil).GetUserByIdIncludingDeleted), arg0, arg1)
+}
+
+// GetUserIdByUUID mocks base method.
+func (m *MockUserRepository) GetUserIdByUUID(arg0 context.Context, arg1 uuid.UUID) (uint, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIdByUUID", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// GetUserIdByUUID indicates an expected call of GetUserIdByUUID.
+func (mr *MockUserRepositoryMockRecorder) GetUserIdByUUID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIdByUUID", reflect.TypeOf((*MockUserRepository)(nil).GetUserIdByUUID), arg0, arg1)
+}
+
+// PurgeDeletedUsers mocks base method.
+func (m *MockUserRepository) PurgeDeletedUsers(arg0 context.Context, arg1 time.Time) (int64, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockUserRepositoryMockRecorder) PurgeDeletedUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockUserRepository)(nil).PurgeDeletedUsers), arg0, arg1)
+}
+
+// RestoreUserById mocks base method.
+func (m *MockUserRepository) RestoreUserById(arg0 context.Context, arg1 uint) *domain.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUserById", arg0, arg1)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// RestoreUserById indicates an expected call of RestoreUserById.
+func (mr *MockUserRepositoryMockRecorder) RestoreUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUserById", reflect.TypeOf((*MockUserRepository)(nil).RestoreUserById), arg0, arg1)
+}
+
+// StreamUsers mocks base method.
+func (m *MockUserRepository) StreamUsers(arg0 context.Context, arg1 domain.UserFilter, arg2 func(domain.User) error) *domain.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// StreamUsers indicates an expected call of StreamUsers.
+func (mr *MockUserRepositoryMockRecorder) StreamUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserRepository)(nil).GetUserById), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamUsers", reflect.TypeOf((*MockUserRepository)(nil).StreamUsers), arg0, arg1, arg2)
 }
 
 // UpdateUser mocks base method.
-func (m *MockUserRepository) UpdateUser(arg0 domain.User) (domain.User, *domain.AppError) {
+func (m *MockUserRepository) UpdateUser(arg0 context.Context, arg1 domain.User) (domain.User, *domain.AppError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", arg0)
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(*domain.AppError)
 	return ret0, ret1
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserRepositoryMockRecorder) UpdateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserRepository)(nil).UpdateUser), arg0, arg1)
 }
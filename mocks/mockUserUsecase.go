@@ -5,10 +5,13 @@
 package mocks
 
 import (
+	context "context"
 	domain "go-app/domain"
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 )
 
 // MockUserUseCase is a mock of UserUseCase interface.
@@ -34,61 +37,237 @@ func (m *MockUserUseCase) EXPECT() *MockUserUseCaseMockRecorder {
 	return m.recorder
 }
 
+// BatchUsers mocks base method.
+func (m *MockUserUseCase) BatchUsers(arg0 context.Context, arg1 domain.BatchRequest) domain.BatchResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchUsers", arg0, arg1)
+	ret0, _ := ret[0].(domain.BatchResponse)
+	return ret0
+}
+
+// BatchUsers indicates an expected call of BatchUsers.
+func (mr *MockUserUseCaseMockRecorder) BatchUsers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchUsers", reflect.TypeOf((*MockUserUseCase)(nil).BatchUsers), arg0, arg1)
+}
+
 // CreateUser mocks base method.
-func (m *MockUserUseCase) CreateUser(arg0 domain.User) (domain.User, *domain.AppError) {
+func (m *MockUserUseCase) CreateUser(arg0 context.Context, arg1 domain.User) (domain.User, *domain.AppError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0)
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(*domain.AppError)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockUserUseCaseMockRecorder) CreateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserUseCaseMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserUseCase)(nil).CreateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUserUseCase)(nil).CreateUser), arg0, arg1)
 }
 
 // DeleteUserById mocks base method.
-func (m *MockUserUseCase) DeleteUserById(arg0 uint) *domain.AppError {
+func (m *MockUserUseCase) DeleteUserById(arg0 context.Context, arg1, arg2 uint) *domain.AppError {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUserById", arg0)
+	ret := m.ctrl.Call(m, "DeleteUserById", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*domain.AppError)
 	return ret0
 }
 
 // DeleteUserById indicates an expected call of DeleteUserById.
-func (mr *MockUserUseCaseMockRecorder) DeleteUserById(arg0 interface{}) *gomock.Call {
+func (mr *MockUserUseCaseMockRecorder) DeleteUserById(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserById", reflect.TypeOf((*MockUserUseCase)(nil).DeleteUserById), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserById", reflect.TypeOf((*MockUserUseCase)(nil).DeleteUserById), arg0, arg1, arg2)
+}
+
+// ExportUsers mocks base method.
+func (m *MockUserUseCase) ExportUsers(arg0 context.Context, arg1 domain.UserFilter, arg2 domain.ExportFormat, arg3 io.Writer) *domain.AppError {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportUsers", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*domain.AppError)
+	return ret0
+}
+
+// ExportUsers indicates an expected call of ExportUsers.
+func (mr *MockUserUseCaseMockRecorder) ExportUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportUsers", reflect.TypeOf((*MockUserUseCase)(nil).ExportUsers), arg0, arg1, arg2, arg3)
+}
+
+// GetImportJob mocks base method.
+func (m *MockUserUseCase) GetImportJob(arg0 context.Context, arg1 string) (domain.ImportReport, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImportJob", arg0, arg1)
+	ret0, _ := ret[0].(domain.ImportReport)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// GetImportJob indicates an expected call of GetImportJob.
+func (mr *MockUserUseCaseMockRecorder) GetImportJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImportJob", reflect.TypeOf((*MockUserUseCase)(nil).GetImportJob), arg0, arg1)
 }
 
 // GetUserById mocks base method.
-func (m *MockUserUseCase) GetUserById(arg0 uint) (domain.User, *domain.AppError) {
+func (m *MockUserUseCase) GetUserById(arg0 context.Context, arg1 uint) (domain.User, *domain.AppError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", arg0)
+	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(*domain.AppError)
 	return ret0, ret1
 }
 
 // GetUserById indicates an expected call of GetUserById.
-func (mr *MockUserUseCaseMockRecorder) GetUserById(arg0 interface{}) *gomock.Call {
+func (mr *MockUserUseCaseMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserUseCase)(nil).GetUserById), arg0, arg1)
+}
+
+// GetUserByIdIncludingDeleted mocks base method.
+func (m *MockUserUseCase) GetUserByIdIncludingDeleted(arg0 context.Context, arg1 uint) (domain.User, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIdIncludingDeleted", arg0, arg1)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// GetUserByIdIncludingDeleted indicates an expected call of GetUserByIdIncludingDeleted.
+func (mr *MockUserUseCaseMockRecorder) GetUserByIdIncludingDeleted(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdIncludingDeleted", reflect.TypeOf((*MockUserUseCase)(nil).GetUserByIdIncludingDeleted), arg0, arg1)
+}
+
+// GetUserHistory mocks base method.
+func (m *MockUserUseCase) GetUserHistory(arg0 context.Context, arg1 uint, arg2 domain.HistoryQuery) (domain.UserHistory, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserHistory", arg0, arg1, arg2)
+	ret0, _ := ret[0].(domain.UserHistory)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// GetUserHistory indicates an expected call of GetUserHistory.
+func (mr *MockUserUseCaseMockRecorder) GetUserHistory(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserHistory", reflect.TypeOf((*MockUserUseCase)(nil).GetUserHistory), arg0, arg1, arg2)
+}
+
+// ImportUsers mocks base method.
+func (m *MockUserUseCase) ImportUsers(arg0 context.Context, arg1 domain.ImportFormat, arg2 io.Reader, arg3 bool) domain.ImportReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportUsers", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(domain.ImportReport)
+	return ret0
+}
+
+// ImportUsers indicates an expected call of ImportUsers.
+func (mr *MockUserUseCaseMockRecorder) ImportUsers(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportUsers", reflect.TypeOf((*MockUserUseCase)(nil).ImportUsers), arg0, arg1, arg2, arg3)
+}
+
+// PatchUser mocks base method.
+func (m *MockUserUseCase) PatchUser(arg0 context.Context, arg1, arg2 uint, arg3 domain.PatchType, arg4 []byte) (domain.User, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchUser", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// PatchUser indicates an expected call of PatchUser.
+func (mr *MockUserUseCaseMockRecorder) PatchUser(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchUser", reflect.TypeOf((*MockUserUseCase)(nil).PatchUser), arg0, arg1, arg2, arg3, arg4)
+}
+
+// ResolveUserId mocks base method.
+func (m *MockUserUseCase) ResolveUserId(arg0 context.Context, arg1 uuid.UUID) (uint, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveUserId", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// ResolveUserId indicates an expected call of ResolveUserId.
+func (mr *MockUserUseCaseMockRecorder) ResolveUserId(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveUserId", reflect.TypeOf((*MockUserUseCase)(nil).ResolveUserId), arg0, arg1)
+}
+
+// RestoreUserById mocks base method.
+func (m *MockUserUseCase) RestoreUserById(arg0 context.Context, arg1 uint) (domain.User, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUserById", arg0, arg1)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// RestoreUserById indicates an expected call of RestoreUserById.
+func (mr *MockUserUseCaseMockRecorder) RestoreUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUserById", reflect.TypeOf((*MockUserUseCase)(nil).RestoreUserById), arg0, arg1)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockUserUseCase) SetUserRoles(arg0 context.Context, arg1, arg2 uint, arg3 domain.Roles) (domain.User, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserRoles", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockUserUseCaseMockRecorder) SetUserRoles(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockUserUseCase)(nil).SetUserRoles), arg0, arg1, arg2, arg3)
+}
+
+// StartImportJob mocks base method.
+func (m *MockUserUseCase) StartImportJob(arg0 context.Context, arg1 domain.ImportFormat, arg2 io.ReadCloser, arg3 bool) domain.ImportReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartImportJob", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(domain.ImportReport)
+	return ret0
+}
+
+// StartImportJob indicates an expected call of StartImportJob.
+func (mr *MockUserUseCaseMockRecorder) StartImportJob(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartImportJob", reflect.TypeOf((*MockUserUseCase)(nil).StartImportJob), arg0, arg1, arg2, arg3)
+}
+
+// TransitionUser mocks base method.
+func (m *MockUserUseCase) TransitionUser(arg0 context.Context, arg1 uint, arg2 domain.UserTransition, arg3 domain.StatusChange) (domain.User, *domain.AppError) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionUser", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(domain.User)
+	ret1, _ := ret[1].(*domain.AppError)
+	return ret0, ret1
+}
+
+// TransitionUser indicates an expected call of TransitionUser.
+func (mr *MockUserUseCaseMockRecorder) TransitionUser(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockUserUseCase)(nil).GetUserById), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionUser", reflect.TypeOf((*MockUserUseCase)(nil).TransitionUser), arg0, arg1, arg2, arg3)
 }
 
 // UpdateUser mocks base method.
-func (m *MockUserUseCase) UpdateUser(arg0 domain.User) (domain.User, *domain.AppError) {
+func (m *MockUserUseCase) UpdateUser(arg0 context.Context, arg1 domain.User) (domain.User, *domain.AppError) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", arg0)
+	ret := m.ctrl.Call(m, "UpdateUser", arg0, arg1)
 	ret0, _ := ret[0].(domain.User)
 	ret1, _ := ret[1].(*domain.AppError)
 	return ret0, ret1
 }
 
 // UpdateUser indicates an expected call of UpdateUser.
-func (mr *MockUserUseCaseMockRecorder) UpdateUser(arg0 interface{}) *gomock.Call {
+func (mr *MockUserUseCaseMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserUseCase)(nil).UpdateUser), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserUseCase)(nil).UpdateUser), arg0, arg1)
 }
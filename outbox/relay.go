@@ -0,0 +1,222 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"time"
+)
+
+type RelayOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	// An event is dead lettered after this many failed attempts, the later events of its aggregate go on.
+	MaxAttempts int
+	// A batch is claimed for this long, events it did not get to by then are left to the next batch.
+	ClaimTimeout time.Duration
+	// Published events are deleted after this long.
+	Retention time.Duration
+}
+
+// How often published events older than the retention are deleted.
+const cleanupInterval = time.Hour
+
+// Relay delivers the pending outbox events to every sink, at least once and in order per aggregate.
+type Relay struct {
+	repo        domain.OutboxRepository
+	txManager   domain.TxManager
+	sinks       []domain.EventSink
+	logger      *zap.Logger
+	options     RelayOptions
+	lastCleanup time.Time
+}
+
+func NewRelay(repo domain.OutboxRepository, txManager domain.TxManager, sinks []domain.EventSink, logger *zap.Logger, options RelayOptions) *Relay {
+	return &Relay{repo: repo, txManager: txManager, sinks: sinks, logger: logger, options: options}
+}
+
+// Start polls the outbox on every interval until the context is cancelled.
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped.")
+			return
+		case <-ticker.C:
+			_, _ = r.RunOnce(ctx)
+		}
+	}
+}
+
+/*
+RunOnce delivers one batch of pending events. The batch is fetched and claimed in a transaction holding an advisory lock,
+so several application instances never deliver the same events, and the sinks are called once it is committed.
+Once an event of an aggregate fails or waits for its retry, the later events of the same aggregate are held back.
+*/
+func (r *Relay) RunOnce(ctx context.Context) (int, *domain.AppError) {
+	events, claimedUntil, err := r.claim(ctx)
+	if err != nil {
+		r.logger.Error(err.Message, zap.Error(err))
+		return 0, err
+	}
+
+	delivered, err := r.deliverAll(ctx, events, claimedUntil)
+	if err != nil {
+		r.logger.Error(err.Message, zap.Error(err))
+		return delivered, err
+	}
+
+	r.recordLag(ctx)
+	r.cleanup(ctx)
+	return delivered, nil
+}
+
+// claim fetches a batch of due events and moves their next attempt past the claim timeout, so no other relay fetches them.
+func (r *Relay) claim(ctx context.Context) ([]domain.OutboxEvent, time.Time, *domain.AppError) {
+	var events []domain.OutboxEvent
+	claimedUntil := time.Now().Add(r.options.ClaimTimeout)
+	err := r.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		events = nil
+		locked, err := r.repo.TryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		if events, err = r.repo.FetchPending(ctx, r.options.BatchSize); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		ids := make([]uint64, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		if err := r.repo.Reschedule(ctx, ids, claimedUntil); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, claimedUntil, err
+	}
+	return events, claimedUntil, nil
+}
+
+// deliverAll sends the claimed events in order, the events it holds back are released for the next batch.
+func (r *Relay) deliverAll(ctx context.Context, events []domain.OutboxEvent, claimedUntil time.Time) (int, *domain.AppError) {
+	// The sinks stop at the end of the claim, the outcomes are still recorded.
+	sinkCtx, cancel := context.WithDeadline(ctx, claimedUntil)
+	defer cancel()
+
+	var delivered int
+	var released []uint64
+	blocked := make(map[string]bool)
+	for _, event := range events {
+		aggregate := event.AggregateType + "/" + event.AggregateID
+		if blocked[aggregate] || sinkCtx.Err() != nil {
+			blocked[aggregate] = true
+			released = append(released, event.ID)
+			continue
+		}
+
+		deliverErr := r.deliver(sinkCtx, event)
+		if deliverErr != nil && sinkCtx.Err() != nil {
+			blocked[aggregate] = true
+			released = append(released, event.ID)
+			continue
+		}
+		if deliverErr == nil {
+			if err := r.repo.MarkPublished(ctx, event.ID, time.Now()); err != nil {
+				return delivered, err
+			}
+			metrics.OutboxEventsPublished.WithLabelValues(string(event.EventType)).Inc()
+			delivered++
+			continue
+		}
+
+		attempts := event.Attempts + 1
+		if attempts >= r.options.MaxAttempts {
+			r.logger.Error(fmt.Sprintf("Outbox event moved to dead letter. Event: %s, attempts: %d", event.EventID, attempts), zap.Error(deliverErr))
+			if err := r.repo.MarkDeadLettered(ctx, event.ID, attempts, time.Now(), deliverErr.Error()); err != nil {
+				return delivered, err
+			}
+			metrics.OutboxEventsDeadLettered.WithLabelValues(string(event.EventType)).Inc()
+			continue
+		}
+
+		blocked[aggregate] = true
+		nextAttemptAt := time.Now().Add(r.backoff(attempts))
+		r.logger.Warn(fmt.Sprintf("Outbox event delivery failed. Event: %s, attempt: %d, next attempt: %s",
+			event.EventID, attempts, nextAttemptAt.Format(time.RFC3339)), zap.Error(deliverErr))
+		if err := r.repo.MarkFailed(ctx, event.ID, attempts, nextAttemptAt, deliverErr.Error()); err != nil {
+			return delivered, err
+		}
+	}
+
+	if len(released) > 0 {
+		if err := r.repo.Reschedule(ctx, released, time.Now()); err != nil {
+			return delivered, err
+		}
+	}
+	return delivered, nil
+}
+
+func (r *Relay) deliver(ctx context.Context, event domain.OutboxEvent) error {
+	envelope := event.Envelope()
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, envelope); err != nil {
+			metrics.OutboxDeliveryFailures.WithLabelValues(sink.Name()).Inc()
+			return fmt.Errorf("sink %s: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
+
+// backoff doubles the delay with every attempt, up to RetryMax.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.options.RetryBase
+	for i := 1; i < attempts && delay < r.options.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, r.options.RetryMax)
+}
+
+func (r *Relay) recordLag(ctx context.Context) {
+	oldest, err := r.repo.OldestPendingCreatedAt(ctx)
+	if err != nil {
+		r.logger.Error(err.Message, zap.Error(err))
+		return
+	}
+	if oldest == nil {
+		metrics.OutboxLag.Set(0)
+		return
+	}
+	metrics.OutboxLag.Set(time.Since(*oldest).Seconds())
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	if time.Since(r.lastCleanup) < cleanupInterval {
+		return
+	}
+	r.lastCleanup = time.Now()
+
+	deleted, err := r.repo.DeletePublishedBefore(ctx, time.Now().Add(-r.options.Retention))
+	if err != nil {
+		r.logger.Error(err.Message, zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		r.logger.Info(fmt.Sprintf("Published outbox events deleted: %d", deleted))
+	}
+}
@@ -0,0 +1,195 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/domain"
+	"go-app/mocks"
+	"testing"
+	"time"
+)
+
+type fakeSink struct {
+	failFor map[string]bool
+	// hangFor holds the delivery of these events until the context is done.
+	hangFor   map[string]bool
+	published []string
+}
+
+func (s *fakeSink) Name() string {
+	return "fake"
+}
+
+func (s *fakeSink) Publish(ctx context.Context, envelope domain.EventEnvelope) error {
+	if s.failFor[envelope.ID] {
+		return errors.New("sink unavailable")
+	}
+	if s.hangFor[envelope.ID] {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	s.published = append(s.published, envelope.ID)
+	return nil
+}
+
+func mockRelaySetup(t *testing.T, sink *fakeSink) (*mocks.MockOutboxRepository, *Relay) {
+	return mockRelaySetupWithClaimTimeout(t, sink, time.Minute)
+}
+
+func mockRelaySetupWithClaimTimeout(t *testing.T, sink *fakeSink, claimTimeout time.Duration) (*mocks.MockOutboxRepository, *Relay) {
+	c := gomock.NewController(t)
+	repo := mocks.NewMockOutboxRepository(c)
+	txManager := mocks.NewMockTxManager(c)
+	txManager.EXPECT().WithinTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error, opts ...domain.TxOption) *domain.AppError {
+			if err := fn(ctx); err != nil {
+				return err.(*domain.AppError)
+			}
+			return nil
+		}).AnyTimes()
+	repo.EXPECT().OldestPendingCreatedAt(gomock.Any()).Return(nil, nil).AnyTimes()
+	repo.EXPECT().DeletePublishedBefore(gomock.Any(), gomock.Any()).Return(int64(0), nil).AnyTimes()
+
+	relay := NewRelay(repo, txManager, []domain.EventSink{sink}, config.ZapTestConfig(), RelayOptions{
+		PollInterval: time.Second,
+		BatchSize:    10,
+		RetryBase:    time.Second,
+		RetryMax:     time.Minute,
+		MaxAttempts:  3,
+		ClaimTimeout: claimTimeout,
+		Retention:    time.Hour,
+	})
+	return repo, relay
+}
+
+func pendingEvent(id uint64, eventID string, aggregateID string) domain.OutboxEvent {
+	return domain.OutboxEvent{ID: id, EventID: eventID, AggregateType: domain.UserAggregate, AggregateID: aggregateID,
+		EventType: domain.UserUpdated, Payload: []byte("{}"), NextAttemptAt: time.Now().Add(-time.Second)}
+}
+
+func Test_Should_Deliver_Pending_Events_And_Mark_Them_Published(t *testing.T) {
+	// GIVEN
+	sink := &fakeSink{}
+	repo, relay := mockRelaySetup(t, sink)
+	events := []domain.OutboxEvent{pendingEvent(1, "e1", "1"), pendingEvent(2, "e2", "2")}
+
+	// WHEN
+	repo.EXPECT().TryLock(gomock.Any()).Return(true, nil)
+	repo.EXPECT().FetchPending(gomock.Any(), 10).Return(events, nil)
+	repo.EXPECT().Reschedule(gomock.Any(), []uint64{1, 2}, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, ids []uint64, nextAttemptAt time.Time) *domain.AppError {
+			assert.WithinDuration(t, time.Now().Add(time.Minute), nextAttemptAt, time.Second)
+			return nil
+		})
+	repo.EXPECT().MarkPublished(gomock.Any(), uint64(1), gomock.Any()).Return(nil)
+	repo.EXPECT().MarkPublished(gomock.Any(), uint64(2), gomock.Any()).Return(nil)
+	delivered, err := relay.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, []string{"e1", "e2"}, sink.published)
+}
+
+func Test_Should_Hold_Back_Later_Events_Of_Aggregate_When_Delivery_Fails(t *testing.T) {
+	// GIVEN
+	sink := &fakeSink{failFor: map[string]bool{"e1": true}}
+	repo, relay := mockRelaySetup(t, sink)
+	failed := pendingEvent(1, "e1", "1")
+	failed.Attempts = 1
+	events := []domain.OutboxEvent{failed, pendingEvent(2, "e2", "1"), pendingEvent(3, "e3", "2")}
+
+	// WHEN
+	repo.EXPECT().TryLock(gomock.Any()).Return(true, nil)
+	repo.EXPECT().FetchPending(gomock.Any(), 10).Return(events, nil)
+	repo.EXPECT().Reschedule(gomock.Any(), []uint64{1, 2, 3}, gomock.Any()).Return(nil)
+	repo.EXPECT().MarkFailed(gomock.Any(), uint64(1), 2, gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id uint64, attempts int, nextAttemptAt time.Time, lastError string) *domain.AppError {
+			assert.WithinDuration(t, time.Now().Add(2*time.Second), nextAttemptAt, time.Second)
+			assert.Contains(t, lastError, "sink unavailable")
+			return nil
+		})
+	repo.EXPECT().MarkPublished(gomock.Any(), uint64(3), gomock.Any()).Return(nil)
+	repo.EXPECT().Reschedule(gomock.Any(), []uint64{2}, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, ids []uint64, nextAttemptAt time.Time) *domain.AppError {
+			assert.WithinDuration(t, time.Now(), nextAttemptAt, time.Second)
+			return nil
+		})
+	delivered, err := relay.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{"e3"}, sink.published)
+}
+
+func Test_Should_Dead_Letter_Event_After_Max_Attempts_And_Go_On_With_Its_Aggregate(t *testing.T) {
+	// GIVEN
+	sink := &fakeSink{failFor: map[string]bool{"e1": true}}
+	repo, relay := mockRelaySetup(t, sink)
+	poison := pendingEvent(1, "e1", "1")
+	poison.Attempts = 2
+	events := []domain.OutboxEvent{poison, pendingEvent(2, "e2", "1")}
+
+	// WHEN
+	repo.EXPECT().TryLock(gomock.Any()).Return(true, nil)
+	repo.EXPECT().FetchPending(gomock.Any(), 10).Return(events, nil)
+	repo.EXPECT().Reschedule(gomock.Any(), []uint64{1, 2}, gomock.Any()).Return(nil)
+	repo.EXPECT().MarkDeadLettered(gomock.Any(), uint64(1), 3, gomock.Any(), gomock.Any()).Return(nil)
+	repo.EXPECT().MarkPublished(gomock.Any(), uint64(2), gomock.Any()).Return(nil)
+	delivered, err := relay.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []string{"e2"}, sink.published)
+}
+
+func Test_Should_Release_Events_Not_Delivered_Before_The_Claim_Expires(t *testing.T) {
+	// GIVEN
+	sink := &fakeSink{hangFor: map[string]bool{"e1": true}}
+	repo, relay := mockRelaySetupWithClaimTimeout(t, sink, 50*time.Millisecond)
+	events := []domain.OutboxEvent{pendingEvent(1, "e1", "1"), pendingEvent(2, "e2", "2")}
+
+	// WHEN
+	repo.EXPECT().TryLock(gomock.Any()).Return(true, nil)
+	repo.EXPECT().FetchPending(gomock.Any(), 10).Return(events, nil)
+	repo.EXPECT().Reschedule(gomock.Any(), []uint64{1, 2}, gomock.Any()).Return(nil)
+	repo.EXPECT().Reschedule(gomock.Any(), []uint64{1, 2}, gomock.Any()).Return(nil)
+	delivered, err := relay.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Empty(t, sink.published)
+}
+
+func Test_Should_Not_Deliver_When_Another_Relay_Holds_The_Lock(t *testing.T) {
+	// GIVEN
+	sink := &fakeSink{}
+	repo, relay := mockRelaySetup(t, sink)
+
+	// WHEN
+	repo.EXPECT().TryLock(gomock.Any()).Return(false, nil)
+	delivered, err := relay.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 0, delivered)
+}
+
+func Test_Should_Cap_Retry_Backoff(t *testing.T) {
+	// GIVEN
+	_, relay := mockRelaySetup(t, &fakeSink{})
+
+	// WHEN
+	first, third, capped := relay.backoff(1), relay.backoff(3), relay.backoff(20)
+
+	// THEN
+	assert.Equal(t, time.Second, first)
+	assert.Equal(t, 4*time.Second, third)
+	assert.Equal(t, time.Minute, capped)
+}
@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"context"
+	"go-app/database"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+)
+
+// Key of the Postgres advisory lock held by the relay while it delivers a batch.
+const relayLockKey = 7_038_001
+
+//go:generate mockgen -destination=../mocks/mockOutboxRepository.go -package=mocks go-app/domain OutboxRepository
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Add(ctx context.Context, events ...domain.OutboxEvent) *domain.AppError {
+	if len(events) == 0 {
+		return nil
+	}
+	if err := database.Conn(ctx, r.db).Create(&events).Error; err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) TryLock(ctx context.Context) (bool, *domain.AppError) {
+	// SQLite serves a single instance and takes a database wide lock for every write transaction.
+	if r.db.Dialector.Name() != "postgres" {
+		return true, nil
+	}
+
+	var locked bool
+	if err := database.Conn(ctx, r.db).Raw("SELECT pg_try_advisory_xact_lock(?)", relayLockKey).Scan(&locked).Error; err != nil {
+		return false, database.TranslateError(err)
+	}
+	return locked, nil
+}
+
+// Events neither published nor dead lettered, the alias o is the outbox table of the outer query.
+const pendingCondition = "o.published_at IS NULL AND o.dead_lettered_at IS NULL"
+
+func (r *outboxRepository) FetchPending(ctx context.Context, limit int) ([]domain.OutboxEvent, *domain.AppError) {
+	var events []domain.OutboxEvent
+	now := time.Now()
+	// An event waiting for a retry, or claimed by a relay, holds back the later events of its aggregate only.
+	err := database.Conn(ctx, r.db).Table("outbox AS o").
+		Where(pendingCondition+" AND o.next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox AS e WHERE e.aggregate_type = o.aggregate_type AND e.aggregate_id = o.aggregate_id
+			AND e.id < o.id AND e.published_at IS NULL AND e.dead_lettered_at IS NULL AND e.next_attempt_at > ?)`, now).
+		Order("o.id").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) Reschedule(ctx context.Context, ids []uint64, nextAttemptAt time.Time) *domain.AppError {
+	if len(ids) == 0 {
+		return nil
+	}
+	err := database.Conn(ctx, r.db).Model(&domain.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", nextAttemptAt).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id uint64, publishedAt time.Time) *domain.AppError {
+	err := database.Conn(ctx, r.db).Model(&domain.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"published_at": publishedAt, "last_error": ""}).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uint64, attempts int, nextAttemptAt time.Time, lastError string) *domain.AppError {
+	err := database.Conn(ctx, r.db).Model(&domain.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": nextAttemptAt, "last_error": lastError}).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkDeadLettered(ctx context.Context, id uint64, attempts int, deadLetteredAt time.Time, lastError string) *domain.AppError {
+	err := database.Conn(ctx, r.db).Model(&domain.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "dead_lettered_at": deadLetteredAt, "last_error": lastError}).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) OldestPendingCreatedAt(ctx context.Context) (*time.Time, *domain.AppError) {
+	var events []domain.OutboxEvent
+	err := database.Conn(ctx, r.db).Table("outbox AS o").Select("o.created_at").Where(pendingCondition).Order("o.id").Limit(1).Find(&events).Error
+	if err != nil {
+		return nil, database.TranslateError(err)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0].CreatedAt, nil
+}
+
+func (r *outboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, *domain.AppError) {
+	result := database.Conn(ctx, r.db).Where("published_at < ?", before).Delete(&domain.OutboxEvent{})
+	if result.Error != nil {
+		return 0, database.TranslateError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *outboxRepository) FetchAfter(ctx context.Context, afterID uint64, limit int) ([]domain.OutboxEvent, *domain.AppError) {
+	var events []domain.OutboxEvent
+	err := database.Conn(ctx, r.db).Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) FetchLatest(ctx context.Context, limit int) ([]domain.OutboxEvent, *domain.AppError) {
+	var events []domain.OutboxEvent
+	err := database.Conn(ctx, r.db).Order("id DESC").Limit(limit).Find(&events).Error
+	if err != nil {
+		return nil, database.TranslateError(err)
+	}
+	slices.Reverse(events)
+	return events, nil
+}
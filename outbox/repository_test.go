@@ -0,0 +1,57 @@
+package outbox
+
+import (
+	"context"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"go-app/database"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_Should_Fetch_Due_Events_Not_Held_Back_By_Their_Aggregate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "outbox.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewOutboxRepository(db)
+	ctx := context.Background()
+
+	// GIVEN
+	now := time.Now()
+	event := func(id uint64, aggregateID string, nextAttemptAt time.Time) domain.OutboxEvent {
+		return domain.OutboxEvent{ID: id, EventID: string(rune('a' + id)), AggregateType: domain.UserAggregate, AggregateID: aggregateID,
+			EventType: domain.UserUpdated, Payload: []byte("{}"), CreatedAt: now, NextAttemptAt: nextAttemptAt}
+	}
+	retrying := event(1, "1", now.Add(time.Minute))
+	deadLettered := event(3, "2", now.Add(-time.Minute))
+	deadLettered.DeadLetteredAt = &now
+	published := event(5, "3", now.Add(-time.Minute))
+	published.PublishedAt = &now
+	assert.Nil(t, repo.Add(ctx,
+		retrying, event(2, "1", now.Add(-time.Minute)),
+		deadLettered, event(4, "2", now.Add(-time.Minute)),
+		published, event(6, "3", now.Add(-time.Minute)), event(7, "3", now.Add(time.Minute)),
+	))
+
+	// WHEN
+	due, fetchErr := repo.FetchPending(ctx, 10)
+	claimErr := repo.Reschedule(ctx, []uint64{4, 6}, now.Add(time.Minute))
+	afterClaim, _ := repo.FetchPending(ctx, 10)
+
+	// THEN
+	assert.Nil(t, fetchErr)
+	assert.Nil(t, claimErr)
+	var ids []uint64
+	for _, event := range due {
+		ids = append(ids, event.ID)
+	}
+	assert.Equal(t, []uint64{4, 6}, ids)
+	assert.Empty(t, afterClaim)
+}
@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-app/domain"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+type SinkOptions struct {
+	HTTPURL     string
+	HTTPTimeout time.Duration
+	FilePath    string
+}
+
+// NewSink creates one of the stdout, file or http sinks.
+func NewSink(name string, options SinkOptions) (domain.EventSink, error) {
+	switch name {
+	case "stdout":
+		return &writerSink{name: name, writer: os.Stdout}, nil
+	case "file":
+		file, err := os.OpenFile(options.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		return &writerSink{name: name, writer: file}, nil
+	case "http":
+		if options.HTTPURL == "" {
+			return nil, fmt.Errorf("http sink needs a URL")
+		}
+		return &httpSink{url: options.HTTPURL, client: &http.Client{Timeout: options.HTTPTimeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown outbox sink: %s", name)
+	}
+}
+
+// writerSink writes every event as one JSON line.
+type writerSink struct {
+	name   string
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (s *writerSink) Name() string {
+	return s.name
+}
+
+func (s *writerSink) Publish(ctx context.Context, envelope domain.EventEnvelope) error {
+	line, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.writer.Write(append(line, '\n'))
+	return err
+}
+
+// httpSink posts every event to a single URL, any non 2xx response is retried.
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSink) Name() string {
+	return "http"
+}
+
+func (s *httpSink) Publish(ctx context.Context, envelope domain.EventEnvelope) error {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", envelope.ID)
+	req.Header.Set("X-Event-Type", string(envelope.Type))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, s.url)
+	}
+	return nil
+}
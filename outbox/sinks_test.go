@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEnvelope() domain.EventEnvelope {
+	return domain.EventEnvelope{ID: "e1", Type: domain.UserCreated, AggregateType: domain.UserAggregate, AggregateID: "1",
+		OccurredAt: time.Now(), Data: json.RawMessage(`{"id":1}`)}
+}
+
+func Test_Should_Post_Event_To_HTTP_Sink(t *testing.T) {
+	// GIVEN
+	var received domain.EventEnvelope
+	var eventType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		eventType = r.Header.Get("X-Event-Type")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	sink, _ := NewSink("http", SinkOptions{HTTPURL: server.URL, HTTPTimeout: time.Second})
+
+	// WHEN
+	err := sink.Publish(context.Background(), testEnvelope())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "e1", received.ID)
+	assert.Equal(t, string(domain.UserCreated), eventType)
+}
+
+func Test_Should_Return_Err_When_HTTP_Sink_Responds_With_Error(t *testing.T) {
+	// GIVEN
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	sink, _ := NewSink("http", SinkOptions{HTTPURL: server.URL, HTTPTimeout: time.Second})
+
+	// WHEN
+	err := sink.Publish(context.Background(), testEnvelope())
+
+	// THEN
+	assert.ErrorContains(t, err, "503")
+}
+
+func Test_Should_Append_Event_To_File_Sink(t *testing.T) {
+	// GIVEN
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink, err := NewSink("file", SinkOptions{FilePath: path})
+	assert.Nil(t, err)
+
+	// WHEN
+	_ = sink.Publish(context.Background(), testEnvelope())
+	_ = sink.Publish(context.Background(), testEnvelope())
+
+	// THEN
+	content, _ := os.ReadFile(path)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 2)
+}
+
+func Test_Should_Return_Err_When_Sink_Is_Unknown(t *testing.T) {
+	// WHEN
+	_, err := NewSink("kafka", SinkOptions{})
+
+	// THEN
+	assert.ErrorContains(t, err, "unknown outbox sink")
+}
@@ -0,0 +1,218 @@
+package stream
+
+import (
+	"context"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+type BrokerOptions struct {
+	PollInterval time.Duration
+	// Number of events kept in memory for clients resuming with Last-Event-ID.
+	LogSize        int
+	MaxSubscribers int
+	// How long a missing outbox ID is waited for before it is skipped, it may belong to a transaction still in flight.
+	GapTimeout time.Duration
+}
+
+const (
+	// Events buffered per subscriber, a subscriber falling further behind is disconnected and resumes from the log.
+	subscriberBuffer = 64
+	pollBatchSize    = 500
+)
+
+// Subscription receives the events of the subscribed types until Events is closed.
+type Subscription struct {
+	Events <-chan domain.OutboxEvent
+	// Replay holds the logged events after the Last-Event-ID of the client.
+	Replay []domain.OutboxEvent
+	// Gap is set when events after the Last-Event-ID have already left the log.
+	Gap bool
+
+	events chan domain.OutboxEvent
+	types  map[domain.EventType]bool
+}
+
+func (s *Subscription) accepts(event domain.OutboxEvent) bool {
+	return len(s.types) == 0 || s.types[event.EventType]
+}
+
+/*
+Broker tails the outbox table and fans the user events out to the stream subscribers of this instance.
+Every instance tails on its own, so subscribers see all events whichever instance delivers them to the sinks.
+*/
+type Broker struct {
+	repo    domain.OutboxRepository
+	logger  *zap.Logger
+	options BrokerOptions
+
+	mu          sync.Mutex
+	log         []domain.OutboxEvent
+	trimmedTo   uint64
+	cursor      uint64
+	gapSince    time.Time
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+func NewBroker(repo domain.OutboxRepository, logger *zap.Logger, options BrokerOptions) *Broker {
+	return &Broker{repo: repo, logger: logger, options: options, subscribers: make(map[*Subscription]struct{})}
+}
+
+// Start loads the latest events into the log and polls for new ones until the context is cancelled, then closes every subscription.
+func (b *Broker) Start(ctx context.Context) {
+	if err := b.Prime(ctx); err != nil {
+		b.logger.Error(err.Message, zap.Error(err))
+	}
+
+	ticker := time.NewTicker(b.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.close()
+			b.logger.Info("Event stream broker stopped.")
+			return
+		case <-ticker.C:
+			if err := b.Poll(ctx); err != nil {
+				b.logger.Error(err.Message, zap.Error(err))
+			}
+		}
+	}
+}
+
+// Prime fills the log with the newest events, streaming starts after them.
+func (b *Broker) Prime(ctx context.Context) *domain.AppError {
+	events, err := b.repo.FetchLatest(ctx, b.options.LogSize)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.log = events
+	if len(events) > 0 {
+		b.cursor = events[len(events)-1].ID
+		if len(events) == b.options.LogSize {
+			b.trimmedTo = events[0].ID - 1
+		}
+	}
+	return nil
+}
+
+// Poll reads the events committed since the last poll, appends them to the log and sends them to the subscribers.
+func (b *Broker) Poll(ctx context.Context) *domain.AppError {
+	b.mu.Lock()
+	cursor := b.cursor
+	b.mu.Unlock()
+
+	events, err := b.repo.FetchAfter(ctx, cursor, pollBatchSize)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, event := range events {
+		if event.ID != b.cursor+1 {
+			if b.gapSince.IsZero() {
+				b.gapSince = now
+			}
+			if now.Sub(b.gapSince) < b.options.GapTimeout {
+				break
+			}
+		}
+		b.gapSince = time.Time{}
+		b.cursor = event.ID
+		b.append(event)
+		b.broadcast(event)
+	}
+	return nil
+}
+
+/*
+Subscribe registers a subscriber for the event types, all types when none are given.
+A client resuming after lastEventID first receives the logged events it has missed.
+*/
+func (b *Broker) Subscribe(lastEventID *uint64, types []domain.EventType) (*Subscription, *domain.AppError) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, domain.NewServiceUnavailableError("Event stream is shutting down.")
+	}
+	if len(b.subscribers) >= b.options.MaxSubscribers {
+		return nil, domain.NewServiceUnavailableError("Too many event stream subscribers, try again later.")
+	}
+
+	events := make(chan domain.OutboxEvent, subscriberBuffer)
+	subscription := &Subscription{Events: events, events: events, types: make(map[domain.EventType]bool)}
+	for _, eventType := range types {
+		subscription.types[eventType] = true
+	}
+
+	if lastEventID != nil {
+		subscription.Gap = *lastEventID < b.trimmedTo
+		for _, event := range b.log {
+			if event.ID > *lastEventID && subscription.accepts(event) {
+				subscription.Replay = append(subscription.Replay, event)
+			}
+		}
+	}
+
+	b.subscribers[subscription] = struct{}{}
+	metrics.UserEventSubscribers.Inc()
+	return subscription, nil
+}
+
+// Unsubscribe removes the subscriber, it is safe to call for a subscription the broker already dropped.
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(subscription)
+}
+
+func (b *Broker) append(event domain.OutboxEvent) {
+	b.log = append(b.log, event)
+	if overflow := len(b.log) - b.options.LogSize; overflow > 0 {
+		b.trimmedTo = b.log[overflow-1].ID
+		b.log = append([]domain.OutboxEvent(nil), b.log[overflow:]...)
+	}
+}
+
+func (b *Broker) broadcast(event domain.OutboxEvent) {
+	for subscription := range b.subscribers {
+		if !subscription.accepts(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			b.logger.Warn("Event stream subscriber is too slow, disconnecting it.")
+			b.remove(subscription)
+		}
+	}
+}
+
+func (b *Broker) remove(subscription *Subscription) {
+	if _, ok := b.subscribers[subscription]; !ok {
+		return
+	}
+	delete(b.subscribers, subscription)
+	close(subscription.events)
+	metrics.UserEventSubscribers.Dec()
+}
+
+func (b *Broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for subscription := range b.subscribers {
+		b.remove(subscription)
+	}
+}
@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/domain"
+	"go-app/mocks"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func mockBrokerSetup(t *testing.T, logSize int) (*mocks.MockOutboxRepository, *Broker) {
+	c := gomock.NewController(t)
+	repo := mocks.NewMockOutboxRepository(c)
+	broker := NewBroker(repo, config.ZapTestConfig(), BrokerOptions{
+		PollInterval:   time.Second,
+		LogSize:        logSize,
+		MaxSubscribers: 2,
+		GapTimeout:     time.Hour,
+	})
+	return repo, broker
+}
+
+func outboxEvents(eventType domain.EventType, ids ...uint64) []domain.OutboxEvent {
+	events := make([]domain.OutboxEvent, len(ids))
+	for i, id := range ids {
+		events[i] = domain.OutboxEvent{ID: id, EventID: "e", EventType: eventType, Payload: []byte("{}")}
+	}
+	return events
+}
+
+func Test_Should_Replay_Logged_Events_After_Last_Event_ID(t *testing.T) {
+	repo, broker := mockBrokerSetup(t, 10)
+
+	// GIVEN
+	repo.EXPECT().FetchLatest(gomock.Any(), 10).Return(outboxEvents(domain.UserUpdated, 1, 2, 3), nil)
+	_ = broker.Prime(context.Background())
+	lastEventID := uint64(1)
+
+	// WHEN
+	subscription, err := broker.Subscribe(&lastEventID, nil)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.False(t, subscription.Gap)
+	assert.Len(t, subscription.Replay, 2)
+	assert.Equal(t, uint64(2), subscription.Replay[0].ID)
+}
+
+func Test_Should_Report_Gap_When_Last_Event_ID_Left_The_Log(t *testing.T) {
+	repo, broker := mockBrokerSetup(t, 2)
+
+	// GIVEN
+	repo.EXPECT().FetchLatest(gomock.Any(), 2).Return(outboxEvents(domain.UserUpdated, 4, 5), nil)
+	_ = broker.Prime(context.Background())
+	lastEventID := uint64(1)
+
+	// WHEN
+	subscription, err := broker.Subscribe(&lastEventID, nil)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.True(t, subscription.Gap)
+	assert.Len(t, subscription.Replay, 2)
+}
+
+func Test_Should_Send_Polled_Events_Of_Subscribed_Types(t *testing.T) {
+	repo, broker := mockBrokerSetup(t, 10)
+
+	// GIVEN
+	repo.EXPECT().FetchLatest(gomock.Any(), 10).Return(nil, nil)
+	_ = broker.Prime(context.Background())
+	subscription, _ := broker.Subscribe(nil, []domain.EventType{domain.UserDeleted})
+	polled := append(outboxEvents(domain.UserCreated, 1), outboxEvents(domain.UserDeleted, 2)...)
+
+	// WHEN
+	repo.EXPECT().FetchAfter(gomock.Any(), uint64(0), gomock.Any()).Return(polled, nil)
+	err := broker.Poll(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, subscription.Events, 1)
+	event := <-subscription.Events
+	assert.Equal(t, uint64(2), event.ID)
+}
+
+func Test_Should_Wait_For_Missing_ID_Before_Sending_Later_Events(t *testing.T) {
+	repo, broker := mockBrokerSetup(t, 10)
+
+	// GIVEN
+	repo.EXPECT().FetchLatest(gomock.Any(), 10).Return(outboxEvents(domain.UserCreated, 1), nil)
+	_ = broker.Prime(context.Background())
+	subscription, _ := broker.Subscribe(nil, nil)
+
+	// WHEN
+	repo.EXPECT().FetchAfter(gomock.Any(), uint64(1), gomock.Any()).Return(outboxEvents(domain.UserCreated, 3), nil)
+	err := broker.Poll(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, subscription.Events, 0)
+}
+
+func Test_Should_Reject_Subscriber_Over_The_Limit(t *testing.T) {
+	_, broker := mockBrokerSetup(t, 10)
+
+	// GIVEN
+	_, _ = broker.Subscribe(nil, nil)
+	_, _ = broker.Subscribe(nil, nil)
+
+	// WHEN
+	_, err := broker.Subscribe(nil, nil)
+
+	// THEN
+	assert.Equal(t, http.StatusServiceUnavailable, err.Status)
+	assert.True(t, err.Retryable)
+}
+
+func Test_Should_Disconnect_Subscriber_That_Falls_Behind(t *testing.T) {
+	repo, broker := mockBrokerSetup(t, 1000)
+
+	// GIVEN
+	repo.EXPECT().FetchLatest(gomock.Any(), 1000).Return(nil, nil)
+	_ = broker.Prime(context.Background())
+	subscription, _ := broker.Subscribe(nil, nil)
+	ids := make([]uint64, subscriberBuffer+1)
+	for i := range ids {
+		ids[i] = uint64(i + 1)
+	}
+
+	// WHEN
+	repo.EXPECT().FetchAfter(gomock.Any(), uint64(0), gomock.Any()).Return(outboxEvents(domain.UserCreated, ids...), nil)
+	_ = broker.Poll(context.Background())
+
+	// THEN
+	received := 0
+	for range subscription.Events {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+}
@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"fmt"
+	sentrygin "github.com/getsentry/sentry-go/gin"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/newrelic/go-agent/v3/integrations/nrgin"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reconnection delay suggested to EventSource clients, in milliseconds.
+const retryMillis = 3000
+
+type Handler struct {
+	broker    *Broker
+	logger    *zap.Logger
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(broker *Broker, logger *zap.Logger, heartbeat time.Duration) *Handler {
+	return &Handler{broker: broker, logger: logger, heartbeat: heartbeat}
+}
+
+// StreamUserEvents godoc
+// @Summary Stream user events
+// @Description Server-Sent Events stream of user changes. The event ID can be sent back as Last-Event-ID to resume,
+// @Description a "gap" event tells the client that events were missed and the users should be reloaded.
+// @Tags users
+// @Produce text/event-stream
+// @Param types query string false "Comma separated event types" example(user.created,user.deleted)
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query string false "Last-Event-ID for clients that can not set headers"
+// @Success 200 {object} domain.EventEnvelope "Stream of events"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 503 {object} domain.ProblemDetails "Too many subscribers"
+// @Router /api/v1/users/events [get]
+func (h *Handler) StreamUserEvents(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		types, err := parseEventTypes(c.Query("types"))
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		lastEventID, err := parseLastEventID(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		subscription, err := h.broker.Subscribe(lastEventID, types)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		defer h.broker.Unsubscribe(subscription)
+
+		hub.Scope().SetTag("stream", "sse")
+		// The New Relic transaction only covers the handshake, a stream open for hours would distort the response times.
+		if txn := nrgin.Transaction(c); txn != nil {
+			txn.End()
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+
+		_, _ = fmt.Fprintf(c.Writer, "retry: %d\n\n", retryMillis)
+		if subscription.Gap {
+			_ = sse.Encode(c.Writer, sse.Event{Event: "gap", Data: "Events were missed, reload the users."})
+		}
+		for _, event := range subscription.Replay {
+			_ = sse.Encode(c.Writer, newSSEvent(event))
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(h.heartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case event, ok := <-subscription.Events:
+				if !ok {
+					// Dropped as too slow or shutting down, the client reconnects with its Last-Event-ID.
+					return
+				}
+				if err := sse.Encode(c.Writer, newSSEvent(event)); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			}
+		}
+	}
+}
+
+func newSSEvent(event domain.OutboxEvent) sse.Event {
+	return sse.Event{Id: strconv.FormatUint(event.ID, 10), Event: string(event.EventType), Data: event.Envelope()}
+}
+
+func parseEventTypes(value string) ([]domain.EventType, *domain.AppError) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var types []domain.EventType
+	for _, name := range strings.Split(value, ",") {
+		eventType := domain.EventType(strings.TrimSpace(name))
+		if !eventType.Valid() {
+			return nil, domain.NewBadRequestError("Invalid event type: " + string(eventType))
+		}
+		types = append(types, eventType)
+	}
+	return types, nil
+}
+
+func parseLastEventID(c *gin.Context) (*uint64, *domain.AppError) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, domain.NewBadRequestError("Invalid Last-Event-ID: " + value)
+	}
+	return &id, nil
+}
+
+func errorResponse(c *gin.Context, err *domain.AppError) {
+	c.Header("Content-Type", domain.ProblemContentType)
+	if err.Retryable {
+		c.Header("Retry-After", "1")
+	}
+	c.JSON(err.Status, err.Problem(c.Request.URL.Path))
+}
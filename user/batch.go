@@ -0,0 +1,210 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+/*
+BatchUsers applies the operation to every item and reports a result per item, in request order.
+The request must already be validated with BatchRequest.Validate.
+*/
+func (u *userUseCase) BatchUsers(ctx context.Context, request domain.BatchRequest) domain.BatchResponse {
+	timer := time.Now()
+	defer func() {
+		metrics.UserBatchDuration.WithLabelValues(string(request.Operation), string(request.Mode)).Observe(time.Since(timer).Seconds())
+	}()
+
+	items, invalid := prepareBatchItems(request.Operation, request.Items, timer)
+
+	var results []domain.BatchItemResult
+	if request.Mode == domain.BatchAtomic {
+		results = u.runAtomicBatch(ctx, request.Operation, items, invalid)
+	} else {
+		results = u.runBestEffortBatch(ctx, request.Operation, items, invalid)
+	}
+
+	response := domain.BatchResponse{Operation: request.Operation, Mode: request.Mode, Results: results}
+	for _, result := range results {
+		if result.Error == nil {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	metrics.UserBatchItems.WithLabelValues(string(request.Operation), "succeeded").Add(float64(response.Succeeded))
+	metrics.UserBatchItems.WithLabelValues(string(request.Operation), "failed").Add(float64(response.Failed))
+
+	u.logger.Info(fmt.Sprintf("User batch %s completed. Mode: %s, succeeded: %d, failed: %d",
+		request.Operation, request.Mode, response.Succeeded, response.Failed))
+	return response
+}
+
+func (u *userUseCase) runAtomicBatch(ctx context.Context, operation domain.BatchOperation, items []domain.User, invalid map[int]*domain.AppError) []domain.BatchItemResult {
+	results := make([]domain.BatchItemResult, len(items))
+
+	if len(invalid) > 0 {
+		for i := range items {
+			if err, ok := invalid[i]; ok {
+				results[i] = failedItem(i, err)
+			} else {
+				results[i] = failedItem(i, domain.NewBatchAbortedError("Batch was not applied because other items are invalid."))
+			}
+		}
+		return results
+	}
+
+	failedIndex := -1
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		// Reset by every attempt, the transaction manager may retry.
+		failedIndex = -1
+		if operation == domain.BatchCreate {
+			createdUsers, err := u.createUsers(ctx, items)
+			if err != nil {
+				return err
+			}
+			for i, user := range createdUsers {
+				results[i] = succeededItem(i, operation, user)
+			}
+			return nil
+		}
+
+		for i, item := range items {
+			user, err := u.applyBatchItem(ctx, operation, item)
+			if err != nil {
+				failedIndex = i
+				return err
+			}
+			results[i] = succeededItem(i, operation, user)
+		}
+		return nil
+	})
+	if err == nil {
+		return results
+	}
+
+	u.logger.Error(err.Message, zap.Error(err))
+	for i := range items {
+		// A failed bulk insert or commit can not be traced back to a single item.
+		if failedIndex == -1 || i == failedIndex {
+			results[i] = failedItem(i, err)
+		} else {
+			results[i] = failedItem(i, domain.NewBatchAbortedError(fmt.Sprintf("Batch was rolled back because item %d failed.", failedIndex)))
+		}
+	}
+	return results
+}
+
+func (u *userUseCase) runBestEffortBatch(ctx context.Context, operation domain.BatchOperation, items []domain.User, invalid map[int]*domain.AppError) []domain.BatchItemResult {
+	results := make([]domain.BatchItemResult, len(items))
+	for i, err := range invalid {
+		results[i] = failedItem(i, err)
+	}
+
+	if operation == domain.BatchCreate {
+		var validIndexes []int
+		var validUsers []domain.User
+		for i, item := range items {
+			if _, ok := invalid[i]; !ok {
+				validIndexes = append(validIndexes, i)
+				validUsers = append(validUsers, item)
+			}
+		}
+		if len(validUsers) == 0 {
+			return results
+		}
+
+		// The bulk insert is all or nothing, fall back to single inserts to find the failing items.
+		var createdUsers []domain.User
+		err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+			var err *domain.AppError
+			createdUsers, err = u.createUsers(ctx, validUsers)
+			return err
+		})
+		if err == nil {
+			for n, user := range createdUsers {
+				results[validIndexes[n]] = succeededItem(validIndexes[n], operation, user)
+			}
+			return results
+		}
+		u.logger.Warn("Bulk insert failed, inserting users one by one.", zap.Error(err))
+	}
+
+	for i, item := range items {
+		if _, ok := invalid[i]; ok {
+			continue
+		}
+		var user domain.User
+		err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+			var err *domain.AppError
+			user, err = u.applyBatchItem(ctx, operation, item)
+			return err
+		})
+		if err != nil {
+			u.logger.Error(err.Message, zap.Error(err))
+			results[i] = failedItem(i, err)
+			continue
+		}
+		results[i] = succeededItem(i, operation, user)
+	}
+	return results
+}
+
+// prepareBatchItems fills the server side fields of the items and validates them, invalid items are returned by index.
+func prepareBatchItems(operation domain.BatchOperation, items []domain.User, now time.Time) ([]domain.User, map[int]*domain.AppError) {
+	prepared := make([]domain.User, len(items))
+	invalid := make(map[int]*domain.AppError)
+	for i, item := range items {
+		var err *domain.AppError
+		switch operation {
+		case domain.BatchCreate:
+			item = domain.PrepareNewUser(domain.User{Name: item.Name, Age: item.Age, Email: item.Email, Status: item.Status}, now)
+			err = domain.ValidateUser(item, domain.OperationCreate)
+		case domain.BatchUpdate:
+			err = domain.ValidateUser(item, domain.OperationUpdate)
+		case domain.BatchDelete:
+			if item.ID == 0 {
+				err = domain.NewValidationError("Validation failed.").WithDetails([]domain.FieldViolation{
+					{Field: "id", Rule: "required", Message: "is required"},
+				})
+			}
+		}
+		prepared[i] = item
+		if err != nil {
+			invalid[i] = err
+		}
+	}
+	return prepared, invalid
+}
+
+func (u *userUseCase) applyBatchItem(ctx context.Context, operation domain.BatchOperation, item domain.User) (domain.User, *domain.AppError) {
+	switch operation {
+	case domain.BatchCreate:
+		return u.createUser(ctx, item)
+	case domain.BatchUpdate:
+		return u.updateUser(ctx, item)
+	default:
+		return item, u.deleteUser(ctx, item.ID, item.Version)
+	}
+}
+
+func succeededItem(index int, operation domain.BatchOperation, user domain.User) domain.BatchItemResult {
+	switch operation {
+	case domain.BatchCreate:
+		return domain.BatchItemResult{Index: index, Status: http.StatusCreated, User: &user}
+	case domain.BatchUpdate:
+		return domain.BatchItemResult{Index: index, Status: http.StatusOK, User: &user}
+	default:
+		return domain.BatchItemResult{Index: index, Status: http.StatusNoContent}
+	}
+}
+
+func failedItem(index int, err *domain.AppError) domain.BatchItemResult {
+	problem := err.Problem("")
+	return domain.BatchItemResult{Index: index, Status: err.Status, Error: &problem}
+}
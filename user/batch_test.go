@@ -0,0 +1,135 @@
+package user
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"net/http"
+	"testing"
+)
+
+func Test_Should_Create_Users_In_Atomic_Batch(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	request := domain.BatchRequest{
+		Operation: domain.BatchCreate,
+		Mode:      domain.BatchAtomic,
+		Items:     []domain.User{{Name: "first", Age: 20, Email: "first@example.com"}, {Name: "second", Age: 30, Email: "second@example.com"}},
+	}
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Len(2)).DoAndReturn(func(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+		for i := range users {
+			users[i].ID = uint(i + 1)
+		}
+		return users, nil
+	})
+	response := _userUseCase.BatchUsers(context.Background(), request)
+
+	// THEN
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, 0, response.Failed)
+	assert.Equal(t, 201, response.Results[1].Status)
+	assert.Equal(t, uint(2), response.Results[1].User.ID)
+}
+
+func Test_Should_Abort_Atomic_Batch_When_Item_Is_Invalid(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	request := domain.BatchRequest{
+		Operation: domain.BatchCreate,
+		Mode:      domain.BatchAtomic,
+		Items:     []domain.User{{Name: "first", Age: 20, Email: "first@example.com"}, {Name: "x", Age: 30, Email: "x@example.com"}},
+	}
+
+	// WHEN
+	response := _userUseCase.BatchUsers(context.Background(), request)
+
+	// THEN
+	assert.Equal(t, 0, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.Equal(t, domain.ErrCodeBatchAborted, response.Results[0].Error.Code)
+	assert.Equal(t, domain.ErrCodeValidationFailed, response.Results[1].Error.Code)
+}
+
+func Test_Should_Reject_Batch_Items_Created_Suspended_Or_Deactivated(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	request := domain.BatchRequest{
+		Operation: domain.BatchCreate,
+		Mode:      domain.BatchBestEffort,
+		Items: []domain.User{
+			{Name: "pending", Age: 20, Status: domain.UserPending},
+			{Name: "suspended", Age: 30, Status: domain.UserSuspended},
+			{Name: "deactivated", Age: 40, Status: domain.UserDeactivated},
+		},
+	}
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Len(1)).DoAndReturn(func(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+		return users, nil
+	})
+	response := _userUseCase.BatchUsers(context.Background(), request)
+
+	// THEN
+	assert.Equal(t, 1, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.Equal(t, http.StatusBadRequest, response.Results[1].Status)
+	assert.Equal(t, domain.ErrCodeValidationFailed, response.Results[1].Error.Code)
+	assert.Equal(t, http.StatusBadRequest, response.Results[2].Status)
+}
+
+func Test_Should_Roll_Back_Atomic_Batch_When_Item_Fails(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	request := domain.BatchRequest{
+		Operation: domain.BatchDelete,
+		Mode:      domain.BatchAtomic,
+		Items:     []domain.User{{ID: 1}, {ID: 2, Version: 3}},
+	}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(domain.User{ID: 1, Name: "first", Age: 20, Version: 1}, nil)
+	_userMockRepo.EXPECT().DeleteUserById(gomock.Any(), uint(1), domain.AnyVersion).Return(nil)
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), uint(2)).Return(domain.User{}, domain.NewUserNotFoundError(2))
+	response := _userUseCase.BatchUsers(context.Background(), request)
+
+	// THEN
+	assert.Equal(t, 0, response.Succeeded)
+	assert.Equal(t, 424, response.Results[0].Status)
+	assert.Equal(t, 404, response.Results[1].Status)
+}
+
+func Test_Should_Insert_One_By_One_When_Best_Effort_Bulk_Insert_Fails(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	request := domain.BatchRequest{
+		Operation: domain.BatchCreate,
+		Mode:      domain.BatchBestEffort,
+		Items:     []domain.User{{Name: "first", Age: 20, Email: "first@example.com"}, {Name: "second", Age: 30, Email: "second@example.com"}, {Name: "third", Age: 0, Email: "third@example.com"}},
+	}
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Len(2)).Return(nil, domain.NewUserAlreadyExistError("User already exists."))
+	_userMockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		if user.Name == "first" {
+			return domain.User{}, domain.NewUserAlreadyExistError("User already exists.")
+		}
+		user.ID = 2
+		return user, nil
+	}).Times(2)
+	response := _userUseCase.BatchUsers(context.Background(), request)
+
+	// THEN
+	assert.Equal(t, 1, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.Equal(t, 409, response.Results[0].Status)
+	assert.Equal(t, 201, response.Results[1].Status)
+	assert.Equal(t, 400, response.Results[2].Status)
+}
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go-app/domain"
+	"strconv"
+	"strings"
+)
+
+func userETag(user domain.User) string {
+	return fmt.Sprintf(`W/"%d"`, user.Version)
+}
+
+/*
+ifMatchVersion reads the expected user version from the If-Match header.
+Updates and deletes must send it, "*" matches any version.
+*/
+func ifMatchVersion(c *gin.Context) (uint, *domain.AppError) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, domain.NewPreconditionRequiredError("If-Match header is required.")
+	}
+	if header == "*" {
+		return domain.AnyVersion, nil
+	}
+
+	version, ok := parseETag(header)
+	if !ok {
+		return 0, domain.NewBadRequestError("Invalid If-Match header: " + header)
+	}
+	return version, nil
+}
+
+// ifNoneMatch reports whether one of the tags in the If-None-Match header matches the user, using weak comparison.
+func ifNoneMatch(c *gin.Context, user domain.User) bool {
+	header := strings.TrimSpace(c.GetHeader("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := parseETag(tag); ok && version == user.Version {
+			return true
+		}
+	}
+	return false
+}
+
+func parseETag(tag string) (uint, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+
+	version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+	return uint(version), true
+}
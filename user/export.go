@@ -0,0 +1,177 @@
+package user
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/parquet-go/parquet-go"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rows buffered by the Parquet writer before a row group is flushed, it bounds the memory used by an export.
+const parquetRowGroupSize = 10000
+
+func (u *userUseCase) ExportUsers(ctx context.Context, filter domain.UserFilter, format domain.ExportFormat, w io.Writer) *domain.AppError {
+	timer := time.Now()
+	metrics.UserExportsInProgress.Inc()
+	defer metrics.UserExportsInProgress.Dec()
+
+	encoder := newUserEncoder(format, w)
+	exportedRows := metrics.UserExportRows.WithLabelValues(string(format))
+
+	var count int
+	err := u.repo.StreamUsers(ctx, filter, func(user domain.User) error {
+		if err := encoder.Encode(user); err != nil {
+			return err
+		}
+		count++
+		exportedRows.Inc()
+		return nil
+	})
+	if err == nil {
+		if closeErr := encoder.Close(); closeErr != nil {
+			err = domain.NewUnexpectedError("User export could not be completed.").WithCause(closeErr)
+		}
+	}
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	metrics.UserExportDuration.WithLabelValues(string(format), status).Observe(time.Since(timer).Seconds())
+
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return err
+	}
+	u.logger.Info(fmt.Sprintf("Users exported. Format: %s, rows: %d", format, count))
+	return nil
+}
+
+type userEncoder interface {
+	Encode(user domain.User) error
+	// Close flushes the buffered rows, it must not be called after a failed export.
+	Close() error
+}
+
+func newUserEncoder(format domain.ExportFormat, w io.Writer) userEncoder {
+	switch format {
+	case domain.ExportNDJSON:
+		return &ndjsonUserEncoder{encoder: json.NewEncoder(w)}
+	case domain.ExportParquet:
+		return &parquetUserEncoder{writer: parquet.NewGenericWriter[exportRow](w, parquet.MaxRowsPerRowGroup(parquetRowGroupSize))}
+	default:
+		return &csvUserEncoder{writer: csv.NewWriter(w)}
+	}
+}
+
+type exportRow struct {
+	ID          uint64     `parquet:"id"`
+	UUID        string     `parquet:"uuid"`
+	Name        string     `parquet:"name"`
+	Age         int64      `parquet:"age"`
+	Email       string     `parquet:"email"`
+	Status      string     `parquet:"status"`
+	Roles       []string   `parquet:"roles,list"`
+	CreatedDate time.Time  `parquet:"created_date,timestamp"`
+	UpdatedAt   time.Time  `parquet:"updated_at,timestamp"`
+	DeletedAt   *time.Time `parquet:"deleted_at,optional"`
+	Version     uint64     `parquet:"version"`
+}
+
+func newExportRow(user domain.User) exportRow {
+	row := exportRow{
+		ID:          uint64(user.ID),
+		UUID:        user.UUID.String(),
+		Name:        user.Name,
+		Age:         int64(user.Age),
+		Email:       user.Email,
+		Status:      string(user.Status),
+		Roles:       user.Roles,
+		CreatedDate: user.CreatedDate,
+		UpdatedAt:   user.UpdatedAt,
+		Version:     uint64(user.Version),
+	}
+	if user.DeletedAt.Valid {
+		row.DeletedAt = &user.DeletedAt.Time
+	}
+	return row
+}
+
+// Roles are joined with ";" in a single CSV column.
+var csvHeader = []string{"id", "uuid", "name", "age", "email", "status", "roles", "created_date", "updated_at", "deleted_at", "version"}
+
+type csvUserEncoder struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (e *csvUserEncoder) Encode(user domain.User) error {
+	if !e.headerWritten {
+		if err := e.writer.Write(csvHeader); err != nil {
+			return err
+		}
+		e.headerWritten = true
+	}
+
+	row := newExportRow(user)
+	deletedAt := ""
+	if row.DeletedAt != nil {
+		deletedAt = row.DeletedAt.Format(time.RFC3339Nano)
+	}
+	return e.writer.Write([]string{
+		strconv.FormatUint(row.ID, 10),
+		row.UUID,
+		row.Name,
+		strconv.FormatInt(row.Age, 10),
+		row.Email,
+		row.Status,
+		strings.Join(row.Roles, ";"),
+		row.CreatedDate.Format(time.RFC3339Nano),
+		row.UpdatedAt.Format(time.RFC3339Nano),
+		deletedAt,
+		strconv.FormatUint(row.Version, 10),
+	})
+}
+
+func (e *csvUserEncoder) Close() error {
+	if !e.headerWritten {
+		if err := e.writer.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonUserEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonUserEncoder) Encode(user domain.User) error {
+	return e.encoder.Encode(user)
+}
+
+func (e *ndjsonUserEncoder) Close() error {
+	return nil
+}
+
+type parquetUserEncoder struct {
+	writer *parquet.GenericWriter[exportRow]
+}
+
+func (e *parquetUserEncoder) Encode(user domain.User) error {
+	_, err := e.writer.Write([]exportRow{newExportRow(user)})
+	return err
+}
+
+func (e *parquetUserEncoder) Close() error {
+	return e.writer.Close()
+}
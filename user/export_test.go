@@ -0,0 +1,99 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func mockStreamUsers(users ...domain.User) {
+	_userMockRepo.EXPECT().StreamUsers(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, filter domain.UserFilter, fn func(user domain.User) error) *domain.AppError {
+		for _, user := range users {
+			if err := fn(user); err != nil {
+				return domain.NewUnexpectedError("User stream was interrupted.").WithCause(err)
+			}
+		}
+		return nil
+	})
+}
+
+func Test_Should_Export_Users_As_CSV(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	createdDate := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	userUUID := uuid.MustParse("5f0c7a52-3d1e-4c3b-9a43-2f4f0b6c8e11")
+	var buffer bytes.Buffer
+
+	// WHEN
+	mockStreamUsers(domain.User{ID: 1, UUID: userUUID, Name: "John, Doe", Age: 30, Email: "john@example.com", Status: domain.UserActive,
+		Roles: domain.Roles{"admin", "viewer"}, CreatedDate: createdDate, UpdatedAt: createdDate, Version: 2})
+	err := _userUseCase.ExportUsers(context.Background(), domain.UserFilter{}, domain.ExportCSV, &buffer)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "id,uuid,name,age,email,status,roles,created_date,updated_at,deleted_at,version\n"+
+		"1,5f0c7a52-3d1e-4c3b-9a43-2f4f0b6c8e11,\"John, Doe\",30,john@example.com,active,admin;viewer,2024-03-01T10:00:00Z,2024-03-01T10:00:00Z,,2\n", buffer.String())
+}
+
+func Test_Should_Export_Header_Only_When_No_Users_Match(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	var buffer bytes.Buffer
+
+	// WHEN
+	mockStreamUsers()
+	err := _userUseCase.ExportUsers(context.Background(), domain.UserFilter{MinAge: 200}, domain.ExportCSV, &buffer)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "id,uuid,name,age,email,status,roles,created_date,updated_at,deleted_at,version\n", buffer.String())
+}
+
+func Test_Should_Export_Users_As_NDJSON(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	var buffer bytes.Buffer
+
+	// WHEN
+	mockStreamUsers(domain.User{ID: 1, Name: "first", Age: 30}, domain.User{ID: 2, Name: "second", Age: 40})
+	err := _userUseCase.ExportUsers(context.Background(), domain.UserFilter{}, domain.ExportNDJSON, &buffer)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 2, bytes.Count(buffer.Bytes(), []byte("\n")))
+	assert.Contains(t, buffer.String(), `"name":"second"`)
+}
+
+func Test_Should_Export_Users_As_Parquet(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	createdDate := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	var buffer bytes.Buffer
+
+	// WHEN
+	mockStreamUsers(
+		domain.User{ID: 1, Name: "first", Age: 30, Roles: domain.Roles{"admin"}, CreatedDate: createdDate, UpdatedAt: createdDate, Version: 1},
+		domain.User{ID: 2, Name: "second", Age: 40, Roles: domain.Roles{}, CreatedDate: createdDate, UpdatedAt: createdDate, DeletedAt: gorm.DeletedAt{Time: createdDate, Valid: true}, Version: 3},
+	)
+	err := _userUseCase.ExportUsers(context.Background(), domain.UserFilter{}, domain.ExportParquet, &buffer)
+
+	// THEN
+	assert.Nil(t, err)
+	rows, readErr := parquet.Read[exportRow](bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.Nil(t, readErr)
+	assert.Equal(t, []exportRow{
+		{ID: 1, UUID: uuid.Nil.String(), Name: "first", Age: 30, Roles: []string{"admin"}, CreatedDate: createdDate, UpdatedAt: createdDate, Version: 1},
+		{ID: 2, UUID: uuid.Nil.String(), Name: "second", Age: 40, Roles: []string{}, CreatedDate: createdDate, UpdatedAt: createdDate, DeletedAt: &createdDate, Version: 3},
+	}, rows)
+}
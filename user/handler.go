@@ -1,49 +1,77 @@
 package user
 
 import (
-	"errors"
+	"compress/gzip"
+	"fmt"
 	sentrygin "github.com/getsentry/sentry-go/gin"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go-app/domain"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
 )
 
 type Handler struct {
 	userUseCase domain.UserUseCase
 	logger      *zap.Logger
+	options     HandlerOptions
+}
+
+type HandlerOptions struct {
+	MaxBatchSize int
+	// Uploads larger than this many bytes are imported as a background job.
+	ImportAsyncThreshold int64
 }
 
-func NewUserHandler(userUseCase domain.UserUseCase, logger *zap.Logger) *Handler {
-	return &Handler{userUseCase: userUseCase, logger: logger}
+func NewUserHandler(userUseCase domain.UserUseCase, logger *zap.Logger, options HandlerOptions) *Handler {
+	return &Handler{userUseCase: userUseCase, logger: logger, options: options}
 }
 
 // CreateUser godoc
 // @Summary Create User
-// @Description Create User.
+// @Description Create User. A user starts active or pending, roles are set through the roles endpoint.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body domain.User true "User to be created"
+// @Param Idempotency-Key header string false "Key to safely retry the request"
 // @Success 201 {object} domain.User "Returns created user"
-// @Success 400 {object} domain.AppError "Returns error"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 409 {object} domain.ProblemDetails "Request with the same key is in progress"
+// @Success 422 {object} domain.ProblemDetails "Key was used for a different request"
 // @Router /api/v1/users [post]
 func (h *Handler) CreateUser(c *gin.Context) {
 	if hub := sentrygin.GetHubFromContext(c); hub != nil {
 		var user domain.User
 
 		if c.ShouldBind(&user) != nil {
-			c.JSON(400, domain.NewBadRequestError("bad request"))
+			errorResponse(c, domain.NewBadRequestError("bad request"))
 			return
 		}
 
-		createUser, err := h.userUseCase.CreateUser(user)
+		if err := domain.ValidateUser(user, domain.OperationCreate); err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		createUser, err := h.userUseCase.CreateUser(c.Request.Context(), user)
 		if err != nil {
-			hub.CaptureException(errors.New(err.Message))
-			c.JSON(err.Code, err.AsMessageError())
+			hub.CaptureException(err)
+			errorResponse(c, err)
 			return
 		}
+		c.Header("ETag", userETag(createUser))
 		c.JSON(http.StatusCreated, createUser)
 	}
 }
@@ -54,19 +82,40 @@ func (h *Handler) CreateUser(c *gin.Context) {
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param id path int true "User ID"
+// @Param id path string true "User ID or UUID"
+// @Param include_deleted query bool false "Also return a soft deleted user, takes the users:admin scope"
+// @Param If-None-Match header string false "ETag of a cached user"
 // @Success 200 {object} domain.User "Returns user"
-// @Success 404 {object} domain.AppError "Returns error"
+// @Success 304 "User has not been modified"
+// @Success 403 {object} domain.ProblemDetails "Returns error when include_deleted is asked without the users:admin scope"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
 // @Router /api/v1/users/{id} [get]
 func (h *Handler) GetUserById(c *gin.Context) {
 	if hub := sentrygin.GetHubFromContext(c); hub != nil {
-		idParam := c.Param("id")
-		id, _ := strconv.ParseInt(idParam, 10, 64)
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
 
-		user, err := h.userUseCase.GetUserById(uint(id))
+		var user domain.User
+		if c.Query("include_deleted") == "true" {
+			if !domain.CallerHasScope(c.Request.Context(), domain.ScopeUsersAdmin) {
+				errorResponse(c, includeDeletedForbidden())
+				return
+			}
+			user, err = h.userUseCase.GetUserByIdIncludingDeleted(c.Request.Context(), id)
+		} else {
+			user, err = h.userUseCase.GetUserById(c.Request.Context(), id)
+		}
 		if err != nil {
-			hub.CaptureException(errors.New(err.Message))
-			c.JSON(err.Code, err.AsMessageError())
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.Header("ETag", userETag(user))
+		if ifNoneMatch(c, user) {
+			c.Status(http.StatusNotModified)
 			return
 		}
 		c.JSON(http.StatusOK, user)
@@ -75,52 +124,618 @@ func (h *Handler) GetUserById(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary Update User
-// @Description Update User.
+// @Description Replace all fields of an existing user.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Param id path string true "User ID or UUID"
+// @Param If-Match header string true "ETag of the user to be updated"
 // @Param user body domain.User true "User to be updated"
-// @Success 201 {object} domain.User "Returns updated user"
-// @Success 400 {object} domain.AppError "Returns error"
-// @Router /api/v1/users [put]
+// @Success 200 {object} domain.User "Returns updated user"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 412 {object} domain.ProblemDetails "Returns error"
+// @Success 428 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/users/{id} [put]
 func (h *Handler) UpdateUser(c *gin.Context) {
 	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
 		var user domain.User
 		if c.ShouldBind(&user) != nil {
-			c.JSON(400, domain.NewBadRequestError("bad request"))
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+		user.ID = id
+		user.Version = version
+
+		if err := domain.ValidateUser(user, domain.OperationUpdate); err != nil {
+			errorResponse(c, err)
+			return
 		}
 
-		updatedUser, err := h.userUseCase.UpdateUser(user)
+		updatedUser, err := h.userUseCase.UpdateUser(c.Request.Context(), user)
 		if err != nil {
-			hub.CaptureException(errors.New(err.Message))
-			c.JSON(err.Code, err.AsMessageError())
+			hub.CaptureException(err)
+			errorResponse(c, err)
 			return
 		}
+		c.Header("ETag", userETag(updatedUser))
 		c.JSON(http.StatusOK, updatedUser)
 	}
 }
 
+// PatchUser godoc
+// @Summary Patch User
+// @Description Partially update a user with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) document.
+// @Tags users
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path string true "User ID or UUID"
+// @Param If-Match header string true "ETag of the user to be patched"
+// @Param patch body object true "Patch document"
+// @Success 200 {object} domain.User "Returns patched user"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 412 {object} domain.ProblemDetails "Returns error"
+// @Success 415 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/users/{id} [patch]
+func (h *Handler) PatchUser(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		patchType := domain.PatchType(c.ContentType())
+		if patchType == "application/json" {
+			patchType = domain.MergePatch
+		}
+		if patchType != domain.MergePatch && patchType != domain.JSONPatch {
+			errorResponse(c, domain.NewUnsupportedMediaTypeError("Content-Type must be application/merge-patch+json or application/json-patch+json."))
+			return
+		}
+
+		patch, readErr := io.ReadAll(c.Request.Body)
+		if readErr != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		patchedUser, err := h.userUseCase.PatchUser(c.Request.Context(), id, version, patchType, patch)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.Header("ETag", userETag(patchedUser))
+		c.JSON(http.StatusOK, patchedUser)
+	}
+}
+
 // DeleteUserById godoc
 // @Summary Delete a user by ID
 // @Description Delete a user using their ID from the database.
 // @Tags users
 // @Accept json
 // @Produce json
-// @Param id path int true "User ID"
+// @Param id path string true "User ID or UUID"
+// @Param If-Match header string true "ETag of the user to be deleted"
 // @Success 204
-// @Success 500 {object} domain.AppError "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 412 {object} domain.ProblemDetails "Returns error"
+// @Success 500 {object} domain.ProblemDetails "Returns error"
 // @Router /api/v1/users/{id} [delete]
 func (h *Handler) DeleteUserById(c *gin.Context) {
 	if hub := sentrygin.GetHubFromContext(c); hub != nil {
-		idParam := c.Param("id")
-		id, _ := strconv.ParseInt(idParam, 10, 64)
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
 
-		err := h.userUseCase.DeleteUserById(uint(id))
+		version, err := ifMatchVersion(c)
 		if err != nil {
-			hub.CaptureException(errors.New(err.Message))
-			c.JSON(err.Code, err.AsMessageError())
+			errorResponse(c, err)
+			return
+		}
+
+		err = h.userUseCase.DeleteUserById(c.Request.Context(), id, version)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
 			return
 		}
 		c.Status(http.StatusNoContent)
 	}
 }
+
+// RestoreUserById godoc
+// @Summary Restore a deleted user by ID
+// @Description Restore a soft deleted user before it is purged.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID or UUID"
+// @Success 200 {object} domain.User "Returns restored user"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/users/{id}/restore [post]
+func (h *Handler) RestoreUserById(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		user, err := h.userUseCase.RestoreUserById(c.Request.Context(), id)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.Header("ETag", userETag(user))
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+// GetUserHistory godoc
+// @Summary Audit trail of a user
+// @Description Changes of a user, newest first, with who made them and from where.
+// @Description With as_of only the changes made until then are listed and the user is returned as it was at that time.
+// @Description The actor is the authenticated subject, without authentication it is the unverified X-Actor header.
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID or UUID"
+// @Param before query int false "next_before of the previous page"
+// @Param limit query int false "Maximum number of entries, 50 by default, at most 500"
+// @Param as_of query string false "RFC 3339 time to rebuild the user at"
+// @Success 200 {object} domain.UserHistory "Returns a page of the audit trail"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/users/{id}/history [get]
+func (h *Handler) GetUserHistory(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		query := domain.HistoryQuery{Limit: defaultHistoryLimit}
+		if value := c.Query("before"); value != "" {
+			before, parseErr := strconv.ParseUint(value, 10, 64)
+			if parseErr != nil {
+				errorResponse(c, domain.NewBadRequestError("Invalid before: "+value))
+				return
+			}
+			query.Before = before
+		}
+		if value := c.Query("limit"); value != "" {
+			limit, parseErr := strconv.Atoi(value)
+			if parseErr != nil || limit < 1 || limit > maxHistoryLimit {
+				errorResponse(c, domain.NewBadRequestError("Invalid limit: "+value))
+				return
+			}
+			query.Limit = limit
+		}
+		if value := c.Query("as_of"); value != "" {
+			asOf, parseErr := time.Parse(time.RFC3339, value)
+			if parseErr != nil {
+				errorResponse(c, domain.NewBadRequestError("Invalid as_of, expected an RFC 3339 time: "+value))
+				return
+			}
+			query.AsOf = asOf
+		}
+
+		history, err := h.userUseCase.GetUserHistory(c.Request.Context(), id, query)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		if history.Entries == nil {
+			history.Entries = []domain.AuditEntry{}
+		}
+		c.JSON(http.StatusOK, history)
+	}
+}
+
+// SetUserRoles godoc
+// @Summary Set the roles of a user
+// @Description Replace all roles of a user, the other user endpoints keep them. Takes the users:admin scope, the endpoint is absent while authentication is off.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID or UUID"
+// @Param If-Match header string true "ETag of the user to be changed"
+// @Param change body domain.RolesChange true "Roles of the user"
+// @Success 200 {object} domain.User "Returns changed user"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 403 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 412 {object} domain.ProblemDetails "Returns error"
+// @Success 428 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/users/{id}/roles [put]
+func (h *Handler) SetUserRoles(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		var change domain.RolesChange
+		if c.ShouldBindJSON(&change) != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		user, err := h.userUseCase.SetUserRoles(c.Request.Context(), id, version, change.Roles)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.Header("ETag", userETag(user))
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+// ActivateUser godoc
+// @Summary Activate a user
+// @Description Move a pending or suspended user to the active status.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID or UUID"
+// @Param change body domain.StatusChange false "Reason and actor of the change"
+// @Success 200 {object} domain.User "Returns activated user"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 409 {object} domain.ProblemDetails "Status does not allow the transition"
+// @Router /api/v1/users/{id}/activate [post]
+func (h *Handler) ActivateUser(c *gin.Context) {
+	h.transitionUser(c, domain.TransitionActivate)
+}
+
+// SuspendUser godoc
+// @Summary Suspend a user
+// @Description Move an active user to the suspended status, a reason is required.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID or UUID"
+// @Param change body domain.StatusChange true "Reason and actor of the change"
+// @Success 200 {object} domain.User "Returns suspended user"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 409 {object} domain.ProblemDetails "Status does not allow the transition"
+// @Router /api/v1/users/{id}/suspend [post]
+func (h *Handler) SuspendUser(c *gin.Context) {
+	h.transitionUser(c, domain.TransitionSuspend)
+}
+
+// DeactivateUser godoc
+// @Summary Deactivate a user
+// @Description Move a user to the deactivated status for good, a reason is required.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID or UUID"
+// @Param change body domain.StatusChange true "Reason and actor of the change"
+// @Success 200 {object} domain.User "Returns deactivated user"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Success 409 {object} domain.ProblemDetails "Status does not allow the transition"
+// @Router /api/v1/users/{id}/deactivate [post]
+func (h *Handler) DeactivateUser(c *gin.Context) {
+	h.transitionUser(c, domain.TransitionDeactivate)
+}
+
+func (h *Handler) transitionUser(c *gin.Context, transition domain.UserTransition) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := h.userId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		// The body is optional, a transition without a reason may be sent empty.
+		var change domain.StatusChange
+		if c.Request.ContentLength != 0 && c.ShouldBindJSON(&change) != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		user, err := h.userUseCase.TransitionUser(c.Request.Context(), id, transition, change)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.Header("ETag", userETag(user))
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+// BatchUsers godoc
+// @Summary Create, update or delete users in bulk
+// @Description Apply one operation to many users. Atomic batches are written in one transaction, best effort batches write every item that succeeds.
+// @Description Deletes only read the id and version of each item. Updates and deletes check the version of each item, version 0 skips the concurrency check like If-Match: *.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param batch body domain.BatchRequest true "Batch to be applied"
+// @Success 200 {object} domain.BatchResponse "Returns a result per item"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 413 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/users:batch [post]
+func (h *Handler) BatchUsers(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		var request domain.BatchRequest
+		if c.ShouldBindJSON(&request) != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		if err := request.Validate(h.options.MaxBatchSize); err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		response := h.userUseCase.BatchUsers(c.Request.Context(), request)
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ExportUsers godoc
+// @Summary Export users
+// @Description Stream the users matching the filters as CSV, NDJSON or Parquet. The output is gzip compressed when the client accepts it.
+// @Tags users
+// @Produce text/csv,application/x-ndjson,application/vnd.apache.parquet
+// @Param format query string false "Export format" Enums(csv, ndjson, parquet) default(csv)
+// @Param name query string false "Part of the user name"
+// @Param status query string false "User status" Enums(active, suspended, pending)
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param created_after query string false "Created at or after (RFC 3339)"
+// @Param created_before query string false "Created before (RFC 3339)"
+// @Param include_deleted query bool false "Also export soft deleted users, takes the users:admin scope"
+// @Param Accept-Encoding header string false "gzip to compress the output"
+// @Success 200 {file} file "Returns users"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 403 {object} domain.ProblemDetails "Returns error when include_deleted is asked without the users:admin scope"
+// @Router /api/v1/users/export [get]
+func (h *Handler) ExportUsers(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		format := domain.ExportFormat(c.DefaultQuery("format", string(domain.ExportCSV)))
+		if !format.Valid() {
+			errorResponse(c, domain.NewBadRequestError("Invalid export format: "+string(format)))
+			return
+		}
+
+		var filter domain.UserFilter
+		if c.ShouldBindQuery(&filter) != nil {
+			errorResponse(c, domain.NewBadRequestError("Invalid user filter."))
+			return
+		}
+		if err := filter.Validate(); err != nil {
+			errorResponse(c, err)
+			return
+		}
+		if filter.IncludeDeleted && !domain.CallerHasScope(c.Request.Context(), domain.ScopeUsersAdmin) {
+			errorResponse(c, includeDeletedForbidden())
+			return
+		}
+
+		var w io.Writer = c.Writer
+		var gz *gzip.Writer
+		if strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			gz = gzip.NewWriter(c.Writer)
+			w = gz
+			c.Header("Content-Encoding", "gzip")
+			c.Header("Vary", "Accept-Encoding")
+		}
+		c.Header("Content-Type", format.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users.%s"`, format))
+		c.Status(http.StatusOK)
+
+		err := h.userUseCase.ExportUsers(c.Request.Context(), filter, format, w)
+		if err != nil {
+			hub.CaptureException(err)
+			// Once rows are sent the status can not change anymore, the client sees a truncated body.
+			if !c.Writer.Written() {
+				c.Writer.Header().Del("Content-Encoding")
+				c.Writer.Header().Del("Content-Disposition")
+				errorResponse(c, err)
+			}
+			return
+		}
+		if gz != nil {
+			if err := gz.Close(); err != nil {
+				h.logger.Error("User export could not be compressed.", zap.Error(err))
+			}
+		}
+	}
+}
+
+// ImportUsers godoc
+// @Summary Import users from a file
+// @Description Create users from an uploaded CSV (with name and age and optional email and status columns) or NDJSON file. Every row is validated and invalid rows are reported by line number.
+// @Description Files larger than the async threshold are imported as a background job, poll the Location header for its status.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV or NDJSON file"
+// @Param format formData string false "File format, taken from the file extension when empty" Enums(csv, ndjson)
+// @Param dry_run formData bool false "Only validate the rows"
+// @Param async formData bool false "Import in the background regardless of the file size"
+// @Success 200 {object} domain.ImportReport "Returns the import report"
+// @Success 202 {object} domain.ImportReport "Returns the pending import job"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 415 {object} domain.ProblemDetails "Returns error"
+// @Success 422 {object} domain.ImportReport "Returns the report of a file that could not be read"
+// @Router /api/v1/users/import [post]
+func (h *Handler) ImportUsers(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		fileHeader, formErr := c.FormFile("file")
+		if formErr != nil {
+			errorResponse(c, domain.NewBadRequestError("file is required."))
+			return
+		}
+
+		format, err := importFormat(c.PostForm("format"), fileHeader.Filename)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		dryRun := c.PostForm("dry_run") == "true"
+
+		file, openErr := fileHeader.Open()
+		if openErr != nil {
+			err := domain.NewUnexpectedError("Uploaded file could not be read.").WithCause(openErr)
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+
+		if fileHeader.Size <= h.options.ImportAsyncThreshold && c.PostForm("async") != "true" {
+			defer file.Close()
+			report := h.userUseCase.ImportUsers(c.Request.Context(), format, file, dryRun)
+			if report.Status == domain.ImportFailed {
+				c.JSON(http.StatusUnprocessableEntity, report)
+				return
+			}
+			c.JSON(http.StatusOK, report)
+			return
+		}
+
+		// The multipart files are removed when the request ends, the job reads its own copy.
+		jobFile, copyErr := copyToTempFile(file)
+		file.Close()
+		if copyErr != nil {
+			err := domain.NewUnexpectedError("Uploaded file could not be stored.").WithCause(copyErr)
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+
+		report := h.userUseCase.StartImportJob(c.Request.Context(), format, jobFile, dryRun)
+		c.Header("Location", "/api/v1/users/import/"+report.ID)
+		c.JSON(http.StatusAccepted, report)
+	}
+}
+
+// GetImportJob godoc
+// @Summary Get the status of an import job
+// @Description Returns the progress of a background import, the report is complete once the status is completed or failed.
+// @Tags users
+// @Produce json
+// @Param jobId path string true "Import job ID"
+// @Success 200 {object} domain.ImportReport "Returns the import report"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/users/import/{jobId} [get]
+func (h *Handler) GetImportJob(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		report, err := h.userUseCase.GetImportJob(c.Request.Context(), c.Param("jobId"))
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+func includeDeletedForbidden() *domain.AppError {
+	return domain.NewForbiddenError(fmt.Sprintf("include_deleted takes the %s scope.", domain.ScopeUsersAdmin))
+}
+
+func errorResponse(c *gin.Context, err *domain.AppError) {
+	c.Header("Content-Type", domain.ProblemContentType)
+	if err.Retryable {
+		c.Header("Retry-After", "1")
+	}
+	c.JSON(err.Status, err.Problem(c.Request.URL.Path))
+}
+
+func importFormat(format string, filename string) (domain.ImportFormat, *domain.AppError) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			format = string(domain.ImportCSV)
+		case ".ndjson", ".jsonl":
+			format = string(domain.ImportNDJSON)
+		}
+	}
+
+	switch domain.ImportFormat(format) {
+	case domain.ImportCSV, domain.ImportNDJSON:
+		return domain.ImportFormat(format), nil
+	default:
+		return "", domain.NewUnsupportedMediaTypeError("Import file must be CSV or NDJSON.")
+	}
+}
+
+// tempFile removes the file from disk when it is closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	defer os.Remove(f.Name())
+	return f.File.Close()
+}
+
+func copyToTempFile(r io.Reader) (io.ReadCloser, error) {
+	file, err := os.CreateTemp("", "user-import-*")
+	if err != nil {
+		return nil, err
+	}
+	tmp := tempFile{File: file}
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// userId reads the id path parameter, a UUID is resolved to the numeric ID of the user.
+func (h *Handler) userId(c *gin.Context) (uint, *domain.AppError) {
+	param := c.Param("id")
+	if id, err := strconv.ParseUint(param, 10, 64); err == nil && id != 0 {
+		return uint(id), nil
+	}
+	userUUID, err := uuid.Parse(param)
+	if err != nil {
+		return 0, domain.NewBadRequestError("Invalid user ID: " + param)
+	}
+	return h.userUseCase.ResolveUserId(c.Request.Context(), userUUID)
+}
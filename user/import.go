@@ -0,0 +1,283 @@
+package user
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Valid rows collected before they are inserted with CreateUsers.
+	importBatchSize = 500
+	// Row errors kept in a report, the rest are only counted.
+	maxImportErrors = 1000
+	// Longest NDJSON line accepted.
+	maxImportLineSize = 1024 * 1024
+)
+
+func (u *userUseCase) ImportUsers(ctx context.Context, format domain.ImportFormat, r io.Reader, dryRun bool) domain.ImportReport {
+	report := domain.ImportReport{Status: domain.ImportRunning, DryRun: dryRun, Errors: []domain.ImportRowError{}, CreatedAt: time.Now()}
+	u.runImport(ctx, format, r, &report, func(report domain.ImportReport) {})
+	return report
+}
+
+func (u *userUseCase) StartImportJob(ctx context.Context, format domain.ImportFormat, r io.ReadCloser, dryRun bool) domain.ImportReport {
+	report := domain.ImportReport{
+		ID:        uuid.NewString(),
+		Status:    domain.ImportPending,
+		DryRun:    dryRun,
+		Errors:    []domain.ImportRowError{},
+		CreatedAt: time.Now(),
+	}
+	u.importJobs.put(report)
+
+	// The job outlives the request, it keeps the context values but not the cancellation.
+	ctx = context.WithoutCancel(ctx)
+	job := report
+	go func() {
+		defer r.Close()
+		job.Status = domain.ImportRunning
+		u.importJobs.put(job)
+		u.runImport(ctx, format, r, &job, u.importJobs.put)
+	}()
+	return report
+}
+
+func (u *userUseCase) GetImportJob(ctx context.Context, id string) (domain.ImportReport, *domain.AppError) {
+	report, ok := u.importJobs.get(id)
+	if !ok {
+		return report, domain.NewNotFoundError("Import job not found, ID: " + id)
+	}
+	return report, nil
+}
+
+// runImport reads the rows, validates them and inserts the valid ones in batches. progress is called after every batch.
+func (u *userUseCase) runImport(ctx context.Context, format domain.ImportFormat, r io.Reader, report *domain.ImportReport, progress func(report domain.ImportReport)) {
+	timer := time.Now()
+	reader, err := newImportReader(format, r)
+
+	var batch []importRow
+	flush := func() {
+		if !report.DryRun {
+			u.insertImportBatch(ctx, batch, report)
+		} else {
+			report.ImportedRows += len(batch)
+		}
+		batch = batch[:0]
+		progress(*report)
+	}
+
+	for err == nil {
+		var row importRow
+		row, err = reader.next()
+		if err != nil {
+			break
+		}
+
+		report.TotalRows++
+		row.user = domain.PrepareNewUser(row.user, timer)
+		if row.err == nil {
+			row.err = domain.ValidateUser(row.user, domain.OperationCreate)
+		}
+		if row.err != nil {
+			addImportError(report, row.line, row.err)
+			continue
+		}
+
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			flush()
+		}
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+
+	finishedAt := time.Now()
+	report.FinishedAt = &finishedAt
+	report.Status = domain.ImportCompleted
+	if !errors.Is(err, io.EOF) {
+		report.Status = domain.ImportFailed
+		report.Error = err.Error()
+		u.logger.Error("User import failed.", zap.Error(err))
+	}
+	progress(*report)
+
+	if !report.DryRun {
+		metrics.UserImportRows.WithLabelValues("imported").Add(float64(report.ImportedRows))
+	}
+	metrics.UserImportRows.WithLabelValues("failed").Add(float64(report.FailedRows))
+	u.logger.Info(fmt.Sprintf("User import %s. Dry run: %t, rows: %d, imported: %d, failed: %d",
+		report.Status, report.DryRun, report.TotalRows, report.ImportedRows, report.FailedRows))
+}
+
+func (u *userUseCase) insertImportBatch(ctx context.Context, batch []importRow, report *domain.ImportReport) {
+	users := make([]domain.User, len(batch))
+	for i, row := range batch {
+		users[i] = row.user
+	}
+
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		_, err := u.createUsers(ctx, users)
+		return err
+	})
+	if err == nil {
+		report.ImportedRows += len(batch)
+		return
+	}
+
+	u.logger.Warn("Bulk insert failed, inserting users one by one.", zap.Error(err))
+	// The bulk insert is all or nothing, single inserts find the failing lines.
+	for _, row := range batch {
+		err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+			_, err := u.createUser(ctx, row.user)
+			return err
+		})
+		if err != nil {
+			addImportError(report, row.line, err)
+			continue
+		}
+		report.ImportedRows++
+	}
+}
+
+func addImportError(report *domain.ImportReport, line int, err *domain.AppError) {
+	report.FailedRows++
+	if len(report.Errors) == maxImportErrors {
+		report.ErrorsTruncated = true
+		return
+	}
+	report.Errors = append(report.Errors, domain.ImportRowError{Line: line, Message: err.Message, Details: err.Details})
+}
+
+type importRow struct {
+	line int
+	user domain.User
+	// err is set when the row could not be parsed.
+	err *domain.AppError
+}
+
+type importReader interface {
+	// next returns io.EOF after the last row, any other error stops the import.
+	next() (importRow, error)
+}
+
+func newImportReader(format domain.ImportFormat, r io.Reader) (importReader, error) {
+	switch format {
+	case domain.ImportCSV:
+		return newCSVImportReader(r)
+	case domain.ImportNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+// csvImportReader reads files with a header row, only the name and age and the optional email and status columns are imported.
+type csvImportReader struct {
+	reader    *csv.Reader
+	nameCol   int
+	ageCol    int
+	emailCol  int
+	statusCol int
+}
+
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("CSV file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	csvReader := &csvImportReader{reader: reader, nameCol: -1, ageCol: -1, emailCol: -1, statusCol: -1}
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "name":
+			csvReader.nameCol = i
+		case "age":
+			csvReader.ageCol = i
+		case "email":
+			csvReader.emailCol = i
+		case "status":
+			csvReader.statusCol = i
+		}
+	}
+	if csvReader.nameCol == -1 || csvReader.ageCol == -1 {
+		return nil, errors.New("CSV header must contain name and age columns")
+	}
+	return csvReader, nil
+}
+
+func (c *csvImportReader) next() (importRow, error) {
+	record, err := c.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return importRow{line: parseErr.StartLine, err: domain.NewBadRequestError("Invalid CSV row: " + parseErr.Err.Error())}, nil
+	}
+	if err != nil {
+		return importRow{}, err
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	if len(record) <= c.nameCol || len(record) <= c.ageCol || len(record) <= c.emailCol || len(record) <= c.statusCol {
+		return importRow{line: line, err: domain.NewBadRequestError("Row has fewer columns than the header.")}, nil
+	}
+
+	age, convErr := strconv.Atoi(strings.TrimSpace(record[c.ageCol]))
+	if convErr != nil {
+		return importRow{line: line, err: domain.NewBadRequestError("age must be a number.")}, nil
+	}
+	user := domain.User{Name: record[c.nameCol], Age: age}
+	if c.emailCol != -1 {
+		user.Email = strings.TrimSpace(record[c.emailCol])
+	}
+	if c.statusCol != -1 {
+		user.Status = domain.UserStatus(strings.TrimSpace(record[c.statusCol]))
+	}
+	return importRow{line: line, user: user}, nil
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (n *ndjsonImportReader) next() (importRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		text := n.scanner.Bytes()
+		if len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+
+		var user domain.User
+		if err := json.Unmarshal(text, &user); err != nil {
+			return importRow{line: n.line, err: domain.NewBadRequestError("Invalid JSON: " + err.Error())}, nil
+		}
+		return importRow{line: n.line, user: domain.User{Name: user.Name, Age: user.Age, Email: user.Email, Status: user.Status}}, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return importRow{}, err
+	}
+	return importRow{}, io.EOF
+}
@@ -0,0 +1,45 @@
+package user
+
+import (
+	"go-app/domain"
+	"sync"
+	"time"
+)
+
+// Finished import jobs are kept this long for the status endpoint.
+const importJobRetention = 24 * time.Hour
+
+// importJobStore keeps the import job reports in memory, they do not survive a restart.
+type importJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]domain.ImportReport
+}
+
+func newImportJobStore() *importJobStore {
+	return &importJobStore{jobs: make(map[string]domain.ImportReport)}
+}
+
+func (s *importJobStore) put(report domain.ImportReport) {
+	// The job keeps appending to its errors, the stored report gets its own copy.
+	report.Errors = append([]domain.ImportRowError{}, report.Errors...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[report.ID] = report
+	s.prune(time.Now().Add(-importJobRetention))
+}
+
+func (s *importJobStore) get(id string) (domain.ImportReport, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	report, ok := s.jobs[id]
+	return report, ok
+}
+
+func (s *importJobStore) prune(finishedBefore time.Time) {
+	for id, report := range s.jobs {
+		if report.FinishedAt != nil && report.FinishedAt.Before(finishedBefore) {
+			delete(s.jobs, id)
+		}
+	}
+}
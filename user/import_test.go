@@ -0,0 +1,126 @@
+package user
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/domain"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Should_Import_Valid_CSV_Rows_And_Report_Invalid_Lines(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	file := "name,age,email\nJohn Doe,30,john@example.com\nx,20,x@example.com\nJane Doe,abc,jane@example.com\nMax Mustermann,40,max@example.com\n"
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Len(2)).Return(nil, nil)
+	report := _userUseCase.ImportUsers(context.Background(), domain.ImportCSV, strings.NewReader(file), false)
+
+	// THEN
+	assert.Equal(t, domain.ImportCompleted, report.Status)
+	assert.Equal(t, 4, report.TotalRows)
+	assert.Equal(t, 2, report.ImportedRows)
+	assert.Equal(t, 2, report.FailedRows)
+	assert.Equal(t, 3, report.Errors[0].Line)
+	assert.Equal(t, 4, report.Errors[1].Line)
+	assert.Equal(t, "age must be a number.", report.Errors[1].Message)
+}
+
+func Test_Should_Not_Insert_Users_When_Import_Is_Dry_Run(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	file := "{\"name\":\"John Doe\",\"age\":30,\"email\":\"john@example.com\"}\n\n{\"name\":\"Jane Doe\",\"age\":0,\"email\":\"jane@example.com\"}\nnot json\n"
+
+	// WHEN
+	report := _userUseCase.ImportUsers(context.Background(), domain.ImportNDJSON, strings.NewReader(file), true)
+
+	// THEN
+	assert.Equal(t, domain.ImportCompleted, report.Status)
+	assert.Equal(t, 3, report.TotalRows)
+	assert.Equal(t, 1, report.ImportedRows)
+	assert.Equal(t, []int{3, 4}, []int{report.Errors[0].Line, report.Errors[1].Line})
+}
+
+func Test_Should_Insert_Import_Rows_One_By_One_When_Batch_Fails(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	file := "age,name,email,status\n30,John Doe,john@example.com,pending\n40,Jane Doe,jane@example.com,active\n"
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Len(2)).Return(nil, domain.NewUserAlreadyExistError("User already exists."))
+	_userMockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		if user.Name == "Jane Doe" {
+			return user, domain.NewUserAlreadyExistError("User already exists.")
+		}
+		assert.Equal(t, domain.UserPending, user.Status)
+		return user, nil
+	}).Times(2)
+	report := _userUseCase.ImportUsers(context.Background(), domain.ImportCSV, strings.NewReader(file), false)
+
+	// THEN
+	assert.Equal(t, 1, report.ImportedRows)
+	assert.Equal(t, []domain.ImportRowError{{Line: 3, Message: "User already exists."}}, report.Errors)
+}
+
+func Test_Should_Report_CSV_Rows_Created_Suspended_Or_Deactivated(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	file := "name,age,status\nJohn Doe,30,pending\nJane Doe,40,suspended\nMax Mustermann,50,deactivated\n"
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Len(1)).Return(nil, nil)
+	report := _userUseCase.ImportUsers(context.Background(), domain.ImportCSV, strings.NewReader(file), false)
+
+	// THEN
+	assert.Equal(t, 1, report.ImportedRows)
+	assert.Equal(t, 2, report.FailedRows)
+	assert.Equal(t, []int{3, 4}, []int{report.Errors[0].Line, report.Errors[1].Line})
+	assert.Equal(t, []domain.FieldViolation{{Field: "status", Rule: "initial_status", Message: "must be one of: active pending"}}, report.Errors[0].Details)
+}
+
+func Test_Should_Fail_Import_When_CSV_Header_Is_Missing_Columns(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// WHEN
+	report := _userUseCase.ImportUsers(context.Background(), domain.ImportCSV, strings.NewReader("name,email\nJohn,john@example.com\n"), false)
+
+	// THEN
+	assert.Equal(t, domain.ImportFailed, report.Status)
+	assert.Equal(t, "CSV header must contain name and age columns", report.Error)
+}
+
+func Test_Should_Run_Import_Job_In_Background(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	file := io.NopCloser(strings.NewReader("name,age,email\nJohn Doe,30,john@example.com\n"))
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUsers(gomock.Any(), gomock.Len(1)).Return(nil, nil)
+	started := _userUseCase.StartImportJob(context.Background(), domain.ImportCSV, file, false)
+
+	// THEN
+	assert.Equal(t, domain.ImportPending, started.Status)
+	assert.Eventually(t, func() bool {
+		report, err := _userUseCase.GetImportJob(context.Background(), started.ID)
+		return err == nil && report.Status == domain.ImportCompleted && report.ImportedRows == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_Should_Return_Not_Found_For_Unknown_Import_Job(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// WHEN
+	_, err := _userUseCase.GetImportJob(context.Background(), "unknown")
+
+	// THEN
+	assert.Equal(t, 404, err.Status)
+}
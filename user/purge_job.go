@@ -0,0 +1,58 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"time"
+)
+
+// PurgeJob permanently removes users that have been soft deleted for longer than the retention period.
+type PurgeJob struct {
+	repo      domain.UserRepository
+	logger    *zap.Logger
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurgeJob(repo domain.UserRepository, logger *zap.Logger, retention time.Duration, interval time.Duration) *PurgeJob {
+	return &PurgeJob{repo: repo, logger: logger, retention: retention, interval: interval}
+}
+
+// Start runs the purge on every interval until the context is cancelled.
+func (j *PurgeJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.logger.Info("User purge job stopped.")
+			return
+		case <-ticker.C:
+			_, _ = j.RunOnce(ctx)
+		}
+	}
+}
+
+func (j *PurgeJob) RunOnce(ctx context.Context) (int64, *domain.AppError) {
+	timer := time.Now()
+	defer func() {
+		metrics.UserPurgeDuration.Observe(time.Since(timer).Seconds())
+	}()
+
+	purged, err := j.repo.PurgeDeletedUsers(ctx, timer.Add(-j.retention))
+	if err != nil {
+		metrics.UserPurgeRuns.WithLabelValues("error").Inc()
+		j.logger.Error(err.Message, zap.Error(err))
+		return 0, err
+	}
+
+	metrics.UserPurgeRuns.WithLabelValues("success").Inc()
+	metrics.UserPurgedCount.Add(float64(purged))
+	metrics.UserPurgeLastSuccess.SetToCurrentTime()
+	j.logger.Info(fmt.Sprintf("User purge completed. Purged: %d", purged))
+	return purged, nil
+}
@@ -0,0 +1,29 @@
+package user
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/domain"
+	"go-app/mocks"
+	"testing"
+	"time"
+)
+
+func Test_Should_Purge_Users_Deleted_Before_Retention(t *testing.T) {
+	c := gomock.NewController(t)
+	repo := mocks.NewMockUserRepository(c)
+	job := NewPurgeJob(repo, config.ZapTestConfig(), 24*time.Hour, time.Hour)
+
+	// WHEN
+	repo.EXPECT().PurgeDeletedUsers(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, deletedBefore time.Time) (int64, *domain.AppError) {
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), deletedBefore, time.Minute)
+		return 2, nil
+	})
+	purged, err := job.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), purged)
+}
@@ -1,58 +1,233 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"go-app/database"
 	"go-app/domain"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
+// Number of rows sent in a single INSERT by CreateUsers.
+const createBatchSize = 100
+
 //go:generate mockgen -destination=../mocks/mockUserRepository.go -package=mocks go-app/domain UserRepository
 type userRepository struct {
 	db *gorm.DB
+	// Case-insensitive LIKE condition on the name column, it depends on the database.
+	nameFilter string
 }
 
 func NewUserRepository(db *gorm.DB) domain.UserRepository {
-	return &userRepository{db: db}
+	return &userRepository{db: db, nameFilter: "name ILIKE ?"}
 }
 
-func (r *userRepository) CreateUser(user domain.User) (domain.User, *domain.AppError) {
-	err := r.db.Create(&user).Error
+func (r *userRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	assignUUID(&user)
+	err := r.conn(ctx).Create(&user).Error
 	if err != nil {
-		return user, domain.NewUnexpectedError(err.Error())
+		return user, translateError(err)
 	}
 	return user, nil
 }
 
-func (r *userRepository) GetUserById(id uint) (domain.User, *domain.AppError) {
+func (r *userRepository) CreateUsers(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+	for i := range users {
+		assignUUID(&users[i])
+	}
+	err := r.conn(ctx).CreateInBatches(&users, createBatchSize).Error
+	if err != nil {
+		return users, translateError(err)
+	}
+	return users, nil
+}
+
+func (r *userRepository) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
 	var user domain.User
 	// err := r.db.First(&user, id).Error
-	err := r.db.Where("id = ?", id).First(&user).Error
+	err := r.conn(ctx).Where("id = ?", id).First(&user).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		errStr := fmt.Sprintf("User not found, ID: %d", id)
-		return user, domain.NewNotFoundError(errStr)
+		return user, domain.NewUserNotFoundError(id)
 	}
 
 	if err != nil {
-		return user, domain.NewUnexpectedError(err.Error())
+		return user, translateError(err)
 	}
 
 	return user, nil
 }
 
-func (r *userRepository) UpdateUser(user domain.User) (domain.User, *domain.AppError) {
-	// err := r.db.WithContext(context.Background()).Model(user).Where("id = ?", user.ID).Update("name", user.Name).Error
-	err := r.db.Save(&user).Error
+func (r *userRepository) GetUserByIdIncludingDeleted(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	var user domain.User
+	err := r.conn(ctx).Unscoped().Where("id = ?", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, domain.NewUserNotFoundError(id)
+	}
+
 	if err != nil {
-		return user, domain.NewUnexpectedError(err.Error())
+		return user, translateError(err)
 	}
+
 	return user, nil
 }
 
-func (r *userRepository) DeleteUserById(id uint) *domain.AppError {
-	err := r.db.Delete(&domain.User{}, id).Error
+func (r *userRepository) GetUserIdByUUID(ctx context.Context, userUUID uuid.UUID) (uint, *domain.AppError) {
+	var user domain.User
+	err := r.conn(ctx).Unscoped().Select("id").Where("uuid = ?", userUUID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, domain.NewUserUUIDNotFoundError(userUUID.String())
+	}
+
 	if err != nil {
-		return domain.NewUnexpectedError(err.Error())
+		return 0, translateError(err)
+	}
+
+	return user.ID, nil
+}
+
+func (r *userRepository) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	// Save would insert a new row for an unknown ID, Updates only touches an existing one with the expected version.
+	expectedVersion := user.Version
+	user.Version++
+	result := r.conn(ctx).Model(&user).Where("version = ?", expectedVersion).Select("*").Updates(&user)
+	if result.Error != nil {
+		return user, translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return user, r.missingOrModified(ctx, user.ID)
+	}
+	return user, nil
+}
+
+func (r *userRepository) DeleteUserById(ctx context.Context, id uint, version uint) *domain.AppError {
+	query := r.conn(ctx)
+	if version != domain.AnyVersion {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.Delete(&domain.User{}, id)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		if version == domain.AnyVersion {
+			return domain.NewUserNotFoundError(id)
+		}
+		return r.missingOrModified(ctx, id)
+	}
+	return nil
+}
+
+func (r *userRepository) RestoreUserById(ctx context.Context, id uint) *domain.AppError {
+	result := r.conn(ctx).Unscoped().Model(&domain.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		// A new version, so an ETag from before the delete does not match the restored user.
+		Updates(map[string]any{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return domain.NewDeletedUserNotFoundError(id)
 	}
 	return nil
 }
+
+// PurgeDeletedUsers permanently removes the users that were soft deleted before the given time.
+func (r *userRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, *domain.AppError) {
+	result := r.conn(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Delete(&domain.User{})
+	if result.Error != nil {
+		return 0, translateError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// StreamUsers reads the users through a cursor, so memory does not grow with the number of rows.
+func (r *userRepository) StreamUsers(ctx context.Context, filter domain.UserFilter, fn func(user domain.User) error) *domain.AppError {
+	rows, err := r.applyUserFilter(r.conn(ctx).Model(&domain.User{}), filter).Order("id").Rows()
+	if err != nil {
+		return translateError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user domain.User
+		if err := r.db.ScanRows(rows, &user); err != nil {
+			return translateError(err)
+		}
+		if err := fn(user); err != nil {
+			return domain.NewUnexpectedError("User stream was interrupted.").WithCause(err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return translateError(err)
+	}
+	return nil
+}
+
+/*
+missingOrModified tells apart a missing user from a version conflict after a conditional write matched no rows.
+It reads from the primary, a replica may not have the latest write yet.
+*/
+func (r *userRepository) missingOrModified(ctx context.Context, id uint) *domain.AppError {
+	var count int64
+	if err := r.conn(database.WithPrimaryReads(ctx)).Model(&domain.User{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return translateError(err)
+	}
+	if count == 0 {
+		return domain.NewUserNotFoundError(id)
+	}
+	return domain.NewPreconditionFailedError(fmt.Sprintf("User has been modified, ID: %d", id))
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *userRepository) applyUserFilter(query *gorm.DB, filter domain.UserFilter) *gorm.DB {
+	if filter.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	if filter.Name != "" {
+		query = query.Where(r.nameFilter, "%"+likeEscaper.Replace(filter.Name)+"%")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.MinAge != 0 {
+		query = query.Where("age >= ?", filter.MinAge)
+	}
+	if filter.MaxAge != 0 {
+		query = query.Where("age <= ?", filter.MaxAge)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		query = query.Where("created_date >= ?", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		query = query.Where("created_date < ?", filter.CreatedBefore)
+	}
+	return query
+}
+
+// assignUUID gives a new user its external identifier, unless the caller already picked one.
+func assignUUID(user *domain.User) {
+	if user.UUID == uuid.Nil {
+		user.UUID = uuid.New()
+	}
+}
+
+// conn returns the transaction of the context when the call is part of one.
+func (r *userRepository) conn(ctx context.Context) *gorm.DB {
+	return database.Conn(ctx, r.db)
+}
+
+func translateError(err error) *domain.AppError {
+	appErr := database.TranslateError(err)
+	if appErr.Code == domain.ErrCodeConflict {
+		return domain.NewUserAlreadyExistError("User already exists.").WithCause(err)
+	}
+	return appErr
+}
@@ -0,0 +1,185 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-app/database"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"sync/atomic"
+	"time"
+)
+
+type CacheOptions struct {
+	TTL time.Duration
+	// How long a missing user is remembered, zero turns off the caching of misses.
+	NegativeTTL time.Duration
+}
+
+/*
+cachingUserRepository reads users by ID through a cache, the writes evict the users they touch once committed.
+The methods it does not override go straight to the wrapped repository.
+*/
+type cachingUserRepository struct {
+	domain.UserRepository
+	cache   domain.Cache
+	logger  *zap.Logger
+	options CacheOptions
+	loads   singleflight.Group
+	// evictions counts the evictions of this instance, a load that overlapped one does not store what it read.
+	evictions atomic.Uint64
+}
+
+type cacheLoad struct {
+	user domain.User
+	err  *domain.AppError
+}
+
+func NewCachingUserRepository(repo domain.UserRepository, cache domain.Cache, logger *zap.Logger, options CacheOptions) domain.UserRepository {
+	return &cachingUserRepository{UserRepository: repo, cache: cache, logger: logger, options: options}
+}
+
+func (r *cachingUserRepository) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	// A transaction or a read-your-writes request must see the database, not an older cached copy.
+	if database.InTx(ctx) || database.PrimaryReads(ctx) {
+		return r.UserRepository.GetUserById(ctx, id)
+	}
+
+	key := userCacheKey(id)
+	if user, err, found := r.lookup(ctx, key, id); found {
+		metrics.UserCacheRequests.WithLabelValues("hit").Inc()
+		return user, err
+	}
+	metrics.UserCacheRequests.WithLabelValues("miss").Inc()
+
+	// Concurrent misses of the same user share one load, it outlives the request that started it.
+	// It reads from the primary, a lagging replica would fill the cache with a user older than the last eviction.
+	result, _, _ := r.loads.Do(key, func() (interface{}, error) {
+		loadCtx := context.WithoutCancel(ctx)
+		evictions := r.evictions.Load()
+		user, err := r.UserRepository.GetUserById(database.WithPrimaryReads(loadCtx), id)
+		r.store(loadCtx, key, user, err, evictions)
+		return cacheLoad{user: user, err: err}, nil
+	})
+	load := result.(cacheLoad)
+	return load.user, load.err
+}
+
+func (r *cachingUserRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	createdUser, err := r.UserRepository.CreateUser(ctx, user)
+	if err == nil {
+		r.evict(ctx, createdUser.ID)
+	}
+	return createdUser, err
+}
+
+func (r *cachingUserRepository) CreateUsers(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+	createdUsers, err := r.UserRepository.CreateUsers(ctx, users)
+	if err == nil {
+		ids := make([]uint, len(createdUsers))
+		for i, user := range createdUsers {
+			ids[i] = user.ID
+		}
+		r.evict(ctx, ids...)
+	}
+	return createdUsers, err
+}
+
+// The writes below evict the user even when they fail, a version conflict hints the cached copy is stale.
+
+func (r *cachingUserRepository) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	defer r.evict(ctx, user.ID)
+	return r.UserRepository.UpdateUser(ctx, user)
+}
+
+func (r *cachingUserRepository) DeleteUserById(ctx context.Context, id uint, version uint) *domain.AppError {
+	defer r.evict(ctx, id)
+	return r.UserRepository.DeleteUserById(ctx, id, version)
+}
+
+func (r *cachingUserRepository) RestoreUserById(ctx context.Context, id uint) *domain.AppError {
+	defer r.evict(ctx, id)
+	return r.UserRepository.RestoreUserById(ctx, id)
+}
+
+// lookup returns found=false when the user is not cached or the cache fails, an empty value is a cached miss.
+func (r *cachingUserRepository) lookup(ctx context.Context, key string, id uint) (domain.User, *domain.AppError, bool) {
+	var user domain.User
+	value, found, err := r.cache.Get(ctx, key)
+	if err != nil {
+		r.logger.Warn(fmt.Sprintf("User cache read failed, reading from the database. ID: %d", id), zap.Error(err))
+		return user, nil, false
+	}
+	if !found {
+		return user, nil, false
+	}
+	if len(value) == 0 {
+		return user, domain.NewUserNotFoundError(id), true
+	}
+	if err := json.Unmarshal(value, &user); err != nil {
+		r.logger.Warn(fmt.Sprintf("Cached user is unreadable, reading from the database. ID: %d", id), zap.Error(err))
+		return user, nil, false
+	}
+	return user, nil, true
+}
+
+// store caches the result of a load, unless a user was evicted since the load started, evictions is the count it started with.
+func (r *cachingUserRepository) store(ctx context.Context, key string, user domain.User, loadErr *domain.AppError, evictions uint64) {
+	var value []byte
+	ttl := r.options.TTL
+	switch {
+	case loadErr == nil:
+		var err error
+		if value, err = json.Marshal(user); err != nil {
+			r.logger.Warn(fmt.Sprintf("User could not be cached. ID: %d", user.ID), zap.Error(err))
+			return
+		}
+	case loadErr.Code == domain.ErrCodeUserNotFound && r.options.NegativeTTL > 0:
+		ttl = r.options.NegativeTTL
+	default:
+		return
+	}
+
+	if r.evictions.Load() != evictions {
+		return
+	}
+	if err := r.cache.Set(ctx, key, value, ttl); err != nil {
+		r.logger.Warn(fmt.Sprintf("User cache write failed. Key: %s", key), zap.Error(err))
+		return
+	}
+	// An eviction between the check and the write may have deleted the key before it was written.
+	if r.evictions.Load() != evictions {
+		if err := r.cache.Delete(ctx, key); err != nil {
+			r.logger.Warn(fmt.Sprintf("User cache write could not be undone. Key: %s", key), zap.Error(err))
+		}
+	}
+}
+
+/*
+evict removes the users from the cache once the transaction of the context commits. Loads in flight may have read
+the users before the commit, they are forgotten so the next miss loads again, and they do not store what they read.
+*/
+func (r *cachingUserRepository) evict(ctx context.Context, ids ...uint) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = userCacheKey(id)
+	}
+	database.AfterCommit(ctx, func() {
+		r.evictions.Add(1)
+		for _, key := range keys {
+			r.loads.Forget(key)
+		}
+		if err := r.cache.Delete(context.WithoutCancel(ctx), keys...); err != nil {
+			r.logger.Error(fmt.Sprintf("User cache eviction failed, cached copies stay until they expire. Keys: %v", keys), zap.Error(err))
+			return
+		}
+		metrics.UserCacheInvalidations.Add(float64(len(keys)))
+	})
+}
+
+func userCacheKey(id uint) string {
+	return fmt.Sprintf("user:%d", id)
+}
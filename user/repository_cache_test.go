@@ -0,0 +1,208 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/cache"
+	"go-app/config"
+	"go-app/database"
+	"go-app/domain"
+	"go-app/mocks"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	_cachedMockRepo *mocks.MockUserRepository
+	_userCache      *cache.LRU
+	_cachingRepo    domain.UserRepository
+)
+
+func mockCachingRepositorySetup(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	_cachedMockRepo = mocks.NewMockUserRepository(c)
+	_userCache = cache.NewLRU(10)
+	_cachingRepo = NewCachingUserRepository(_cachedMockRepo, _userCache, config.ZapTestConfig(), CacheOptions{TTL: time.Minute, NegativeTTL: time.Minute})
+}
+
+func Test_Should_Read_User_Through_Cache(t *testing.T) {
+	mockCachingRepositorySetup(t)
+	ctx := context.Background()
+
+	// GIVEN
+	expectedUser := domain.User{ID: 1, Name: "test", Age: 18, Version: 3}
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(expectedUser, nil).Times(1)
+
+	// WHEN
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+	user, err := _cachingRepo.GetUserById(ctx, 1)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedUser, user)
+}
+
+func Test_Should_Cache_Missing_User(t *testing.T) {
+	mockCachingRepositorySetup(t)
+	ctx := context.Background()
+
+	// GIVEN
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(domain.User{}, domain.NewUserNotFoundError(1)).Times(1)
+
+	// WHEN
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+	_, err := _cachingRepo.GetUserById(ctx, 1)
+
+	// THEN
+	assert.Equal(t, domain.ErrCodeUserNotFound, err.Code)
+}
+
+func Test_Should_Not_Cache_Unexpected_Error(t *testing.T) {
+	mockCachingRepositorySetup(t)
+	ctx := context.Background()
+
+	// GIVEN
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(domain.User{}, domain.NewUnexpectedError("connection refused")).Times(2)
+
+	// WHEN
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+	_, err := _cachingRepo.GetUserById(ctx, 1)
+
+	// THEN
+	assert.Equal(t, domain.ErrCodeUnexpected, err.Code)
+	assert.Equal(t, 0, _userCache.Len())
+}
+
+func Test_Should_Collapse_Concurrent_Misses(t *testing.T) {
+	mockCachingRepositorySetup(t)
+
+	// GIVEN
+	release := make(chan struct{})
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).DoAndReturn(func(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+		<-release
+		return domain.User{ID: id, Name: "test", Age: 18}, nil
+	}).Times(1)
+
+	// WHEN
+	var wg sync.WaitGroup
+	users := make([]domain.User, 10)
+	for i := range users {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			users[i], _ = _cachingRepo.GetUserById(context.Background(), 1)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// THEN
+	for _, user := range users {
+		assert.Equal(t, "test", user.Name)
+	}
+}
+
+func Test_Should_Evict_User_After_Update_And_Delete(t *testing.T) {
+	mockCachingRepositorySetup(t)
+	ctx := context.Background()
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "test", Age: 18, Version: 1}
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(user, nil).Times(3)
+	_cachedMockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(user, nil)
+	_cachedMockRepo.EXPECT().DeleteUserById(gomock.Any(), uint(1), uint(1)).Return(domain.NewPreconditionFailedError("modified"))
+
+	// WHEN
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+	_, _ = _cachingRepo.UpdateUser(ctx, user)
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+	err := _cachingRepo.DeleteUserById(ctx, 1, 1)
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+
+	// THEN
+	assert.Equal(t, domain.ErrCodePreconditionFail, err.Code)
+}
+
+func Test_Should_Not_Store_Load_That_Overlapped_Eviction(t *testing.T) {
+	mockCachingRepositorySetup(t)
+	ctx := context.Background()
+
+	// GIVEN
+	stale := domain.User{ID: 1, Name: "stale", Age: 18, Version: 1}
+	updated := domain.User{ID: 1, Name: "updated", Age: 18, Version: 2}
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).DoAndReturn(func(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+		assert.True(t, database.PrimaryReads(ctx))
+		// The update commits while the load is in flight, after the load read the user.
+		_, _ = _cachingRepo.UpdateUser(context.Background(), updated)
+		return stale, nil
+	})
+	_cachedMockRepo.EXPECT().UpdateUser(gomock.Any(), updated).Return(updated, nil)
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(updated, nil)
+
+	// WHEN
+	first, _ := _cachingRepo.GetUserById(ctx, 1)
+	second, _ := _cachingRepo.GetUserById(ctx, 1)
+
+	// THEN
+	assert.Equal(t, "stale", first.Name)
+	assert.Equal(t, "updated", second.Name)
+}
+
+func Test_Should_Evict_Cached_Miss_After_Create(t *testing.T) {
+	mockCachingRepositorySetup(t)
+	ctx := context.Background()
+
+	// GIVEN
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(domain.User{}, domain.NewUserNotFoundError(1))
+	_cachedMockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(domain.User{ID: 1, Name: "test", Age: 18}, nil)
+
+	// WHEN
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+	_, _ = _cachingRepo.CreateUser(ctx, domain.User{ID: 1, Name: "test", Age: 18})
+
+	// THEN
+	assert.Equal(t, 0, _userCache.Len())
+}
+
+func Test_Should_Bypass_Cache_For_Primary_Reads(t *testing.T) {
+	mockCachingRepositorySetup(t)
+	ctx := database.WithPrimaryReads(context.Background())
+
+	// GIVEN
+	_cachedMockRepo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(domain.User{ID: 1, Name: "test", Age: 18}, nil).Times(2)
+
+	// WHEN
+	_, _ = _cachingRepo.GetUserById(ctx, 1)
+	user, err := _cachingRepo.GetUserById(ctx, 1)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), user.ID)
+	assert.Equal(t, 0, _userCache.Len())
+}
+
+func Test_Should_Read_From_Repository_When_Cache_Fails(t *testing.T) {
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	// GIVEN
+	repo := mocks.NewMockUserRepository(c)
+	failingCache := mocks.NewMockCache(c)
+	cachingRepo := NewCachingUserRepository(repo, failingCache, config.ZapTestConfig(), CacheOptions{TTL: time.Minute})
+	failingCache.EXPECT().Get(gomock.Any(), "user:1").Return(nil, false, errors.New("connection refused"))
+	failingCache.EXPECT().Set(gomock.Any(), "user:1", gomock.Any(), time.Minute).Return(errors.New("connection refused"))
+	repo.EXPECT().GetUserById(gomock.Any(), uint(1)).Return(domain.User{ID: 1, Name: "test", Age: 18}, nil)
+
+	// WHEN
+	user, err := cachingRepo.GetUserById(context.Background(), 1)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "test", user.Name)
+}
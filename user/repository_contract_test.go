@@ -0,0 +1,324 @@
+package user
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/database"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+/*
+runUserRepositoryContract checks the behaviour every domain.UserRepository must share.
+newRepo returns an empty repository for each case.
+*/
+func runUserRepositoryContract(t *testing.T, newRepo func(t *testing.T) domain.UserRepository) {
+	ctx := context.Background()
+	createdDate := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+
+	t.Run("Should_Create_And_Get_User", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// WHEN
+		created, err := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+		found, getErr := repo.GetUserById(ctx, created.ID)
+
+		// THEN
+		assert.Nil(t, err)
+		assert.Nil(t, getErr)
+		assert.NotZero(t, created.ID)
+		assert.Equal(t, uint(1), created.Version)
+		assert.Equal(t, "Ada", found.Name)
+		assert.True(t, createdDate.Equal(found.CreatedDate))
+		assert.NotEqual(t, uuid.Nil, found.UUID)
+		assert.Equal(t, created.UUID, found.UUID)
+		assert.Equal(t, domain.UserActive, found.Status)
+		assert.Equal(t, domain.Roles{}, found.Roles)
+		assert.False(t, found.UpdatedAt.IsZero())
+	})
+
+	t.Run("Should_Keep_Status_And_Roles", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// WHEN
+		created, err := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", Status: domain.UserPending,
+			Roles: domain.Roles{"admin", "viewer"}, CreatedDate: createdDate})
+		found, _ := repo.GetUserById(ctx, created.ID)
+
+		// THEN
+		assert.Nil(t, err)
+		assert.Equal(t, domain.UserPending, found.Status)
+		assert.Equal(t, domain.Roles{"admin", "viewer"}, found.Roles)
+		assert.Equal(t, "ada@example.com", found.Email)
+	})
+
+	t.Run("Should_Keep_Status_Change", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+		changedAt := createdDate.Add(time.Hour)
+
+		// WHEN
+		created.Status, created.StatusReason, created.StatusChangedAt, created.StatusChangedBy = domain.UserSuspended, "chargeback", &changedAt, "support"
+		_, err := repo.UpdateUser(ctx, created)
+		found, _ := repo.GetUserById(ctx, created.ID)
+
+		// THEN
+		assert.Nil(t, err)
+		assert.Equal(t, domain.UserSuspended, found.Status)
+		assert.Equal(t, "chargeback", found.StatusReason)
+		assert.Equal(t, "support", found.StatusChangedBy)
+		assert.True(t, changedAt.Equal(*found.StatusChangedAt))
+	})
+
+	t.Run("Should_Return_Already_Exists_For_Duplicate_Email", func(t *testing.T) {
+		repo := newRepo(t)
+		first, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+		second, _ := repo.CreateUser(ctx, domain.User{Name: "Grace", Age: 40, Email: "grace@example.com", CreatedDate: createdDate})
+		_ = repo.DeleteUserById(ctx, first.ID, domain.AnyVersion)
+
+		// WHEN
+		_, createErr := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+		second.Email = "ada@example.com"
+		_, updateErr := repo.UpdateUser(ctx, second)
+
+		// THEN
+		assert.Equal(t, domain.ErrCodeUserAlreadyExists, createErr.Code)
+		assert.Equal(t, domain.ErrCodeUserAlreadyExists, updateErr.Code)
+	})
+
+	t.Run("Should_Allow_Many_Users_Without_Email", func(t *testing.T) {
+		repo := newRepo(t)
+		_, _ = repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, CreatedDate: createdDate})
+
+		// WHEN
+		_, createErr := repo.CreateUser(ctx, domain.User{Name: "Grace", Age: 40, CreatedDate: createdDate})
+		created, createUsersErr := repo.CreateUsers(ctx, []domain.User{
+			{Name: "Linus", Age: 30, CreatedDate: createdDate},
+			{Name: "Ken", Age: 50, CreatedDate: createdDate},
+		})
+
+		// THEN
+		assert.Nil(t, createErr)
+		assert.Nil(t, createUsersErr)
+		assert.Len(t, created, 2)
+		assert.Equal(t, 4, len(streamAll(t, repo, domain.UserFilter{})))
+	})
+
+	t.Run("Should_Get_User_Id_By_UUID_Including_Deleted", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+		_ = repo.DeleteUserById(ctx, created.ID, domain.AnyVersion)
+
+		// WHEN
+		id, err := repo.GetUserIdByUUID(ctx, created.UUID)
+		_, unknownErr := repo.GetUserIdByUUID(ctx, uuid.New())
+
+		// THEN
+		assert.Nil(t, err)
+		assert.Equal(t, created.ID, id)
+		assert.Equal(t, domain.ErrCodeUserNotFound, unknownErr.Code)
+	})
+
+	t.Run("Should_Return_Not_Found_For_Unknown_User", func(t *testing.T) {
+		repo := newRepo(t)
+
+		// WHEN
+		_, err := repo.GetUserById(ctx, 42)
+		_, errIncludingDeleted := repo.GetUserByIdIncludingDeleted(ctx, 42)
+
+		// THEN
+		assert.Equal(t, domain.ErrCodeUserNotFound, err.Code)
+		assert.Equal(t, domain.ErrCodeUserNotFound, errIncludingDeleted.Code)
+	})
+
+	t.Run("Should_Return_Already_Exists_For_Duplicate_ID", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+
+		// WHEN
+		_, err := repo.CreateUser(ctx, domain.User{ID: created.ID, Name: "Grace", Age: 40, Email: "grace@example.com", CreatedDate: createdDate})
+
+		// THEN
+		assert.Equal(t, http.StatusConflict, err.Status)
+		assert.Equal(t, domain.ErrCodeUserAlreadyExists, err.Code)
+	})
+
+	t.Run("Should_Create_Users_All_Or_None", func(t *testing.T) {
+		repo := newRepo(t)
+		existing, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+
+		// WHEN
+		created, err := repo.CreateUsers(ctx, []domain.User{
+			{Name: "Grace", Age: 40, Email: "grace@example.com", CreatedDate: createdDate},
+			{Name: "Linus", Age: 30, Email: "linus@example.com", CreatedDate: createdDate},
+		})
+		_, duplicateErr := repo.CreateUsers(ctx, []domain.User{
+			{ID: existing.ID + 100, Name: "Ken", Age: 50, Email: "ken@example.com", CreatedDate: createdDate},
+			{ID: existing.ID, Name: "Dennis", Age: 50, Email: "dennis@example.com", CreatedDate: createdDate},
+		})
+
+		// THEN
+		assert.Nil(t, err)
+		assert.Len(t, created, 2)
+		assert.NotEqual(t, created[0].ID, created[1].ID)
+		assert.Equal(t, domain.ErrCodeUserAlreadyExists, duplicateErr.Code)
+		assert.Equal(t, 3, len(streamAll(t, repo, domain.UserFilter{})))
+	})
+
+	t.Run("Should_Update_User_And_Increment_Version", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+
+		// WHEN
+		created.Name = "Ada Lovelace"
+		updated, err := repo.UpdateUser(ctx, created)
+		found, _ := repo.GetUserById(ctx, created.ID)
+
+		// THEN
+		assert.Nil(t, err)
+		assert.Equal(t, uint(2), updated.Version)
+		assert.Equal(t, "Ada Lovelace", found.Name)
+		assert.Equal(t, uint(2), found.Version)
+		assert.Equal(t, created.UUID, found.UUID)
+		assert.False(t, found.UpdatedAt.Before(created.UpdatedAt))
+	})
+
+	t.Run("Should_Reject_Update_With_Stale_Version_Or_Unknown_ID", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+		_, _ = repo.UpdateUser(ctx, created)
+
+		// WHEN
+		_, staleErr := repo.UpdateUser(ctx, created)
+		_, unknownErr := repo.UpdateUser(ctx, domain.User{ID: created.ID + 100, Name: "Grace", Age: 40, Email: "grace@example.com", Version: 1})
+
+		// THEN
+		assert.Equal(t, http.StatusPreconditionFailed, staleErr.Status)
+		assert.Equal(t, domain.ErrCodeUserNotFound, unknownErr.Code)
+	})
+
+	t.Run("Should_Soft_Delete_And_Restore_User", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+
+		// WHEN
+		deleteErr := repo.DeleteUserById(ctx, created.ID, created.Version)
+		_, getErr := repo.GetUserById(ctx, created.ID)
+		deleted, deletedErr := repo.GetUserByIdIncludingDeleted(ctx, created.ID)
+		restoreErr := repo.RestoreUserById(ctx, created.ID)
+		restored, restoredErr := repo.GetUserById(ctx, created.ID)
+		restoreAgainErr := repo.RestoreUserById(ctx, created.ID)
+
+		// THEN
+		assert.Nil(t, deleteErr)
+		assert.Equal(t, domain.ErrCodeUserNotFound, getErr.Code)
+		assert.Nil(t, deletedErr)
+		assert.True(t, deleted.DeletedAt.Valid)
+		assert.Nil(t, restoreErr)
+		assert.Nil(t, restoredErr)
+		assert.False(t, restored.DeletedAt.Valid)
+		assert.Equal(t, created.Version+1, restored.Version)
+		assert.Equal(t, http.StatusNotFound, restoreAgainErr.Status)
+	})
+
+	t.Run("Should_Reject_Delete_With_Stale_Version_Or_Unknown_ID", func(t *testing.T) {
+		repo := newRepo(t)
+		created, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+
+		// WHEN
+		staleErr := repo.DeleteUserById(ctx, created.ID, created.Version+1)
+		unknownErr := repo.DeleteUserById(ctx, created.ID+100, domain.AnyVersion)
+		anyVersionErr := repo.DeleteUserById(ctx, created.ID, domain.AnyVersion)
+
+		// THEN
+		assert.Equal(t, http.StatusPreconditionFailed, staleErr.Status)
+		assert.Equal(t, domain.ErrCodeUserNotFound, unknownErr.Code)
+		assert.Nil(t, anyVersionErr)
+	})
+
+	t.Run("Should_Purge_Users_Deleted_Before_Time", func(t *testing.T) {
+		repo := newRepo(t)
+		deleted, _ := repo.CreateUser(ctx, domain.User{Name: "Ada", Age: 36, Email: "ada@example.com", CreatedDate: createdDate})
+		kept, _ := repo.CreateUser(ctx, domain.User{Name: "Grace", Age: 40, Email: "grace@example.com", CreatedDate: createdDate})
+		_ = repo.DeleteUserById(ctx, deleted.ID, domain.AnyVersion)
+
+		// WHEN
+		purgedEarly, _ := repo.PurgeDeletedUsers(ctx, time.Now().Add(-time.Hour))
+		purged, err := repo.PurgeDeletedUsers(ctx, time.Now().Add(time.Hour))
+		_, deletedErr := repo.GetUserByIdIncludingDeleted(ctx, deleted.ID)
+		_, keptErr := repo.GetUserById(ctx, kept.ID)
+
+		// THEN
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), purgedEarly)
+		assert.Equal(t, int64(1), purged)
+		assert.Equal(t, domain.ErrCodeUserNotFound, deletedErr.Code)
+		assert.Nil(t, keptErr)
+	})
+
+	t.Run("Should_Stream_Filtered_Users_In_ID_Order", func(t *testing.T) {
+		repo := newRepo(t)
+		users, _ := repo.CreateUsers(ctx, []domain.User{
+			{Name: "Ada Lovelace", Age: 36, Email: "ada.lovelace@example.com", CreatedDate: createdDate},
+			{Name: "ada_100%", Age: 20, Email: "ada.100@example.com", CreatedDate: createdDate.Add(time.Hour)},
+			{Name: "Grace Hopper", Age: 85, Email: "grace.hopper@example.com", CreatedDate: createdDate.Add(2 * time.Hour)},
+			{Name: "Adam", Age: 50, Email: "adam@example.com", Status: domain.UserSuspended, CreatedDate: createdDate.Add(3 * time.Hour)},
+		})
+		_ = repo.DeleteUserById(ctx, users[3].ID, domain.AnyVersion)
+
+		// WHEN
+		byName := streamAll(t, repo, domain.UserFilter{Name: "ADA"})
+		byLiteral := streamAll(t, repo, domain.UserFilter{Name: "0%"})
+		byAge := streamAll(t, repo, domain.UserFilter{MinAge: 30, MaxAge: 85})
+		byDate := streamAll(t, repo, domain.UserFilter{CreatedAfter: createdDate.Add(time.Hour), CreatedBefore: createdDate.Add(2 * time.Hour)})
+		withDeleted := streamAll(t, repo, domain.UserFilter{Name: "ada", IncludeDeleted: true})
+		byStatus := streamAll(t, repo, domain.UserFilter{Status: domain.UserSuspended, IncludeDeleted: true})
+
+		// THEN
+		assert.Equal(t, []uint{users[0].ID, users[1].ID}, byName)
+		assert.Equal(t, []uint{users[1].ID}, byLiteral)
+		assert.Equal(t, []uint{users[0].ID, users[2].ID}, byAge)
+		assert.Equal(t, []uint{users[1].ID}, byDate)
+		assert.Equal(t, []uint{users[0].ID, users[1].ID, users[3].ID}, withDeleted)
+		assert.Equal(t, []uint{users[3].ID}, byStatus)
+	})
+}
+
+func streamAll(t *testing.T, repo domain.UserRepository, filter domain.UserFilter) []uint {
+	var ids []uint
+	err := repo.StreamUsers(context.Background(), filter, func(user domain.User) error {
+		ids = append(ids, user.ID)
+		return nil
+	})
+	assert.Nil(t, err)
+	return ids
+}
+
+func Test_Should_Satisfy_Contract_With_Memory_Repository(t *testing.T) {
+	runUserRepositoryContract(t, func(t *testing.T) domain.UserRepository {
+		return NewMemoryUserRepository()
+	})
+}
+
+func Test_Should_Satisfy_Contract_With_SQLite_Repository(t *testing.T) {
+	runUserRepositoryContract(t, func(t *testing.T) domain.UserRepository {
+		db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "users.db"), &gorm.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := database.Migrate(db); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			sqlDB, _ := db.DB()
+			_ = sqlDB.Close()
+		})
+		return NewSQLiteUserRepository(db)
+	})
+}
@@ -52,16 +52,38 @@ func init() {
 
 func Test_Should_Create_User(t *testing.T) {
 
-	gormDb := config.ConnectTestPostgres(pgConStr)
-	database.Migrate(gormDb)
+	gormDb, connectErr := config.ConnectTestPostgres(pgConStr)
+	if connectErr != nil {
+		t.Fatal(connectErr)
+	}
+	if err := database.Migrate(gormDb); err != nil {
+		t.Fatal(err)
+	}
 
 	userRepo := NewUserRepository(gormDb)
 
 	user := domain.User{Name: "mert", Age: 26}
-	savedUser, err := userRepo.CreateUser(user)
+	savedUser, err := userRepo.CreateUser(context.Background(), user)
 	if err != nil {
 		log.Fatal(err.Message)
 	}
 
 	assert.Equal(t, user.Name, savedUser.Name)
 }
+
+func Test_Should_Satisfy_Contract_With_Postgres_Repository(t *testing.T) {
+	gormDb, err := config.ConnectTestPostgres(pgConStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := database.Migrate(gormDb); err != nil {
+		t.Fatal(err)
+	}
+
+	runUserRepositoryContract(t, func(t *testing.T) domain.UserRepository {
+		if err := gormDb.Exec("TRUNCATE users RESTART IDENTITY").Error; err != nil {
+			t.Fatal(err)
+		}
+		return NewUserRepository(gormDb)
+	})
+}
@@ -0,0 +1,261 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+memoryUserRepository keeps the users in a map, it is meant for local development and tests.
+It does not take part in the transactions of the context, every call is applied on its own.
+*/
+type memoryUserRepository struct {
+	mu     sync.RWMutex
+	users  map[uint]domain.User
+	lastID uint
+}
+
+func NewMemoryUserRepository() domain.UserRepository {
+	return &memoryUserRepository{users: make(map[uint]domain.User)}
+}
+
+func (r *memoryUserRepository) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkNew(user); err != nil {
+		return user, err
+	}
+	return r.insert(user), nil
+}
+
+// CreateUsers inserts all users or none of them, like the single INSERT of the database repositories.
+func (r *memoryUserRepository) CreateUsers(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make(map[uint]bool)
+	emails := make(map[string]bool)
+	for _, user := range users {
+		if err := r.checkNew(user); err != nil {
+			return users, err
+		}
+		email := strings.ToLower(user.Email)
+		if (user.ID != 0 && ids[user.ID]) || (email != "" && emails[email]) {
+			return users, domain.NewUserAlreadyExistError("User already exists.")
+		}
+		ids[user.ID] = true
+		emails[email] = true
+	}
+
+	created := make([]domain.User, len(users))
+	for i, user := range users {
+		created[i] = r.insert(user)
+	}
+	return created, nil
+}
+
+func (r *memoryUserRepository) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return domain.User{}, domain.NewUserNotFoundError(id)
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) GetUserByIdIncludingDeleted(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, domain.NewUserNotFoundError(id)
+	}
+	return user, nil
+}
+
+func (r *memoryUserRepository) GetUserIdByUUID(ctx context.Context, userUUID uuid.UUID) (uint, *domain.AppError) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, user := range r.users {
+		if user.UUID == userUUID {
+			return id, nil
+		}
+	}
+	return 0, domain.NewUserUUIDNotFoundError(userUUID.String())
+}
+
+func (r *memoryUserRepository) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[user.ID]
+	if !ok || existing.DeletedAt.Valid {
+		return user, domain.NewUserNotFoundError(user.ID)
+	}
+	if existing.Version != user.Version {
+		return user, domain.NewPreconditionFailedError(fmt.Sprintf("User has been modified, ID: %d", user.ID))
+	}
+	if r.emailTaken(user.Email, user.ID) {
+		return user, domain.NewUserAlreadyExistError("User already exists.")
+	}
+
+	user.Version++
+	user.UpdatedAt = time.Now()
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *memoryUserRepository) DeleteUserById(ctx context.Context, id uint, version uint) *domain.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return domain.NewUserNotFoundError(id)
+	}
+	if version != domain.AnyVersion && user.Version != version {
+		return domain.NewPreconditionFailedError(fmt.Sprintf("User has been modified, ID: %d", id))
+	}
+
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) RestoreUserById(ctx context.Context, id uint) *domain.AppError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || !user.DeletedAt.Valid {
+		return domain.NewDeletedUserNotFoundError(id)
+	}
+
+	user.DeletedAt = gorm.DeletedAt{}
+	user.UpdatedAt = time.Now()
+	user.Version++
+	r.users[id] = user
+	return nil
+}
+
+func (r *memoryUserRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int64, *domain.AppError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, user := range r.users {
+		if user.DeletedAt.Valid && user.DeletedAt.Time.Before(deletedBefore) {
+			delete(r.users, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// StreamUsers calls fn on a snapshot of the matching users, so fn may take its time without blocking writers.
+func (r *memoryUserRepository) StreamUsers(ctx context.Context, filter domain.UserFilter, fn func(user domain.User) error) *domain.AppError {
+	r.mu.RLock()
+	var users []domain.User
+	for _, user := range r.users {
+		if matchesUserFilter(user, filter) {
+			users = append(users, user)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return domain.NewUnexpectedError("User stream was interrupted.").WithCause(err)
+		}
+		if err := fn(user); err != nil {
+			return domain.NewUnexpectedError("User stream was interrupted.").WithCause(err)
+		}
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) checkNew(user domain.User) *domain.AppError {
+	if _, ok := r.users[user.ID]; user.ID != 0 && ok {
+		return domain.NewUserAlreadyExistError("User already exists.")
+	}
+	if r.emailTaken(user.Email, 0) {
+		return domain.NewUserAlreadyExistError("User already exists.")
+	}
+	return nil
+}
+
+// emailTaken tells whether another user has the email, soft deleted users keep theirs like in the unique index.
+func (r *memoryUserRepository) emailTaken(email string, id uint) bool {
+	if email == "" {
+		return false
+	}
+	for _, user := range r.users {
+		if user.ID != id && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// insert stores the user with the defaults the database columns would fill in, the caller holds the lock.
+func (r *memoryUserRepository) insert(user domain.User) domain.User {
+	if user.ID == 0 {
+		r.lastID++
+		user.ID = r.lastID
+	} else if user.ID > r.lastID {
+		r.lastID = user.ID
+	}
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	if user.Status == "" {
+		user.Status = domain.UserActive
+	}
+	if user.Roles == nil {
+		user.Roles = domain.Roles{}
+	}
+	assignUUID(&user)
+	now := time.Now()
+	if user.CreatedDate.IsZero() {
+		user.CreatedDate = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	r.users[user.ID] = user
+	return user
+}
+
+func matchesUserFilter(user domain.User, filter domain.UserFilter) bool {
+	switch {
+	case user.DeletedAt.Valid && !filter.IncludeDeleted:
+		return false
+	case filter.Name != "" && !strings.Contains(strings.ToLower(user.Name), strings.ToLower(filter.Name)):
+		return false
+	case filter.Status != "" && user.Status != filter.Status:
+		return false
+	case filter.MinAge != 0 && user.Age < filter.MinAge:
+		return false
+	case filter.MaxAge != 0 && user.Age > filter.MaxAge:
+		return false
+	case !filter.CreatedAfter.IsZero() && user.CreatedDate.Before(filter.CreatedAfter):
+		return false
+	case !filter.CreatedBefore.IsZero() && !user.CreatedDate.Before(filter.CreatedBefore):
+		return false
+	default:
+		return true
+	}
+}
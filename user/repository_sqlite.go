@@ -0,0 +1,14 @@
+package user
+
+import (
+	"go-app/domain"
+	"gorm.io/gorm"
+)
+
+/*
+NewSQLiteUserRepository returns the gorm repository for a SQLite database, used for local development and tests.
+SQLite has no ILIKE, its LIKE ignores the case of ASCII letters and needs the escape character to be declared.
+*/
+func NewSQLiteUserRepository(db *gorm.DB) domain.UserRepository {
+	return &userRepository{db: db, nameFilter: `name LIKE ? ESCAPE '\'`}
+}
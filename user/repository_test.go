@@ -1,12 +1,15 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 	"go-app/domain"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -37,16 +40,16 @@ func Test_Should_Create_User_With_Mock_Db(t *testing.T) {
 	repo := NewUserRepository(db)
 
 	// GIVEN
-	user := domain.User{Name: "John Doe", Age: 30, CreatedDate: time.Now()}
+	user := domain.User{Name: "John Doe", Age: 30, Email: "john@example.com", CreatedDate: time.Now()}
 
 	// WHEN
 	mock.ExpectBegin()
 	mock.ExpectQuery(`INSERT INTO "users"`).
-		WithArgs(user.Name, user.Age, user.CreatedDate).
+		WithArgs(sqlmock.AnyArg(), user.Name, user.Age, user.Email, "active", "", nil, "", "[]", user.CreatedDate, sqlmock.AnyArg(), nil, 1).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
-	result, err := repo.CreateUser(user)
+	result, err := repo.CreateUser(context.Background(), user)
 
 	// THEN
 	assert.Nil(t, err)
@@ -58,23 +61,48 @@ func Test_Should_Return_Err_When_Invoke_Create_User_With_Mock_Db(t *testing.T) {
 	repo := NewUserRepository(db)
 
 	// GIVEN
-	user := domain.User{Name: "John Doe", Age: 30, CreatedDate: time.Now()}
+	user := domain.User{Name: "John Doe", Age: 30, Email: "john@example.com", CreatedDate: time.Now()}
 	gormErr := errors.New("Unexpected Error")
 	unexpectedErr := domain.NewUnexpectedError(gormErr.Error())
 
 	// WHEN
 	mock.ExpectBegin()
 	mock.ExpectQuery(`INSERT INTO "users"`).
-		WithArgs(user.Name, user.Age, user.CreatedDate).
+		WithArgs(sqlmock.AnyArg(), user.Name, user.Age, user.Email, "active", "", nil, "", "[]", user.CreatedDate, sqlmock.AnyArg(), nil, 1).
 		WillReturnError(gormErr)
-	mock.ExpectCommit()
+	mock.ExpectRollback()
 
-	_, err := repo.CreateUser(user)
+	_, err := repo.CreateUser(context.Background(), user)
 
 	// THEN
 	assert.NotNil(t, err)
 	assert.Equal(t, unexpectedErr.Code, err.Code)
-	assert.True(t, strings.Contains(err.Message, unexpectedErr.Message), "Should contains Unexpected Error")
+	assert.True(t, errors.Is(err, gormErr))
+	assert.False(t, strings.Contains(err.Message, gormErr.Error()), "Should not leak database error")
+}
+
+func Test_Should_Return_Already_Exist_Err_When_Invoke_Create_User_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// GIVEN
+	user := domain.User{Name: "John Doe", Age: 30, Email: "john@example.com", CreatedDate: time.Now()}
+	pgErr := &pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"}
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users"`).
+		WithArgs(sqlmock.AnyArg(), user.Name, user.Age, user.Email, "active", "", nil, "", "[]", user.CreatedDate, sqlmock.AnyArg(), nil, 1).
+		WillReturnError(pgErr)
+	mock.ExpectRollback()
+
+	_, err := repo.CreateUser(context.Background(), user)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusConflict, err.Status)
+	assert.Equal(t, domain.ErrCodeUserAlreadyExists, err.Code)
+	assert.True(t, errors.Is(err, pgErr))
 }
 
 func Test_Should_Get_User_By_Id_With_Mock_Db(t *testing.T) {
@@ -90,7 +118,7 @@ func Test_Should_Get_User_By_Id_With_Mock_Db(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "age", "created_date"}).
 			AddRow(user.ID, user.Name, user.Age, user.CreatedDate))
 
-	result, err := repo.GetUserById(user.ID)
+	result, err := repo.GetUserById(context.Background(), user.ID)
 
 	// THEN
 	assert.Nil(t, err)
@@ -103,13 +131,13 @@ func Test_Should_Return_Not_Found_Error_When_Invoke_Get_User_By_Id_With_Mock_Db(
 
 	// GIVEN
 	var id uint = 1
-	expectedError := domain.NewNotFoundError("User not found, ID: 1")
+	expectedError := domain.NewUserNotFoundError(id)
 
 	// WHEN
 	expectedSQL := "SELECT (.+) FROM \"users\" WHERE id =(.+)"
 	mock.ExpectQuery(expectedSQL).WillReturnError(gorm.ErrRecordNotFound)
 
-	_, err := repo.GetUserById(id)
+	_, err := repo.GetUserById(context.Background(), id)
 
 	// THEN
 	assert.NotNil(t, err)
@@ -129,7 +157,7 @@ func Test_Should_Return_Unexpected_Error_When_Invoke_Get_User_By_Id_With_Mock_Db
 	expectedSQL := "SELECT (.+) FROM \"users\" WHERE id =(.+)"
 	mock.ExpectQuery(expectedSQL).WillReturnError(gorm.ErrNotImplemented)
 
-	_, err := repo.GetUserById(id)
+	_, err := repo.GetUserById(context.Background(), id)
 
 	// THEN
 	assert.NotNil(t, err)
@@ -149,13 +177,58 @@ func Test_Should_Update_User_With_Mock_Db(t *testing.T) {
 	mock.ExpectExec(updUserSQL).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
-	updateUser, err := repo.UpdateUser(user)
+	updateUser, err := repo.UpdateUser(context.Background(), user)
 
 	// THEN
 	assert.Nil(t, err)
 	assert.Equal(t, user.Name, updateUser.Name)
 }
 
+func Test_Should_Return_Not_Found_Err_When_Invoke_Update_User_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// GIVEN
+	user := domain.User{ID: 99, Name: "Edit User", Age: 29}
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE \"users\" SET .+").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT count(.+) FROM \"users\" WHERE id = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, err := repo.UpdateUser(context.Background(), user)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeUserNotFound, err.Code)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Return_Precondition_Failed_Err_When_Invoke_Update_User_With_Stale_Version_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "Edit User", Age: 29, Version: 1}
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE \"users\" SET (.+)\"version\"=(.+) WHERE version = (.+) AND \"users\".\"deleted_at\" IS NULL AND \"id\" = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT count(.+) FROM \"users\" WHERE id = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	_, err := repo.UpdateUser(context.Background(), user)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, 412, err.Status)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
 func Test_Should_Return_Unexpected_Err_When_Invoke_Update_User_With_Mock_Db(t *testing.T) {
 	db, mock := mockRepositorySetup()
 	repo := NewUserRepository(db)
@@ -169,17 +242,18 @@ func Test_Should_Return_Unexpected_Err_When_Invoke_Update_User_With_Mock_Db(t *t
 	mock.ExpectBegin()
 	mock.ExpectExec("UPDATE \"users\" SET .+").
 		WillReturnError(gormErr)
-	mock.ExpectCommit()
+	mock.ExpectRollback()
 
-	_, err := repo.UpdateUser(user)
+	_, err := repo.UpdateUser(context.Background(), user)
 
 	// THEN
 	assert.NotNil(t, err)
 	assert.Equal(t, unexpectedErr.Code, err.Code)
-	assert.True(t, strings.Contains(err.Message, unexpectedErr.Message), "Should contains Unexpected Error")
+	assert.True(t, errors.Is(err, gormErr))
+	assert.False(t, strings.Contains(err.Message, gormErr.Error()), "Should not leak database error")
 }
 
-func Test_Should_Delete_User_With_Mock_Db(t *testing.T) {
+func Test_Should_Soft_Delete_User_With_Mock_Db(t *testing.T) {
 	db, mock := mockRepositorySetup()
 	repo := NewUserRepository(db)
 
@@ -188,16 +262,33 @@ func Test_Should_Delete_User_With_Mock_Db(t *testing.T) {
 
 	// WHEN
 	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM \"users\" WHERE (.+)$").
+	mock.ExpectExec("UPDATE \"users\" SET \"deleted_at\"=(.+)$").
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
-	err := repo.DeleteUserById(user.ID)
+	err := repo.DeleteUserById(context.Background(), user.ID, domain.AnyVersion)
 
 	// THEN
 	assert.Nil(t, err)
 }
 
+func Test_Should_Return_Not_Found_Err_When_Invoke_Delete_User_By_Id_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE \"users\" SET \"deleted_at\"=(.+)$").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.DeleteUserById(context.Background(), 99, domain.AnyVersion)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeUserNotFound, err.Code)
+}
+
 func Test_Should_Return_Unexpected_Err_When_Invoke_Delete_User_By_Id_With_Mock_Db(t *testing.T) {
 	db, mock := mockRepositorySetup()
 	repo := NewUserRepository(db)
@@ -209,14 +300,124 @@ func Test_Should_Return_Unexpected_Err_When_Invoke_Delete_User_By_Id_With_Mock_D
 
 	// WHEN
 	mock.ExpectBegin()
-	mock.ExpectExec("DELETE FROM \"users\" WHERE (.+)$").
+	mock.ExpectExec("UPDATE \"users\" SET \"deleted_at\"=(.+)$").
 		WillReturnError(gormErr)
-	mock.ExpectCommit()
+	mock.ExpectRollback()
 
-	err := repo.DeleteUserById(user.ID)
+	err := repo.DeleteUserById(context.Background(), user.ID, domain.AnyVersion)
 
 	// THEN
 	assert.NotNil(t, err)
 	assert.Equal(t, unexpectedErr.Code, err.Code)
-	assert.True(t, strings.Contains(err.Message, unexpectedErr.Message), "Should contains Unexpected Error")
+	assert.True(t, errors.Is(err, gormErr))
+	assert.False(t, strings.Contains(err.Message, gormErr.Error()), "Should not leak database error")
+}
+
+func Test_Should_Restore_User_By_Id_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE \"users\" SET \"deleted_at\"=(.+) WHERE id = (.+) AND deleted_at IS NOT NULL").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := repo.RestoreUserById(context.Background(), 1)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Return_Not_Found_Err_When_Invoke_Restore_User_By_Id_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE \"users\" SET \"deleted_at\"=(.+)").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.RestoreUserById(context.Background(), 1)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeUserNotFound, err.Code)
+}
+
+func Test_Should_Purge_Deleted_Users_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// GIVEN
+	deletedBefore := time.Now().Add(-time.Hour)
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM \"users\" WHERE deleted_at IS NOT NULL AND deleted_at < (.+)").
+		WithArgs(deletedBefore).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgeDeletedUsers(context.Background(), deletedBefore)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), purged)
+}
+
+func Test_Should_Create_Users_In_Batches_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// GIVEN
+	now := time.Now()
+	users := []domain.User{
+		{Name: "John Doe", Age: 30, Email: "john@example.com", CreatedDate: now},
+		{Name: "Jane Doe", Age: 28, Email: "jane@example.com", Roles: domain.Roles{"admin"}, CreatedDate: now},
+	}
+
+	// WHEN
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users"`).
+		WithArgs(sqlmock.AnyArg(), "John Doe", 30, "john@example.com", "active", "", nil, "", "[]", now, sqlmock.AnyArg(), nil, 1,
+			sqlmock.AnyArg(), "Jane Doe", 28, "jane@example.com", "active", "", nil, "", `["admin"]`, now, sqlmock.AnyArg(), nil, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	result, err := repo.CreateUsers(context.Background(), users)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, uint(2), result[1].ID)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func Test_Should_Stream_Filtered_Users_With_Mock_Db(t *testing.T) {
+	db, mock := mockRepositorySetup()
+	repo := NewUserRepository(db)
+
+	// GIVEN
+	filter := domain.UserFilter{Name: "50%", MinAge: 18}
+	rows := sqlmock.NewRows([]string{"id", "name", "age", "created_date", "deleted_at", "version"}).
+		AddRow(1, "50% off", 20, time.Now(), nil, 1).
+		AddRow(2, "50% more", 30, time.Now(), nil, 1)
+
+	// WHEN
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE name ILIKE \$1 AND age >= \$2 AND "users"."deleted_at" IS NULL ORDER BY id`).
+		WithArgs(`%50\%%`, 18).
+		WillReturnRows(rows)
+
+	var names []string
+	err := repo.StreamUsers(context.Background(), filter, func(user domain.User) error {
+		names = append(names, user.Name)
+		return nil
+	})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"50% off", "50% more"}, names)
+	assert.Nil(t, mock.ExpectationsWereMet())
 }
@@ -1,33 +1,45 @@
 package user
 
 import (
+	"context"
 	"fmt"
+	"github.com/google/uuid"
 	"go-app/domain"
+	"go-app/metrics"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"time"
 )
 
 //go:generate mockgen -destination=../mocks/mockUserUsecase.go -package=mocks go-app/domain UserUseCase
 type userUseCase struct {
-	repo   domain.UserRepository
-	logger *zap.Logger
+	repo       domain.UserRepository
+	outboxRepo domain.OutboxRepository
+	auditRepo  domain.AuditRepository
+	txManager  domain.TxManager
+	logger     *zap.Logger
+	importJobs *importJobStore
 }
 
-func NewUserUseCase(repo domain.UserRepository, logger *zap.Logger) domain.UserUseCase {
-	return &userUseCase{repo: repo, logger: logger}
+func NewUserUseCase(repo domain.UserRepository, outboxRepo domain.OutboxRepository, auditRepo domain.AuditRepository, txManager domain.TxManager, logger *zap.Logger) domain.UserUseCase {
+	return &userUseCase{repo: repo, outboxRepo: outboxRepo, auditRepo: auditRepo, txManager: txManager, logger: logger, importJobs: newImportJobStore()}
 }
 
-func (u *userUseCase) CreateUser(user domain.User) (domain.User, *domain.AppError) {
-	user.CreatedDate = time.Now()
-	if user.Name == "" {
-		err := domain.NewValidationError("The name should not be empty.")
-		u.logger.Error(err.Message)
+func (u *userUseCase) CreateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	user = domain.PrepareNewUser(user, time.Now())
+	if err := domain.ValidateUser(user, domain.OperationCreate); err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
 		return user, err
 	}
 
-	createdUser, err := u.repo.CreateUser(user)
+	var createdUser domain.User
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		var err *domain.AppError
+		createdUser, err = u.createUser(ctx, user)
+		return err
+	})
 	if err != nil {
-		u.logger.Error(err.Message)
+		u.logger.Error(err.Message, zap.Error(err))
 		return domain.User{}, err
 	}
 
@@ -35,30 +47,365 @@ func (u *userUseCase) CreateUser(user domain.User) (domain.User, *domain.AppErro
 	return createdUser, nil
 }
 
-func (u *userUseCase) GetUserById(id uint) (domain.User, *domain.AppError) {
-	user, err := u.repo.GetUserById(id)
+func (u *userUseCase) GetUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	user, err := u.repo.GetUserById(ctx, id)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return user, err
+	}
+
+	return user, nil
+}
+
+func (u *userUseCase) GetUserByIdIncludingDeleted(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	user, err := u.repo.GetUserByIdIncludingDeleted(ctx, id)
 	if err != nil {
-		u.logger.Error(err.Message)
+		u.logger.Error(err.Message, zap.Error(err))
 		return user, err
 	}
 
 	return user, nil
 }
 
-func (u *userUseCase) UpdateUser(user domain.User) (domain.User, *domain.AppError) {
-	updatedUser, err := u.repo.UpdateUser(user)
+func (u *userUseCase) ResolveUserId(ctx context.Context, userUUID uuid.UUID) (uint, *domain.AppError) {
+	id, err := u.repo.GetUserIdByUUID(ctx, userUUID)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (u *userUseCase) UpdateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	if err := domain.ValidateUser(user, domain.OperationUpdate); err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return user, err
+	}
+
+	updatedUser := user
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		var err *domain.AppError
+		updatedUser, err = u.updateUser(ctx, user)
+		return err
+	})
 	if err != nil {
-		u.logger.Error(err.Message)
+		u.logger.Error(err.Message, zap.Error(err))
 		return updatedUser, err
 	}
 	return updatedUser, nil
 }
 
-func (u *userUseCase) DeleteUserById(id uint) *domain.AppError {
-	err := u.repo.DeleteUserById(id)
+func (u *userUseCase) PatchUser(ctx context.Context, id uint, version uint, patchType domain.PatchType, patch []byte) (domain.User, *domain.AppError) {
+	var patchedUser domain.User
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		var err *domain.AppError
+		patchedUser, err = u.patchUser(ctx, id, version, patchType, patch)
+		return err
+	})
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return patchedUser, err
+	}
+	return patchedUser, nil
+}
+
+func (u *userUseCase) DeleteUserById(ctx context.Context, id uint, version uint) *domain.AppError {
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		return u.deleteUser(ctx, id, version)
+	})
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (u *userUseCase) RestoreUserById(ctx context.Context, id uint) (domain.User, *domain.AppError) {
+	var restoredUser domain.User
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		deletedUser, err := u.repo.GetUserByIdIncludingDeleted(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := u.repo.RestoreUserById(ctx, id); err != nil {
+			return err
+		}
+		if restoredUser, err = u.repo.GetUserById(ctx, id); err != nil {
+			return err
+		}
+		if err := u.recordAudit(ctx, domain.AuditUpdate, &deletedUser, restoredUser); err != nil {
+			return err
+		}
+		return u.recordEvents(ctx, domain.UserUpdated, restoredUser)
+	})
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.User{}, err
+	}
+
+	u.logger.Info(fmt.Sprintf("User restored. ID: %d", id))
+	return restoredUser, nil
+}
+
+func (u *userUseCase) SetUserRoles(ctx context.Context, id uint, version uint, roles domain.Roles) (domain.User, *domain.AppError) {
+	if roles == nil {
+		roles = domain.Roles{}
+	}
+	var updatedUser domain.User
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		user, err := u.repo.GetUserById(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(user, version); err != nil {
+			return err
+		}
+
+		updatedUser = user
+		updatedUser.Roles = roles
+		if err := domain.ValidateUser(updatedUser, domain.OperationPatch, "Roles"); err != nil {
+			return err
+		}
+		updatedUser, err = u.saveUser(ctx, user, updatedUser)
+		return err
+	})
 	if err != nil {
-		u.logger.Error(err.Message)
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.User{}, err
+	}
+
+	u.logger.Info(fmt.Sprintf("User roles changed. ID: %d, roles: %v", id, roles))
+	return updatedUser, nil
+}
+
+func (u *userUseCase) TransitionUser(ctx context.Context, id uint, transition domain.UserTransition, change domain.StatusChange) (domain.User, *domain.AppError) {
+	// An authenticated caller is always the actor, the request body can only name one for anonymous requests.
+	if principal, ok := domain.PrincipalFrom(ctx); ok {
+		change.Actor = principal.Subject
+	} else if change.Actor == "" {
+		change.Actor = domain.AuditMetadataFrom(ctx).Actor
+	}
+	var from domain.UserStatus
+	var transitionedUser domain.User
+	err := u.inTx(ctx, func(ctx context.Context) *domain.AppError {
+		user, err := u.repo.GetUserById(ctx, id)
+		if err != nil {
+			return err
+		}
+		from = user.Status
+		if transitionedUser, err = domain.ApplyTransition(user, transition, change, time.Now()); err != nil {
+			return err
+		}
+		transitionedUser, err = u.saveUser(ctx, user, transitionedUser)
 		return err
+	})
+	if err != nil {
+		if err.Code == domain.ErrCodeInvalidTransition {
+			metrics.UserStatusTransitions.WithLabelValues(string(transition), string(from), "rejected").Inc()
+		}
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.User{}, err
+	}
+
+	metrics.UserStatusTransitions.WithLabelValues(string(transition), string(from), "applied").Inc()
+	u.logger.Info(fmt.Sprintf("User status changed. ID: %d, from: %s, to: %s, actor: %q, reason: %q",
+		id, from, transitionedUser.Status, change.Actor, change.Reason))
+	return transitionedUser, nil
+}
+
+func (u *userUseCase) GetUserHistory(ctx context.Context, id uint, query domain.HistoryQuery) (domain.UserHistory, *domain.AppError) {
+	history, err := u.getUserHistory(ctx, id, query)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return history, err
+	}
+	return history, nil
+}
+
+func (u *userUseCase) getUserHistory(ctx context.Context, id uint, query domain.HistoryQuery) (domain.UserHistory, *domain.AppError) {
+	// One more entry than asked tells whether there is a next page.
+	entries, err := u.auditRepo.ListByUser(ctx, id, query.Before, query.AsOf, query.Limit+1)
+	if err != nil {
+		return domain.UserHistory{}, err
+	}
+	if len(entries) == 0 && query.Before == 0 {
+		// Purged users keep their history, users written before the audit trail existed have none.
+		if _, err := u.repo.GetUserByIdIncludingDeleted(ctx, id); err != nil {
+			return domain.UserHistory{}, err
+		}
+	}
+
+	history := domain.UserHistory{Entries: entries}
+	if len(entries) > query.Limit {
+		history.Entries = entries[:query.Limit]
+		history.NextBefore = history.Entries[query.Limit-1].ID
+	}
+	if query.AsOf.IsZero() {
+		return history, nil
+	}
+
+	allEntries, err := u.auditRepo.ListByUserUntil(ctx, id, query.AsOf)
+	if err != nil || len(allEntries) == 0 {
+		return history, err
+	}
+	user, err := domain.ReplayAudit(allEntries)
+	if err != nil {
+		return history, err
+	}
+	history.User = &user
+	return history, nil
+}
+
+// updateUser replaces an existing user, keeping the fields a client can not change.
+func (u *userUseCase) updateUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	existingUser, err := u.repo.GetUserById(ctx, user.ID)
+	if err != nil {
+		return user, err
+	}
+	if err := checkVersion(existingUser, user.Version); err != nil {
+		return user, err
+	}
+	user.UUID = existingUser.UUID
+	user.CreatedDate = existingUser.CreatedDate
+	user.Version = existingUser.Version
+	user.Email = domain.NormalizeEmail(user.Email)
+	user.Status = existingUser.Status
+	user.StatusReason = existingUser.StatusReason
+	user.StatusChangedAt = existingUser.StatusChangedAt
+	user.StatusChangedBy = existingUser.StatusChangedBy
+	user.Roles = existingUser.Roles
+
+	return u.saveUser(ctx, existingUser, user)
+}
+
+func (u *userUseCase) patchUser(ctx context.Context, id uint, version uint, patchType domain.PatchType, patch []byte) (domain.User, *domain.AppError) {
+	user, err := u.repo.GetUserById(ctx, id)
+	if err != nil {
+		return user, err
+	}
+	if err := checkVersion(user, version); err != nil {
+		return user, err
+	}
+
+	patchedUser, changedFields, err := domain.ApplyUserPatch(user, patchType, patch)
+	if err != nil {
+		return user, err
+	}
+	if len(changedFields) == 0 {
+		return user, nil
+	}
+
+	if err := domain.ValidateUser(patchedUser, domain.OperationPatch, changedFields...); err != nil {
+		return user, err
+	}
+	patchedUser.Email = domain.NormalizeEmail(patchedUser.Email)
+
+	return u.saveUser(ctx, user, patchedUser)
+}
+
+/*
+The write helpers below record the domain event of every change in the outbox and its audit entry.
+They must run inside a transaction, so the event and the entry are committed or rolled back together with the change.
+*/
+
+func (u *userUseCase) createUser(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+	createdUser, err := u.repo.CreateUser(ctx, user)
+	if err != nil {
+		return createdUser, err
+	}
+	if err := u.recordAudit(ctx, domain.AuditCreate, nil, createdUser); err != nil {
+		return createdUser, err
+	}
+	return createdUser, u.recordEvents(ctx, domain.UserCreated, createdUser)
+}
+
+func (u *userUseCase) createUsers(ctx context.Context, users []domain.User) ([]domain.User, *domain.AppError) {
+	createdUsers, err := u.repo.CreateUsers(ctx, users)
+	if err != nil {
+		return createdUsers, err
+	}
+	if err := u.recordAudit(ctx, domain.AuditCreate, nil, createdUsers...); err != nil {
+		return createdUsers, err
+	}
+	return createdUsers, u.recordEvents(ctx, domain.UserCreated, createdUsers...)
+}
+
+// saveUser writes the changes made to existingUser, which is the user as it was read in the same transaction.
+func (u *userUseCase) saveUser(ctx context.Context, existingUser domain.User, user domain.User) (domain.User, *domain.AppError) {
+	savedUser, err := u.repo.UpdateUser(ctx, user)
+	if err != nil {
+		return savedUser, err
+	}
+	if err := u.recordAudit(ctx, domain.AuditUpdate, &existingUser, savedUser); err != nil {
+		return savedUser, err
+	}
+	return savedUser, u.recordEvents(ctx, domain.UserUpdated, savedUser)
+}
+
+func (u *userUseCase) deleteUser(ctx context.Context, id uint, version uint) *domain.AppError {
+	user, err := u.repo.GetUserById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.DeleteUserById(ctx, id, version); err != nil {
+		return err
+	}
+
+	deletedUser := user
+	deletedUser.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	if err := u.recordAudit(ctx, domain.AuditDelete, &user, deletedUser); err != nil {
+		return err
+	}
+	return u.recordEvents(ctx, domain.UserDeleted, domain.User{ID: id})
+}
+
+// recordAudit adds an audit entry per user, before is nil for created users.
+func (u *userUseCase) recordAudit(ctx context.Context, action domain.AuditAction, before *domain.User, users ...domain.User) *domain.AppError {
+	now := time.Now()
+	entries := make([]domain.AuditEntry, 0, len(users))
+	for _, user := range users {
+		entry, err := domain.NewAuditEntry(ctx, action, before, user, now)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	return u.auditRepo.Add(ctx, entries...)
+}
+
+func (u *userUseCase) recordEvents(ctx context.Context, eventType domain.EventType, users ...domain.User) *domain.AppError {
+	events := make([]domain.OutboxEvent, 0, len(users))
+	for _, user := range users {
+		event, err := domain.NewUserEvent(eventType, user)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return u.outboxRepo.Add(ctx, events...)
+}
+
+func (u *userUseCase) inTx(ctx context.Context, fn func(ctx context.Context) *domain.AppError) *domain.AppError {
+	return u.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		return txError(fn(ctx))
+	})
+}
+
+func checkVersion(user domain.User, version uint) *domain.AppError {
+	if version != domain.AnyVersion && user.Version != version {
+		return domain.NewPreconditionFailedError(fmt.Sprintf("User has been modified, ID: %d, current version: %d", user.ID, user.Version))
+	}
+	return nil
+}
+
+// txError returns the error of a transaction callback, a nil *AppError must not become a non-nil error.
+func txError(err *domain.AppError) error {
+	if err == nil {
+		return nil
 	}
 	return err
 }
@@ -1,72 +1,130 @@
 package user
 
 import (
+	"context"
 	"fmt"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go-app/config"
 	"go-app/domain"
 	"go-app/mocks"
 	"testing"
+	"time"
 )
 
 var (
-	_userMockRepo *mocks.MockUserRepository
-	_userUseCase  domain.UserUseCase
+	_userMockRepo   *mocks.MockUserRepository
+	_outboxMockRepo *mocks.MockOutboxRepository
+	_auditMockRepo  *mocks.MockAuditRepository
+	_txMockManager  *mocks.MockTxManager
+	_userUseCase    domain.UserUseCase
+	_recordedEvents []domain.OutboxEvent
+	_outboxErr      *domain.AppError
+	_recordedAudit  []domain.AuditEntry
 )
 
 func mockUseCaseSetup(t *testing.T) {
 	c := gomock.NewController(t)
 	defer c.Finish()
 
-	// Mock UserRepository
+	// Mock UserRepository, OutboxRepository, AuditRepository & TxManager, transactions run the callback directly
 	_userMockRepo = mocks.NewMockUserRepository(c)
+	_outboxMockRepo = mocks.NewMockOutboxRepository(c)
+	_recordedEvents, _outboxErr = nil, nil
+	_outboxMockRepo.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, events ...domain.OutboxEvent) *domain.AppError {
+			if _outboxErr != nil {
+				return _outboxErr
+			}
+			_recordedEvents = append(_recordedEvents, events...)
+			return nil
+		}).AnyTimes()
+	_auditMockRepo = mocks.NewMockAuditRepository(c)
+	_recordedAudit = nil
+	_auditMockRepo.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, entries ...domain.AuditEntry) *domain.AppError {
+			_recordedAudit = append(_recordedAudit, entries...)
+			return nil
+		}).AnyTimes()
+	_txMockManager = mocks.NewMockTxManager(c)
+	_txMockManager.EXPECT().WithinTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error, opts ...domain.TxOption) *domain.AppError {
+			if err := fn(ctx); err != nil {
+				return err.(*domain.AppError)
+			}
+			return nil
+		}).AnyTimes()
 
 	logger := config.ZapTestConfig()
-	_userUseCase = NewUserUseCase(_userMockRepo, logger)
+	_userUseCase = NewUserUseCase(_userMockRepo, _outboxMockRepo, _auditMockRepo, _txMockManager, logger)
 }
 
 func Test_Should_Create_User_With_MockUserRepository(t *testing.T) {
 	mockUseCaseSetup(t)
 
 	// GIVEN
-	user := domain.User{Name: "test", Age: 18}
+	user := domain.User{Name: "test", Age: 18, Email: "Test@Example.com"}
 	expectedUser := domain.User{ID: 1, Name: "test", Age: 18}
 
 	// WHEN
-	_userMockRepo.EXPECT().CreateUser(gomock.Any()).Return(expectedUser, nil)
-	res, err := _userUseCase.CreateUser(user)
+	_userMockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		assert.Equal(t, "test@example.com", user.Email)
+		assert.Equal(t, domain.UserActive, user.Status)
+		assert.NotEqual(t, uuid.Nil, user.UUID)
+		return expectedUser, nil
+	})
+	res, err := _userUseCase.CreateUser(context.Background(), user)
 
 	// THEN
 	assert.Nil(t, err)
 	assert.Equal(t, expectedUser.Name, res.Name)
+	assert.Len(t, _recordedEvents, 1)
+	assert.Equal(t, domain.UserCreated, _recordedEvents[0].EventType)
+	assert.Equal(t, "1", _recordedEvents[0].AggregateID)
+}
+
+func Test_Should_Return_Err_When_User_Created_Event_Can_Not_Be_Recorded(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{Name: "test", Age: 18, Email: "Test@Example.com"}
+	_outboxErr = domain.NewUnexpectedError("outbox unavailable")
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(domain.User{ID: 1, Name: "test", Age: 18}, nil)
+	_, err := _userUseCase.CreateUser(context.Background(), user)
+
+	// THEN
+	assert.Equal(t, _outboxErr, err)
+	assert.Empty(t, _recordedEvents)
 }
 
 func Test_Should_Return_Validation_Err_When_Invoke_Create_User_With_MockUserRepository(t *testing.T) {
 	mockUseCaseSetup(t)
 
 	// GIVEN
-	user := domain.User{Age: 18}
-	validationErr := domain.NewValidationError("The name should not be empty.")
+	user := domain.User{Age: 18, Email: "test@example.com"}
 
 	// WHEN
-	_, err := _userUseCase.CreateUser(user)
+	_, err := _userUseCase.CreateUser(context.Background(), user)
 
 	// THEN
 	assert.NotNil(t, err)
-	assert.Equal(t, validationErr.Message, err.Message)
+	assert.Equal(t, domain.ErrCodeValidationFailed, err.Code)
+	assert.Equal(t, []domain.FieldViolation{{Field: "name", Rule: "required", Message: "is required"}}, err.Details)
 }
 
 func Test(t *testing.T) {
 	mockUseCaseSetup(t)
 
 	// GIVEN
-	user := domain.User{Name: "test-user", Age: 18}
+	user := domain.User{Name: "test-user", Age: 18, Email: "test@example.com"}
 	expectedErr := domain.NewUnexpectedError("Unexpected error.")
 
 	// WHEN
-	_userMockRepo.EXPECT().CreateUser(gomock.Any()).Return(domain.User{}, expectedErr)
-	_, err := _userUseCase.CreateUser(user)
+	_userMockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(domain.User{}, expectedErr)
+	_, err := _userUseCase.CreateUser(context.Background(), user)
 
 	// THEN
 	assert.NotNil(t, err)
@@ -81,8 +139,8 @@ func Test_Should_Return_Unexpected_Err_When_Invoke_Create_User_With_MockUserRepo
 	var id uint = 1
 
 	// WHEN
-	_userMockRepo.EXPECT().GetUserById(id).Return(expectedUser, nil)
-	res, err := _userUseCase.GetUserById(id)
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), id).Return(expectedUser, nil)
+	res, err := _userUseCase.GetUserById(context.Background(), id)
 
 	// THEN
 	assert.Nil(t, err)
@@ -98,8 +156,8 @@ func Test_Should_Return_Not_Found_Err_When_Invoke_Get_User_By_Id_With_MockUserRe
 	notFoundErr := domain.NewNotFoundError(errStr)
 
 	// WHEN
-	_userMockRepo.EXPECT().GetUserById(gomock.Any()).Return(domain.User{}, notFoundErr)
-	_, err := _userUseCase.GetUserById(id)
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), gomock.Any()).Return(domain.User{}, notFoundErr)
+	_, err := _userUseCase.GetUserById(context.Background(), id)
 
 	// THEN
 	assert.NotNil(t, err)
@@ -110,36 +168,290 @@ func Test_Should_Update_User_With_MockUserRepository(t *testing.T) {
 	mockUseCaseSetup(t)
 
 	// GIVEN
-	user := domain.User{ID: 1, Name: "updated-user", Age: 18}
+	user := domain.User{ID: 1, Name: "updated-user", Age: 18, Email: "test@example.com"}
 	expectedUser := domain.User{ID: 1, Name: "updated-user", Age: 18}
 
 	// WHEN
-	_userMockRepo.EXPECT().UpdateUser(gomock.Any()).Return(expectedUser, nil)
-	res, err := _userUseCase.UpdateUser(user)
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(expectedUser, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(expectedUser, nil)
+	res, err := _userUseCase.UpdateUser(context.Background(), user)
 
 	// THEN
 	assert.Nil(t, err)
 	assert.Equal(t, expectedUser.ID, res.ID)
 	assert.Equal(t, expectedUser.Name, res.Name)
+	assert.Len(t, _recordedEvents, 1)
+	assert.Equal(t, domain.UserUpdated, _recordedEvents[0].EventType)
+}
+
+func Test_Should_Keep_Status_And_Roles_When_Invoke_Update_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "updated-user", Age: 18, Email: "test@example.com", Status: domain.UserActive, Roles: domain.Roles{"admin"}}
+	existingUser := domain.User{ID: 1, Name: "user", Age: 18, Email: "test@example.com", Status: domain.UserSuspended, StatusReason: "chargeback",
+		Roles: domain.Roles{"viewer"}}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(existingUser, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		return user, nil
+	})
+	res, err := _userUseCase.UpdateUser(context.Background(), user)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.UserSuspended, res.Status)
+	assert.Equal(t, "chargeback", res.StatusReason)
+	assert.Equal(t, domain.Roles{"viewer"}, res.Roles)
+}
+
+func Test_Should_Create_User_Without_Roles_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{Name: "test", Age: 18, Roles: domain.Roles{"admin"}}
+
+	// WHEN
+	_userMockRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		user.ID = 1
+		return user, nil
+	})
+	res, err := _userUseCase.CreateUser(context.Background(), user)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.Roles{}, res.Roles)
+	assert.Empty(t, res.Email)
+}
+
+func Test_Should_Set_User_Roles_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	existingUser := domain.User{ID: 1, Name: "user", Age: 18, Status: domain.UserActive, Roles: domain.Roles{"viewer"}, Version: 2}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), existingUser.ID).Return(existingUser, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		return user, nil
+	})
+	res, err := _userUseCase.SetUserRoles(context.Background(), existingUser.ID, 2, domain.Roles{"admin", "billing"})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.Roles{"admin", "billing"}, res.Roles)
+	assert.Len(t, _recordedEvents, 1)
+	assert.Equal(t, domain.UserUpdated, _recordedEvents[0].EventType)
+}
+
+func Test_Should_Return_Validation_Err_When_Invoke_Set_User_Roles_With_Invalid_Role(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	existingUser := domain.User{ID: 1, Name: "user", Age: 18, Status: domain.UserActive, Roles: domain.Roles{}}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), existingUser.ID).Return(existingUser, nil)
+	_, err := _userUseCase.SetUserRoles(context.Background(), existingUser.ID, domain.AnyVersion, domain.Roles{"Admin"})
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeValidationFailed, err.Code)
+	assert.Empty(t, _recordedEvents)
+}
+
+func Test_Should_Suspend_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	existingUser := domain.User{ID: 1, Name: "user", Age: 18, Email: "test@example.com", Status: domain.UserActive, Version: 2}
+	change := domain.StatusChange{Reason: "chargeback", Actor: "support"}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), existingUser.ID).Return(existingUser, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		assert.Equal(t, uint(2), user.Version)
+		return user, nil
+	})
+	res, err := _userUseCase.TransitionUser(context.Background(), existingUser.ID, domain.TransitionSuspend, change)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.UserSuspended, res.Status)
+	assert.Equal(t, "chargeback", res.StatusReason)
+	assert.Equal(t, "support", res.StatusChangedBy)
+	assert.NotNil(t, res.StatusChangedAt)
+	assert.Len(t, _recordedEvents, 1)
+	assert.Equal(t, domain.UserUpdated, _recordedEvents[0].EventType)
+}
+
+func Test_Should_Record_Principal_As_Actor_When_Invoke_Transition_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	existingUser := domain.User{ID: 1, Name: "user", Age: 18, Status: domain.UserActive}
+	ctx := domain.WithAuditMetadata(context.Background(), domain.AuditMetadata{Actor: "claimed-actor"})
+	ctx = domain.WithPrincipal(ctx, domain.Principal{Subject: "service-account"})
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), existingUser.ID).Return(existingUser, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, user domain.User) (domain.User, *domain.AppError) {
+		return user, nil
+	})
+	res, err := _userUseCase.TransitionUser(ctx, existingUser.ID, domain.TransitionSuspend, domain.StatusChange{Reason: "chargeback", Actor: "spoofed-actor"})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "service-account", res.StatusChangedBy)
+}
+
+func Test_Should_Return_Invalid_Transition_Err_When_Invoke_Transition_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	existingUser := domain.User{ID: 1, Name: "user", Age: 18, Email: "test@example.com", Status: domain.UserDeactivated}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), existingUser.ID).Return(existingUser, nil)
+	_, err := _userUseCase.TransitionUser(context.Background(), existingUser.ID, domain.TransitionActivate, domain.StatusChange{})
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeInvalidTransition, err.Code)
+	assert.Equal(t, 409, err.Status)
+	assert.Empty(t, _recordedEvents)
+}
+
+func Test_Should_Return_Validation_Err_When_Invoke_Update_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{Name: "updated-user", Age: 18, Email: "test@example.com"}
+
+	// WHEN
+	_, err := _userUseCase.UpdateUser(context.Background(), user)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeValidationFailed, err.Code)
 }
 
 func Test_Should_Return_Unexpected_Err_When_Invoke_Update_User_With_MockUserRepository(t *testing.T) {
 	mockUseCaseSetup(t)
 
 	// GIVEN
-	user := domain.User{ID: 1, Name: "updated-user", Age: 18}
+	user := domain.User{ID: 1, Name: "updated-user", Age: 18, Email: "test@example.com"}
 	errStr := fmt.Sprintf("Unexpected Error")
 	expectedErr := domain.NewUnexpectedError(errStr)
 
 	// WHEN
-	_userMockRepo.EXPECT().UpdateUser(gomock.Any()).Return(domain.User{}, expectedErr)
-	_, err := _userUseCase.UpdateUser(user)
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(user, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(domain.User{}, expectedErr)
+	_, err := _userUseCase.UpdateUser(context.Background(), user)
 
 	// THEN
 	assert.NotNil(t, err)
 	assert.Equal(t, expectedErr.Message, err.Message)
 }
 
+func Test_Should_Return_Not_Found_Err_When_Invoke_Update_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "updated-user", Age: 18, Email: "test@example.com"}
+	notFoundErr := domain.NewUserNotFoundError(user.ID)
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(domain.User{}, notFoundErr)
+	_, err := _userUseCase.UpdateUser(context.Background(), user)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeUserNotFound, err.Code)
+}
+
+func Test_Should_Return_Precondition_Failed_Err_When_Invoke_Update_User_With_Stale_Version(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "updated-user", Age: 18, Email: "test@example.com", Version: 1}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(domain.User{ID: 1, Name: "test", Age: 18, Version: 2}, nil)
+	_, err := _userUseCase.UpdateUser(context.Background(), user)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, 412, err.Status)
+}
+
+func Test_Should_Merge_Patch_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "test-user", Age: 18}
+	expectedUser := domain.User{ID: 1, Name: "test-user", Age: 30}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(user, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), expectedUser).Return(expectedUser, nil)
+	res, err := _userUseCase.PatchUser(context.Background(), user.ID, domain.AnyVersion, domain.MergePatch, []byte(`{"age":30,"id":7,"roles":["admin"]}`))
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedUser, res)
+}
+
+func Test_Should_Json_Patch_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "test-user", Age: 18}
+	expectedUser := domain.User{ID: 1, Name: "patched-user", Age: 18}
+	patch := []byte(`[{"op":"test","path":"/age","value":18},{"op":"replace","path":"/name","value":"patched-user"}]`)
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(user, nil)
+	_userMockRepo.EXPECT().UpdateUser(gomock.Any(), expectedUser).Return(expectedUser, nil)
+	res, err := _userUseCase.PatchUser(context.Background(), user.ID, domain.AnyVersion, domain.JSONPatch, patch)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, expectedUser, res)
+}
+
+func Test_Should_Return_Validation_Err_When_Invoke_Patch_User_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "test-user", Age: 18}
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(user, nil)
+	_, err := _userUseCase.PatchUser(context.Background(), user.ID, domain.AnyVersion, domain.MergePatch, []byte(`{"age":500}`))
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeValidationFailed, err.Code)
+}
+
+func Test_Should_Return_Conflict_Err_When_Json_Patch_Test_Fails_With_MockUserRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	user := domain.User{ID: 1, Name: "test-user", Age: 18}
+	patch := []byte(`[{"op":"test","path":"/age","value":99},{"op":"replace","path":"/age","value":20}]`)
+
+	// WHEN
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), user.ID).Return(user, nil)
+	_, err := _userUseCase.PatchUser(context.Background(), user.ID, domain.AnyVersion, domain.JSONPatch, patch)
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, 409, err.Status)
+}
+
 func Test_Should_Delete_User_By_Id_With_MockUserRepository(t *testing.T) {
 	mockUseCaseSetup(t)
 
@@ -147,11 +459,18 @@ func Test_Should_Delete_User_By_Id_With_MockUserRepository(t *testing.T) {
 	var id uint = 1
 
 	// WHEN
-	_userMockRepo.EXPECT().DeleteUserById(gomock.Any()).Return(nil)
-	err := _userUseCase.DeleteUserById(id)
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), id).Return(domain.User{ID: id, Name: "test", Age: 18, Version: 1}, nil)
+	_userMockRepo.EXPECT().DeleteUserById(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	err := _userUseCase.DeleteUserById(context.Background(), id, domain.AnyVersion)
 
 	// THEN
 	assert.Nil(t, err)
+	assert.Len(t, _recordedEvents, 1)
+	assert.Equal(t, domain.UserDeleted, _recordedEvents[0].EventType)
+	assert.Len(t, _recordedAudit, 1)
+	assert.Equal(t, domain.AuditDelete, _recordedAudit[0].Action)
+	assert.Len(t, _recordedAudit[0].Changes, 1)
+	assert.Contains(t, _recordedAudit[0].Changes, "deleted_at")
 }
 
 func Test_Should_Return_Unexpected_Err_When_Invoke_Delete_User_By_Id_With_MockUserRepository(t *testing.T) {
@@ -163,10 +482,45 @@ func Test_Should_Return_Unexpected_Err_When_Invoke_Delete_User_By_Id_With_MockUs
 	expectedErr := domain.NewUnexpectedError(errStr)
 
 	// WHEN
-	_userMockRepo.EXPECT().DeleteUserById(gomock.Any()).Return(expectedErr)
-	err := _userUseCase.DeleteUserById(id)
+	_userMockRepo.EXPECT().GetUserById(gomock.Any(), id).Return(domain.User{ID: id, Name: "test", Age: 18, Version: 1}, nil)
+	_userMockRepo.EXPECT().DeleteUserById(gomock.Any(), gomock.Any(), gomock.Any()).Return(expectedErr)
+	err := _userUseCase.DeleteUserById(context.Background(), id, domain.AnyVersion)
 
 	// THEN
 	assert.NotNil(t, err)
 	assert.Equal(t, expectedErr.Message, err.Message)
 }
+
+func Test_Should_Return_User_History_Page_With_MockAuditRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	var id uint = 1
+	entries := []domain.AuditEntry{{ID: 9, UserID: id}, {ID: 7, UserID: id}, {ID: 4, UserID: id}}
+
+	// WHEN
+	_auditMockRepo.EXPECT().ListByUser(gomock.Any(), id, uint64(10), time.Time{}, 3).Return(entries, nil)
+	history, err := _userUseCase.GetUserHistory(context.Background(), id, domain.HistoryQuery{Before: 10, Limit: 2})
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Len(t, history.Entries, 2)
+	assert.Equal(t, uint64(7), history.NextBefore)
+	assert.Nil(t, history.User)
+}
+
+func Test_Should_Return_Not_Found_Err_When_User_Has_No_History_With_MockAuditRepository(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	var id uint = 1
+
+	// WHEN
+	_auditMockRepo.EXPECT().ListByUser(gomock.Any(), id, uint64(0), time.Time{}, 51).Return(nil, nil)
+	_userMockRepo.EXPECT().GetUserByIdIncludingDeleted(gomock.Any(), id).Return(domain.User{}, domain.NewUserNotFoundError(id))
+	_, err := _userUseCase.GetUserHistory(context.Background(), id, domain.HistoryQuery{Limit: 50})
+
+	// THEN
+	assert.NotNil(t, err)
+	assert.Equal(t, domain.ErrCodeUserNotFound, err.Code)
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"fmt"
+	"go-app/domain"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// privateNetworks are the ranges net.IP has no predicate for, they are not reachable from the internet either.
+var privateNetworks = []*net.IPNet{
+	mustParseCIDR("100.64.0.0/10"), // carrier grade NAT
+	mustParseCIDR("192.0.0.0/24"),  // IETF protocol assignments
+	mustParseCIDR("198.18.0.0/15"), // benchmarking
+	mustParseCIDR("64:ff9b::/96"),  // NAT64, may reach an IPv4 private address
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// publicIP tells whether the IP may receive webhooks, loopback, private, link local and unspecified addresses may not.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+checkDestination rejects a subscription URL whose host is localhost or an IP that is not public.
+Host names are resolved when a delivery is sent, the dispatcher checks the address it connects to again.
+*/
+func checkDestination(rawURL string) *domain.AppError {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil // the validation of the request reports it
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	ip := net.ParseIP(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || (ip != nil && !publicIP(ip)) {
+		return domain.NewValidationError("Validation failed.").WithDetails([]domain.FieldViolation{
+			{Field: "url", Rule: "public_host", Message: "must not be a loopback, private or link local address"},
+		})
+	}
+	return nil
+}
+
+// newClient returns an HTTP client which refuses to connect to the addresses publicIP rejects, unless private networks are allowed.
+func newClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("webhook destination %s is not a public address", host)
+			}
+			return nil
+		}
+	}
+
+	// Without a proxy the dialer sees the address of the subscriber, redirects are dialed through it as well.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
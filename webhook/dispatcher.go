@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-app/domain"
+	"go-app/metrics"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type DispatcherOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Timeout      time.Duration
+	// A delivery goes to the dead letter state after this many failed attempts.
+	MaxAttempts int
+	RetryBase   time.Duration
+	RetryMax    time.Duration
+	// AllowPrivateNetworks lets deliveries reach loopback and private addresses, for subscribers on the same network.
+	AllowPrivateNetworks bool
+}
+
+// Bytes of a failed response body kept as the last error of a delivery.
+const maxErrorBody = 512
+
+// Dispatcher sends the due webhook deliveries to the subscribers.
+type Dispatcher struct {
+	repo      domain.WebhookRepository
+	txManager domain.TxManager
+	client    *http.Client
+	logger    *zap.Logger
+	options   DispatcherOptions
+}
+
+func NewDispatcher(repo domain.WebhookRepository, txManager domain.TxManager, logger *zap.Logger, options DispatcherOptions) *Dispatcher {
+	return &Dispatcher{
+		repo:      repo,
+		txManager: txManager,
+		client:    newClient(options.Timeout, options.AllowPrivateNetworks),
+		logger:    logger,
+		options:   options,
+	}
+}
+
+// Start polls for due deliveries on every interval until the context is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Webhook dispatcher stopped.")
+			return
+		case <-ticker.C:
+			_, _ = d.RunOnce(ctx)
+		}
+	}
+}
+
+/*
+RunOnce sends one batch of due deliveries and returns how many succeeded.
+The deliveries stay locked until the batch is done, so instances running side by side never send the same delivery.
+*/
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, *domain.AppError) {
+	var succeeded int
+	err := d.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		succeeded = 0
+		deliveries, err := d.repo.FetchDueDeliveries(ctx, d.options.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		subscriptions := make(map[uint]domain.WebhookSubscription)
+		for _, delivery := range deliveries {
+			subscription, ok := subscriptions[delivery.SubscriptionID]
+			if !ok {
+				if subscription, err = d.repo.GetSubscription(ctx, delivery.SubscriptionID); err != nil {
+					return err
+				}
+				subscriptions[delivery.SubscriptionID] = subscription
+			}
+
+			delivery = d.attempt(ctx, subscription, delivery)
+			if err := d.repo.UpdateDelivery(ctx, delivery); err != nil {
+				return err
+			}
+			if delivery.Status == domain.DeliverySucceeded {
+				succeeded++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		d.logger.Error(err.Message, zap.Error(err))
+		return 0, err
+	}
+	return succeeded, nil
+}
+
+// attempt sends the delivery once and returns it with the outcome recorded.
+func (d *Dispatcher) attempt(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) domain.WebhookDelivery {
+	timer := time.Now()
+	delivery.Attempts++
+	statusCode, err := d.send(ctx, subscription, delivery)
+	metrics.WebhookDeliveryDuration.Observe(time.Since(timer).Seconds())
+
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		now := time.Now()
+		delivery.Status = domain.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		metrics.WebhookDeliveries.WithLabelValues(string(domain.DeliverySucceeded)).Inc()
+		return delivery
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= d.options.MaxAttempts {
+		delivery.Status = domain.DeliveryDeadLetter
+		metrics.WebhookDeliveries.WithLabelValues(string(domain.DeliveryDeadLetter)).Inc()
+		d.logger.Error(fmt.Sprintf("Webhook delivery moved to dead letter. Delivery: %d, subscription: %d, attempts: %d",
+			delivery.ID, subscription.ID, delivery.Attempts), zap.Error(err))
+		return delivery
+	}
+
+	delivery.NextAttemptAt = time.Now().Add(d.backoff(delivery.Attempts))
+	metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+	d.logger.Warn(fmt.Sprintf("Webhook delivery failed. Delivery: %d, subscription: %d, attempt: %d, next attempt: %s",
+		delivery.ID, subscription.ID, delivery.Attempts, delivery.NextAttemptAt.Format(time.RFC3339)), zap.Error(err))
+	return delivery
+}
+
+func (d *Dispatcher) send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(delivery.Attempts))
+	req.Header.Set("X-Event-Id", delivery.EventID)
+	req.Header.Set("X-Event-Type", string(delivery.EventType))
+	req.Header.Set(SignatureHeader, Sign(subscription.Secret, time.Now(), delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d: %s", resp.StatusCode, body)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the delay with every attempt, up to RetryMax.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.options.RetryBase
+	for i := 1; i < attempts && delay < d.options.RetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, d.options.RetryMax)
+}
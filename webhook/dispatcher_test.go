@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/domain"
+	"go-app/mocks"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecret = "secret-of-the-subscriber"
+
+func mockDispatcherSetup(t *testing.T, url string, allowPrivateNetworks bool) (*mocks.MockWebhookRepository, *Dispatcher) {
+	c := gomock.NewController(t)
+	repo := mocks.NewMockWebhookRepository(c)
+	txManager := mocks.NewMockTxManager(c)
+	txManager.EXPECT().WithinTx(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, fn func(ctx context.Context) error, opts ...domain.TxOption) *domain.AppError {
+			if err := fn(ctx); err != nil {
+				return err.(*domain.AppError)
+			}
+			return nil
+		}).AnyTimes()
+	repo.EXPECT().GetSubscription(gomock.Any(), uint(1)).
+		Return(domain.WebhookSubscription{ID: 1, URL: url, Secret: testSecret, Active: true}, nil).AnyTimes()
+
+	dispatcher := NewDispatcher(repo, txManager, config.ZapTestConfig(), DispatcherOptions{
+		PollInterval:         time.Second,
+		BatchSize:            10,
+		Timeout:              time.Second,
+		MaxAttempts:          3,
+		RetryBase:            time.Second,
+		RetryMax:             time.Minute,
+		AllowPrivateNetworks: allowPrivateNetworks,
+	})
+	return repo, dispatcher
+}
+
+func dueDelivery(attempts int) domain.WebhookDelivery {
+	return domain.WebhookDelivery{ID: 7, SubscriptionID: 1, EventID: "e1", EventType: domain.UserCreated,
+		Payload: []byte(`{"id":"e1"}`), Status: domain.DeliveryPending, Attempts: attempts}
+}
+
+func Test_Should_Send_Signed_Delivery_And_Mark_It_Succeeded(t *testing.T) {
+	// GIVEN
+	var signed bool
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signed = Verify(testSecret, r.Header.Get(SignatureHeader), body, time.Minute)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer subscriber.Close()
+	repo, dispatcher := mockDispatcherSetup(t, subscriber.URL, true)
+
+	// WHEN
+	var updated domain.WebhookDelivery
+	repo.EXPECT().FetchDueDeliveries(gomock.Any(), 10).Return([]domain.WebhookDelivery{dueDelivery(0)}, nil)
+	repo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.WebhookDelivery) *domain.AppError {
+		updated = delivery
+		return nil
+	})
+	succeeded, err := dispatcher.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 1, succeeded)
+	assert.True(t, signed)
+	assert.Equal(t, domain.DeliverySucceeded, updated.Status)
+	assert.Equal(t, http.StatusNoContent, updated.LastStatusCode)
+	assert.NotNil(t, updated.DeliveredAt)
+}
+
+func Test_Should_Schedule_Retry_When_Subscriber_Fails(t *testing.T) {
+	// GIVEN
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer subscriber.Close()
+	repo, dispatcher := mockDispatcherSetup(t, subscriber.URL, true)
+
+	// WHEN
+	var updated domain.WebhookDelivery
+	repo.EXPECT().FetchDueDeliveries(gomock.Any(), 10).Return([]domain.WebhookDelivery{dueDelivery(1)}, nil)
+	repo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.WebhookDelivery) *domain.AppError {
+		updated = delivery
+		return nil
+	})
+	succeeded, err := dispatcher.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 0, succeeded)
+	assert.Equal(t, domain.DeliveryPending, updated.Status)
+	assert.Equal(t, 2, updated.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, updated.LastStatusCode)
+	assert.WithinDuration(t, time.Now().Add(2*time.Second), updated.NextAttemptAt, time.Second)
+}
+
+func Test_Should_Move_Delivery_To_Dead_Letter_After_Last_Attempt(t *testing.T) {
+	// GIVEN
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer subscriber.Close()
+	repo, dispatcher := mockDispatcherSetup(t, subscriber.URL, true)
+
+	// WHEN
+	var updated domain.WebhookDelivery
+	repo.EXPECT().FetchDueDeliveries(gomock.Any(), 10).Return([]domain.WebhookDelivery{dueDelivery(2)}, nil)
+	repo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.WebhookDelivery) *domain.AppError {
+		updated = delivery
+		return nil
+	})
+	_, err := dispatcher.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.DeliveryDeadLetter, updated.Status)
+	assert.Equal(t, 3, updated.Attempts)
+	assert.Contains(t, updated.LastError, "410")
+}
+
+func Test_Should_Not_Send_Delivery_To_Loopback_Address_When_Private_Networks_Are_Not_Allowed(t *testing.T) {
+	// GIVEN
+	var called bool
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer subscriber.Close()
+	repo, dispatcher := mockDispatcherSetup(t, subscriber.URL, false)
+
+	// WHEN
+	var updated domain.WebhookDelivery
+	repo.EXPECT().FetchDueDeliveries(gomock.Any(), 10).Return([]domain.WebhookDelivery{dueDelivery(0)}, nil)
+	repo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, delivery domain.WebhookDelivery) *domain.AppError {
+		updated = delivery
+		return nil
+	})
+	succeeded, err := dispatcher.RunOnce(context.Background())
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, 0, succeeded)
+	assert.False(t, called)
+	assert.Equal(t, domain.DeliveryPending, updated.Status)
+	assert.Contains(t, updated.LastError, "not a public address")
+}
@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	sentrygin "github.com/getsentry/sentry-go/gin"
+	"github.com/gin-gonic/gin"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type Handler struct {
+	webhookUseCase domain.WebhookUseCase
+	logger         *zap.Logger
+}
+
+func NewWebhookHandler(webhookUseCase domain.WebhookUseCase, logger *zap.Logger) *Handler {
+	return &Handler{webhookUseCase: webhookUseCase, logger: logger}
+}
+
+// CreateSubscription godoc
+// @Summary Create Webhook Subscription
+// @Description Subscribe a URL to user events. The response holds the signing secret, it is not returned again.
+// @Description Loopback, private and link local addresses are refused unless WEBHOOK_ALLOW_PRIVATE_NETWORKS is set.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param subscription body domain.WebhookSubscriptionRequest true "Subscription to be created"
+// @Success 201 {object} domain.WebhookSubscription "Returns created subscription"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/webhooks [post]
+func (h *Handler) CreateSubscription(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		var request domain.WebhookSubscriptionRequest
+		if c.ShouldBindJSON(&request) != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		subscription, err := h.webhookUseCase.CreateSubscription(c.Request.Context(), request)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, subscription)
+	}
+}
+
+// ListSubscriptions godoc
+// @Summary List Webhook Subscriptions
+// @Description List all webhook subscriptions.
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} domain.WebhookSubscription "Returns subscriptions"
+// @Router /api/v1/webhooks [get]
+func (h *Handler) ListSubscriptions(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		subscriptions, err := h.webhookUseCase.ListSubscriptions(c.Request.Context())
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		if subscriptions == nil {
+			subscriptions = []domain.WebhookSubscription{}
+		}
+		c.JSON(http.StatusOK, subscriptions)
+	}
+}
+
+// GetSubscription godoc
+// @Summary Get a webhook subscription by ID
+// @Description Retrieve a webhook subscription, without its secret.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} domain.WebhookSubscription "Returns subscription"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/webhooks/{id} [get]
+func (h *Handler) GetSubscription(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		subscription, err := h.webhookUseCase.GetSubscription(c.Request.Context(), id)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, subscription)
+	}
+}
+
+// UpdateSubscription godoc
+// @Summary Update Webhook Subscription
+// @Description Replace the URL, event types and state of a subscription. The secret is rotated when one is given.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param subscription body domain.WebhookSubscriptionRequest true "Subscription to be updated"
+// @Success 200 {object} domain.WebhookSubscription "Returns updated subscription"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/webhooks/{id} [put]
+func (h *Handler) UpdateSubscription(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		var request domain.WebhookSubscriptionRequest
+		if c.ShouldBindJSON(&request) != nil {
+			errorResponse(c, domain.NewBadRequestError("bad request"))
+			return
+		}
+
+		subscription, err := h.webhookUseCase.UpdateSubscription(c.Request.Context(), id, request)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, subscription)
+	}
+}
+
+// DeleteSubscription godoc
+// @Summary Delete Webhook Subscription
+// @Description Delete a subscription together with its delivery log.
+// @Tags webhooks
+// @Param id path int true "Subscription ID"
+// @Success 204 "Subscription deleted"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *Handler) DeleteSubscription(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		if err := h.webhookUseCase.DeleteSubscription(c.Request.Context(), id); err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// ListDeliveries godoc
+// @Summary List Webhook Deliveries
+// @Description Delivery log of a subscription, newest first.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param status query string false "Only deliveries in this status" Enums(pending, succeeded, dead_letter)
+// @Param limit query int false "Maximum number of deliveries, 50 by default, at most 500"
+// @Success 200 {array} domain.WebhookDelivery "Returns deliveries"
+// @Success 400 {object} domain.ProblemDetails "Returns error"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		status := domain.DeliveryStatus(c.Query("status"))
+		switch status {
+		case "", domain.DeliveryPending, domain.DeliverySucceeded, domain.DeliveryDeadLetter:
+		default:
+			errorResponse(c, domain.NewBadRequestError("Invalid delivery status: "+string(status)))
+			return
+		}
+
+		limit := defaultDeliveryLimit
+		if value := c.Query("limit"); value != "" {
+			parsed, parseErr := strconv.Atoi(value)
+			if parseErr != nil || parsed < 1 || parsed > maxDeliveryLimit {
+				errorResponse(c, domain.NewBadRequestError("Invalid limit: "+value))
+				return
+			}
+			limit = parsed
+		}
+
+		deliveries, err := h.webhookUseCase.ListDeliveries(c.Request.Context(), id, status, limit)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		if deliveries == nil {
+			deliveries = []domain.WebhookDelivery{}
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+// Redeliver godoc
+// @Summary Redeliver Webhook Delivery
+// @Description Queue a delivery again with fresh attempts, typically one in the dead letter state.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 202 {object} domain.WebhookDelivery "Returns the queued delivery"
+// @Success 404 {object} domain.ProblemDetails "Returns error"
+// @Router /api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *Handler) Redeliver(c *gin.Context) {
+	if hub := sentrygin.GetHubFromContext(c); hub != nil {
+		id, err := parseId(c)
+		if err != nil {
+			errorResponse(c, err)
+			return
+		}
+
+		deliveryID, parseErr := strconv.ParseUint(c.Param("deliveryId"), 10, 64)
+		if parseErr != nil || deliveryID == 0 {
+			errorResponse(c, domain.NewBadRequestError("Invalid delivery ID: "+c.Param("deliveryId")))
+			return
+		}
+
+		delivery, err := h.webhookUseCase.Redeliver(c.Request.Context(), id, deliveryID)
+		if err != nil {
+			hub.CaptureException(err)
+			errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, delivery)
+	}
+}
+
+func errorResponse(c *gin.Context, err *domain.AppError) {
+	c.Header("Content-Type", domain.ProblemContentType)
+	if err.Retryable {
+		c.Header("Retry-After", "1")
+	}
+	c.JSON(err.Status, err.Problem(c.Request.URL.Path))
+}
+
+func parseId(c *gin.Context) (uint, *domain.AppError) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, domain.NewBadRequestError("Invalid subscription ID: " + c.Param("id"))
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,151 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-app/database"
+	"go-app/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks/mockWebhookRepository.go -package=mocks go-app/domain WebhookRepository
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) domain.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	if err := database.Conn(ctx, r.db).Create(&subscription).Error; err != nil {
+		return subscription, database.TranslateError(err)
+	}
+	return subscription, nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, id uint) (domain.WebhookSubscription, *domain.AppError) {
+	var subscription domain.WebhookSubscription
+	err := database.Conn(ctx, r.db).First(&subscription, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return subscription, subscriptionNotFound(id)
+	}
+	if err != nil {
+		return subscription, database.TranslateError(err)
+	}
+	return subscription, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, *domain.AppError) {
+	var subscriptions []domain.WebhookSubscription
+	if err := database.Conn(ctx, r.db).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+	result := database.Conn(ctx, r.db).Model(&subscription).Select("url", "event_types", "secret", "active", "updated_at").Updates(&subscription)
+	if result.Error != nil {
+		return subscription, database.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return subscription, subscriptionNotFound(subscription.ID)
+	}
+	return subscription, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uint) *domain.AppError {
+	conn := database.Conn(ctx, r.db)
+	if err := conn.Where("subscription_id = ?", id).Delete(&domain.WebhookDelivery{}).Error; err != nil {
+		return database.TranslateError(err)
+	}
+	result := conn.Delete(&domain.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return database.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return subscriptionNotFound(id)
+	}
+	return nil
+}
+
+func (r *webhookRepository) ListActiveSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, *domain.AppError) {
+	var subscriptions []domain.WebhookSubscription
+	if err := database.Conn(ctx, r.db).Where("active").Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return subscriptions, nil
+}
+
+func (r *webhookRepository) AddDeliveries(ctx context.Context, deliveries ...domain.WebhookDelivery) *domain.AppError {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	err := database.Conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func (r *webhookRepository) FetchDueDeliveries(ctx context.Context, limit int) ([]domain.WebhookDelivery, *domain.AppError) {
+	var deliveries []domain.WebhookDelivery
+	query := database.Conn(ctx, r.db)
+	// SQLite has no row locks, it serves a single instance.
+	if r.db.Dialector.Name() == "postgres" {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "webhook_deliveries"}, Options: "SKIP LOCKED"})
+	}
+
+	// Deliveries of paused subscriptions wait until the subscription is active again.
+	err := query.
+		Joins("JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id AND webhook_subscriptions.active").
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", domain.DeliveryPending, time.Now()).
+		Order("webhook_deliveries.id").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, subscriptionID uint, deliveryID uint64) (domain.WebhookDelivery, *domain.AppError) {
+	var delivery domain.WebhookDelivery
+	err := database.Conn(ctx, r.db).Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).First(&delivery).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return delivery, domain.NewNotFoundError(fmt.Sprintf("Webhook delivery not found, ID: %d", deliveryID))
+	}
+	if err != nil {
+		return delivery, database.TranslateError(err)
+	}
+	return delivery, nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uint, status domain.DeliveryStatus, limit int) ([]domain.WebhookDelivery, *domain.AppError) {
+	query := database.Conn(ctx, r.db).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []domain.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) *domain.AppError {
+	err := database.Conn(ctx, r.db).Model(&delivery).
+		Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at").
+		Updates(&delivery).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+func subscriptionNotFound(id uint) *domain.AppError {
+	return domain.NewNotFoundError(fmt.Sprintf("Webhook subscription not found, ID: %d", id))
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_Should_Verify_Signed_Body(t *testing.T) {
+	// GIVEN
+	body := []byte(`{"id":"e1"}`)
+	header := Sign("secret-of-the-subscriber", time.Now(), body)
+
+	// WHEN
+	valid := Verify("secret-of-the-subscriber", header, body, time.Minute)
+
+	// THEN
+	assert.True(t, valid)
+}
+
+func Test_Should_Reject_Signature_Of_Tampered_Body_Or_Wrong_Secret(t *testing.T) {
+	// GIVEN
+	body := []byte(`{"id":"e1"}`)
+	header := Sign("secret-of-the-subscriber", time.Now(), body)
+
+	// WHEN
+	tampered := Verify("secret-of-the-subscriber", header, []byte(`{"id":"e2"}`), time.Minute)
+	wrongSecret := Verify("another-secret", header, body, time.Minute)
+
+	// THEN
+	assert.False(t, tampered)
+	assert.False(t, wrongSecret)
+}
+
+func Test_Should_Reject_Signature_Outside_Tolerance(t *testing.T) {
+	// GIVEN
+	body := []byte(`{"id":"e1"}`)
+	header := Sign("secret-of-the-subscriber", time.Now().Add(-10*time.Minute), body)
+
+	// WHEN
+	valid := Verify("secret-of-the-subscriber", header, body, 5*time.Minute)
+
+	// THEN
+	assert.False(t, valid)
+}
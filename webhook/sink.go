@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"go-app/domain"
+	"time"
+)
+
+// sink is the outbox sink fanning every event out to one delivery per matching subscription.
+type sink struct {
+	repo domain.WebhookRepository
+}
+
+// NewSink returns the outbox sink of the webhooks. The deliveries are stored in the transaction of the relay.
+func NewSink(repo domain.WebhookRepository) domain.EventSink {
+	return &sink{repo: repo}
+}
+
+func (s *sink) Name() string {
+	return "webhook"
+}
+
+func (s *sink) Publish(ctx context.Context, envelope domain.EventEnvelope) error {
+	subscriptions, err := s.repo.ListActiveSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, jsonErr := json.Marshal(envelope)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	now := time.Now()
+	var deliveries []domain.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(envelope.Type) {
+			continue
+		}
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        envelope.ID,
+			EventType:      envelope.Type,
+			Payload:        payload,
+			Status:         domain.DeliveryPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	if err := s.repo.AddDeliveries(ctx, deliveries...); err != nil {
+		return err
+	}
+	return nil
+}
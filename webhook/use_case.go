@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"go-app/domain"
+	"go.uber.org/zap"
+	"time"
+)
+
+//go:generate mockgen -destination=../mocks/mockWebhookUsecase.go -package=mocks go-app/domain WebhookUseCase
+type webhookUseCase struct {
+	repo                 domain.WebhookRepository
+	logger               *zap.Logger
+	allowPrivateNetworks bool
+}
+
+// NewWebhookUseCase rejects subscriptions to loopback and private addresses unless allowPrivateNetworks is set.
+func NewWebhookUseCase(repo domain.WebhookRepository, logger *zap.Logger, allowPrivateNetworks bool) domain.WebhookUseCase {
+	return &webhookUseCase{repo: repo, logger: logger, allowPrivateNetworks: allowPrivateNetworks}
+}
+
+func (u *webhookUseCase) CreateSubscription(ctx context.Context, request domain.WebhookSubscriptionRequest) (domain.WebhookSubscription, *domain.AppError) {
+	if err := u.validate(request); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	subscription, err := newSubscription(request)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return subscription, err
+	}
+
+	created, err := u.repo.CreateSubscription(ctx, subscription)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.WebhookSubscription{}, err
+	}
+
+	u.logger.Info(fmt.Sprintf("Webhook subscription created. ID: %d", created.ID))
+	return created, nil
+}
+
+func (u *webhookUseCase) GetSubscription(ctx context.Context, id uint) (domain.WebhookSubscription, *domain.AppError) {
+	subscription, err := u.repo.GetSubscription(ctx, id)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return subscription, err
+	}
+	return withoutSecret(subscription), nil
+}
+
+func (u *webhookUseCase) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, *domain.AppError) {
+	subscriptions, err := u.repo.ListSubscriptions(ctx)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return nil, err
+	}
+	for i := range subscriptions {
+		subscriptions[i] = withoutSecret(subscriptions[i])
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription replaces the subscription, the secret is kept when the request has none.
+func (u *webhookUseCase) UpdateSubscription(ctx context.Context, id uint, request domain.WebhookSubscriptionRequest) (domain.WebhookSubscription, *domain.AppError) {
+	if err := u.validate(request); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	subscription, err := u.repo.GetSubscription(ctx, id)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return subscription, err
+	}
+
+	subscription.URL = request.URL
+	subscription.EventTypes = request.EventTypes
+	if request.Secret != "" {
+		subscription.Secret = request.Secret
+	}
+	if request.Active != nil {
+		subscription.Active = *request.Active
+	}
+	subscription.UpdatedAt = time.Now()
+
+	updated, err := u.repo.UpdateSubscription(ctx, subscription)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return domain.WebhookSubscription{}, err
+	}
+	return withoutSecret(updated), nil
+}
+
+func (u *webhookUseCase) DeleteSubscription(ctx context.Context, id uint) *domain.AppError {
+	if err := u.repo.DeleteSubscription(ctx, id); err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return err
+	}
+
+	u.logger.Info(fmt.Sprintf("Webhook subscription deleted. ID: %d", id))
+	return nil
+}
+
+func (u *webhookUseCase) ListDeliveries(ctx context.Context, subscriptionID uint, status domain.DeliveryStatus, limit int) ([]domain.WebhookDelivery, *domain.AppError) {
+	if _, err := u.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return nil, err
+	}
+
+	deliveries, err := u.repo.ListDeliveries(ctx, subscriptionID, status, limit)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (u *webhookUseCase) Redeliver(ctx context.Context, subscriptionID uint, deliveryID uint64) (domain.WebhookDelivery, *domain.AppError) {
+	delivery, err := u.repo.GetDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return delivery, err
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	if err := u.repo.UpdateDelivery(ctx, delivery); err != nil {
+		u.logger.Error(err.Message, zap.Error(err))
+		return delivery, err
+	}
+
+	u.logger.Info(fmt.Sprintf("Webhook delivery queued for redelivery. ID: %d", deliveryID))
+	return delivery, nil
+}
+
+func (u *webhookUseCase) validate(request domain.WebhookSubscriptionRequest) *domain.AppError {
+	if err := request.Validate(); err != nil {
+		return err
+	}
+	if !u.allowPrivateNetworks {
+		return checkDestination(request.URL)
+	}
+	return nil
+}
+
+func newSubscription(request domain.WebhookSubscriptionRequest) (domain.WebhookSubscription, *domain.AppError) {
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			return domain.WebhookSubscription{}, domain.NewUnexpectedError("Webhook secret could not be generated.").WithCause(err)
+		}
+	}
+
+	now := time.Now()
+	return domain.WebhookSubscription{
+		URL:        request.URL,
+		EventTypes: request.EventTypes,
+		Secret:     secret,
+		Active:     request.Active == nil || *request.Active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}, nil
+}
+
+func withoutSecret(subscription domain.WebhookSubscription) domain.WebhookSubscription {
+	subscription.Secret = ""
+	return subscription
+}
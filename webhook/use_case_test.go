@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go-app/config"
+	"go-app/domain"
+	"go-app/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	_webhookMockRepo *mocks.MockWebhookRepository
+	_webhookUseCase  domain.WebhookUseCase
+	_subscription    = domain.WebhookSubscription{ID: 1, URL: "https://partner.example.com/hooks", Secret: testSecret, Active: true,
+		EventTypes: []domain.EventType{domain.UserCreated, domain.UserDeleted}}
+)
+
+func mockUseCaseSetup(t *testing.T) {
+	c := gomock.NewController(t)
+	_webhookMockRepo = mocks.NewMockWebhookRepository(c)
+	_webhookUseCase = NewWebhookUseCase(_webhookMockRepo, config.ZapTestConfig(), false)
+}
+
+func Test_Should_Create_Subscription_With_Generated_Secret(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	request := domain.WebhookSubscriptionRequest{URL: "https://partner.example.com/hooks", EventTypes: []domain.EventType{domain.UserCreated}}
+
+	// WHEN
+	_webhookMockRepo.EXPECT().CreateSubscription(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+			subscription.ID = 1
+			return subscription, nil
+		})
+	subscription, err := _webhookUseCase.CreateSubscription(context.Background(), request)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.True(t, subscription.Active)
+	assert.True(t, strings.HasPrefix(subscription.Secret, "whsec_"))
+}
+
+func Test_Should_Return_Validation_Err_When_Subscription_Is_Invalid(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	request := domain.WebhookSubscriptionRequest{URL: "ftp://partner.example.com", EventTypes: []domain.EventType{"user.renamed"}, Secret: "short"}
+
+	// WHEN
+	_, err := _webhookUseCase.CreateSubscription(context.Background(), request)
+
+	// THEN
+	assert.Equal(t, domain.ErrCodeValidationFailed, err.Code)
+	assert.Len(t, err.Details, 3)
+}
+
+func Test_Should_Return_Validation_Err_When_Subscription_Url_Is_Not_Public(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	for _, url := range []string{"http://localhost:8080/hooks", "http://127.0.0.1/hooks", "http://10.0.0.5/hooks",
+		"http://169.254.169.254/latest/meta-data", "http://[::1]/hooks", "http://0.0.0.0/hooks"} {
+		// GIVEN
+		request := domain.WebhookSubscriptionRequest{URL: url, EventTypes: []domain.EventType{domain.UserCreated}}
+
+		// WHEN
+		_, err := _webhookUseCase.CreateSubscription(context.Background(), request)
+
+		// THEN
+		assert.NotNil(t, err, url)
+		assert.Equal(t, domain.ErrCodeValidationFailed, err.Code, url)
+		assert.Equal(t, "public_host", err.Details.([]domain.FieldViolation)[0].Rule, url)
+	}
+}
+
+func Test_Should_Hide_Secret_When_Subscription_Is_Read(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// WHEN
+	_webhookMockRepo.EXPECT().GetSubscription(gomock.Any(), uint(1)).Return(_subscription, nil)
+	subscription, err := _webhookUseCase.GetSubscription(context.Background(), 1)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Empty(t, subscription.Secret)
+}
+
+func Test_Should_Keep_Secret_When_Update_Has_None(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	active := false
+	request := domain.WebhookSubscriptionRequest{URL: "https://partner.example.com/v2", EventTypes: []domain.EventType{domain.UserUpdated}, Active: &active}
+
+	// WHEN
+	_webhookMockRepo.EXPECT().GetSubscription(gomock.Any(), uint(1)).Return(_subscription, nil)
+	_webhookMockRepo.EXPECT().UpdateSubscription(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, subscription domain.WebhookSubscription) (domain.WebhookSubscription, *domain.AppError) {
+			assert.Equal(t, testSecret, subscription.Secret)
+			assert.False(t, subscription.Active)
+			return subscription, nil
+		})
+	subscription, err := _webhookUseCase.UpdateSubscription(context.Background(), 1, request)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, "https://partner.example.com/v2", subscription.URL)
+	assert.Empty(t, subscription.Secret)
+}
+
+func Test_Should_Requeue_Dead_Letter_Delivery(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	delivery := dueDelivery(3)
+	delivery.Status = domain.DeliveryDeadLetter
+
+	// WHEN
+	_webhookMockRepo.EXPECT().GetDelivery(gomock.Any(), uint(1), uint64(7)).Return(delivery, nil)
+	_webhookMockRepo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).Return(nil)
+	redelivered, err := _webhookUseCase.Redeliver(context.Background(), 1, 7)
+
+	// THEN
+	assert.Nil(t, err)
+	assert.Equal(t, domain.DeliveryPending, redelivered.Status)
+	assert.Equal(t, 0, redelivered.Attempts)
+	assert.WithinDuration(t, time.Now(), redelivered.NextAttemptAt, time.Second)
+}
+
+func Test_Should_Create_Deliveries_For_Subscribed_Events_Only(t *testing.T) {
+	mockUseCaseSetup(t)
+
+	// GIVEN
+	sink := NewSink(_webhookMockRepo)
+	envelope := domain.EventEnvelope{ID: "e1", Type: domain.UserCreated, AggregateType: domain.UserAggregate, AggregateID: "1", Data: json.RawMessage(`{}`)}
+	other := domain.WebhookSubscription{ID: 2, Active: true, EventTypes: []domain.EventType{domain.UserUpdated}}
+
+	// WHEN
+	_webhookMockRepo.EXPECT().ListActiveSubscriptions(gomock.Any()).Return([]domain.WebhookSubscription{_subscription, other}, nil)
+	_webhookMockRepo.EXPECT().AddDeliveries(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, deliveries ...domain.WebhookDelivery) *domain.AppError {
+			assert.Len(t, deliveries, 1)
+			assert.Equal(t, uint(1), deliveries[0].SubscriptionID)
+			assert.Equal(t, domain.DeliveryPending, deliveries[0].Status)
+			return nil
+		})
+	err := sink.Publish(context.Background(), envelope)
+
+	// THEN
+	assert.Nil(t, err)
+}